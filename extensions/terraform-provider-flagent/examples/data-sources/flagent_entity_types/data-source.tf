@@ -0,0 +1 @@
+data "flagent_entity_types" "all" {}
@@ -0,0 +1,8 @@
+data "flagent_flags" "payments" {
+  tags    = ["team:payments"]
+  enabled = true
+}
+
+output "payments_flag_keys" {
+  value = data.flagent_flags.payments.flags[*].key
+}
@@ -0,0 +1,19 @@
+terraform {
+  required_providers {
+    flagent = {
+      source = "maxluxs/flagent"
+    }
+  }
+}
+
+# The endpoint and credentials may also come from FLAGENT_ENDPOINT, FLAGENT_API_KEY,
+# FLAGENT_TOKEN, FLAGENT_EMAIL and FLAGENT_PASSWORD.
+provider "flagent" {
+  endpoint = "http://localhost:18000/api/v1"
+  api_key  = var.flagent_api_key
+}
+
+variable "flagent_api_key" {
+  type      = string
+  sensitive = true
+}
@@ -0,0 +1 @@
+terraform import flagent_constraint.country 42/3/11
@@ -0,0 +1,7 @@
+resource "flagent_constraint" "country" {
+  flag_id    = flagent_flag.checkout.id
+  segment_id = flagent_segment.eu.id
+  property   = "country"
+  operator   = "IN"
+  value      = jsonencode(["DE", "FR", "ES"])
+}
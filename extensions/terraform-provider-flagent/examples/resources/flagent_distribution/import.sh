@@ -0,0 +1 @@
+terraform import flagent_distribution.eu 42/3
@@ -0,0 +1,8 @@
+resource "flagent_distribution" "eu" {
+  flag_id    = flagent_flag.checkout.id
+  segment_id = flagent_segment.eu.id
+  variants = [
+    { variant_id = flagent_variant.control.id, percent = 50 },
+    { variant_id = flagent_variant.treatment.id, percent = 50 },
+  ]
+}
@@ -0,0 +1,3 @@
+# By ID or by key
+terraform import flagent_flag.checkout 42
+terraform import flagent_flag.checkout new_checkout
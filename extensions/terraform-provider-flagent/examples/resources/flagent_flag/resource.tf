@@ -0,0 +1,7 @@
+resource "flagent_flag" "checkout" {
+  key         = "new_checkout"
+  description = "New checkout flow"
+  enabled     = true
+  entity_type = "user"
+  notes       = "Owned by the payments team"
+}
@@ -0,0 +1 @@
+terraform import flagent_segment.eu 42/3
@@ -0,0 +1,9 @@
+resource "flagent_segment" "eu" {
+  flag_id         = flagent_flag.checkout.id
+  description     = "EU users"
+  rollout_percent = 50
+
+  # A variant cannot be deleted while a segment distributes to it, so segments
+  # must be destroyed before the variants they use.
+  depends_on = [flagent_variant.control, flagent_variant.treatment]
+}
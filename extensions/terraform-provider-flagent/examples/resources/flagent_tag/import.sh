@@ -0,0 +1 @@
+terraform import flagent_tag.team 42/5
@@ -0,0 +1,4 @@
+resource "flagent_tag" "team" {
+  flag_id = flagent_flag.checkout.id
+  value   = "team:payments"
+}
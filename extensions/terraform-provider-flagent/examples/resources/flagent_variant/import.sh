@@ -0,0 +1 @@
+terraform import flagent_variant.treatment 42/7
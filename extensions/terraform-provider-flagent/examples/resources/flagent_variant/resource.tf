@@ -0,0 +1,10 @@
+resource "flagent_variant" "control" {
+  flag_id = flagent_flag.checkout.id
+  key     = "control"
+}
+
+resource "flagent_variant" "treatment" {
+  flag_id    = flagent_flag.checkout.id
+  key        = "treatment"
+  attachment = jsonencode({ button_color = "green" })
+}
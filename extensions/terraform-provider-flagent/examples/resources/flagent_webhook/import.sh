@@ -0,0 +1 @@
+terraform import flagent_webhook.audit 9
@@ -0,0 +1,6 @@
+resource "flagent_webhook" "audit" {
+  url     = "https://hooks.example.com/flagent"
+  events  = ["flag.created", "flag.updated", "flag.deleted"]
+  secret  = var.webhook_secret
+  enabled = true
+}
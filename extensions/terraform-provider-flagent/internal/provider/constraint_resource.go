@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+// constraintOperators are the operators the server accepts
+var constraintOperators = []string{
+	"EQ", "NEQ", "LT", "LTE", "GT", "GTE", "IN", "NOTIN", "EREG", "NEREG", "CONTAINS", "NOTCONTAINS",
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*constraintResource)(nil)
+	_ resource.ResourceWithImportState = (*constraintResource)(nil)
+)
+
+type constraintResource struct {
+	client *flagent.Client
+}
+
+type constraintResourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	FlagID    types.Int64  `tfsdk:"flag_id"`
+	SegmentID types.Int64  `tfsdk:"segment_id"`
+	Property  types.String `tfsdk:"property"`
+	Operator  types.String `tfsdk:"operator"`
+	Value     types.String `tfsdk:"value"`
+}
+
+func newConstraintResource() resource.Resource {
+	return &constraintResource{}
+}
+
+func (r *constraintResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_constraint"
+}
+
+func (r *constraintResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A constraint of a segment; an entity is in the segment if it matches all of them. " +
+			"Import with `<flag_id>/<segment_id>/<constraint_id>`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Constraint ID.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"flag_id":    flagIDAttribute(),
+			"segment_id": segmentIDAttribute(),
+			"property": schema.StringAttribute{
+				Description: "Entity context property to compare, e.g. `country`.",
+				Required:    true,
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"operator": schema.StringAttribute{
+				Description: "One of `EQ`, `NEQ`, `LT`, `LTE`, `GT`, `GTE`, `IN`, `NOTIN`, `EREG`, `NEREG`, `CONTAINS`, `NOTCONTAINS`.",
+				Required:    true,
+				Validators:  []validator.String{stringvalidator.OneOf(constraintOperators...)},
+			},
+			"value": schema.StringAttribute{
+				Description: "Value to compare with; `IN` and `NOTIN` take a comma-separated list.",
+				Required:    true,
+			},
+		},
+	}
+}
+
+// segmentIDAttribute is the segment_id attribute of the resources that belong to a segment
+func segmentIDAttribute() schema.Int64Attribute {
+	return schema.Int64Attribute{
+		Description:   "ID of the segment.",
+		Required:      true,
+		PlanModifiers: []planmodifier.Int64{int64planmodifier.RequiresReplace()},
+	}
+}
+
+func (r *constraintResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *constraintResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan constraintResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	constraint, err := r.client.CreateConstraint(ctx, plan.FlagID.ValueInt64(), plan.SegmentID.ValueInt64(), plan.input())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create constraint", err.Error())
+		return
+	}
+	plan.ID = types.StringValue(formatID(constraint.Id))
+	r.read(ctx, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *constraintResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state constraintResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !r.read(ctx, &state, &resp.Diagnostics) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// read refreshes model from the server, returning false if the constraint is gone
+func (r *constraintResource) read(ctx context.Context, model *constraintResourceModel, diags *diag.Diagnostics) bool {
+	constraintID := parseID(model.ID.ValueString(), diags)
+	if diags.HasError() {
+		return true
+	}
+	constraints, err := r.client.ListConstraints(ctx, model.FlagID.ValueInt64(), model.SegmentID.ValueInt64())
+	if isNotFound(err) {
+		return false
+	}
+	if err != nil {
+		diags.AddError("Unable to read constraint", err.Error())
+		return true
+	}
+	for _, c := range constraints {
+		if c.Id == constraintID {
+			model.Property = types.StringValue(c.Property)
+			model.Operator = types.StringValue(c.Operator)
+			model.Value = types.StringValue(c.Value)
+			return true
+		}
+	}
+	return false
+}
+
+func (r *constraintResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan constraintResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	constraintID := parseID(plan.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.client.UpdateConstraint(ctx, plan.FlagID.ValueInt64(), plan.SegmentID.ValueInt64(), constraintID, plan.input()); err != nil {
+		resp.Diagnostics.AddError("Unable to update constraint", err.Error())
+		return
+	}
+	r.read(ctx, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *constraintResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state constraintResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	constraintID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.client.DeleteConstraint(ctx, state.FlagID.ValueInt64(), state.SegmentID.ValueInt64(), constraintID)
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Unable to delete constraint", err.Error())
+	}
+}
+
+func (r *constraintResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importIDs(ctx, req, resp, "flag_id", "segment_id", "id")
+}
+
+func (m *constraintResourceModel) input() *flagent.ConstraintInput {
+	return &flagent.ConstraintInput{
+		Property: m.Property.ValueString(),
+		Operator: m.Operator.ValueString(),
+		Value:    m.Value.ValueString(),
+	}
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDataSources(t *testing.T) {
+	server := newFakeServer(t)
+	flags := server.providerConfig() + `
+resource "flagent_flag" "checkout" {
+  key         = "checkout_v2"
+  description = "New checkout flow"
+  enabled     = true
+  entity_type = "user"
+}
+
+resource "flagent_flag" "search" {
+  key         = "search_ranking"
+  description = "Ranking experiment"
+  entity_type = "session"
+}
+
+resource "flagent_tag" "checkout" {
+  flag_id = flagent_flag.checkout.id
+  value   = "team:payments"
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{Config: flags},
+			{
+				Config: flags + `
+data "flagent_flags" "payments" {
+  tags = ["team:payments"]
+}
+
+data "flagent_flags" "enabled" {
+  enabled = true
+}
+
+data "flagent_flags" "ranking" {
+  description_like = "Ranking"
+}
+
+data "flagent_entity_types" "all" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.flagent_flags.payments", "flags.#", "1"),
+					resource.TestCheckResourceAttr("data.flagent_flags.payments", "flags.0.key", "checkout_v2"),
+					resource.TestCheckResourceAttr("data.flagent_flags.payments", "flags.0.tags.0", "team:payments"),
+					resource.TestCheckResourceAttr("data.flagent_flags.enabled", "flags.#", "1"),
+					resource.TestCheckResourceAttr("data.flagent_flags.ranking", "flags.0.key", "search_ranking"),
+					resource.TestCheckResourceAttr("data.flagent_entity_types.all", "entity_types.#", "2"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+var (
+	_ resource.ResourceWithConfigure      = (*distributionResource)(nil)
+	_ resource.ResourceWithImportState    = (*distributionResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*distributionResource)(nil)
+)
+
+type distributionResource struct {
+	client *flagent.Client
+}
+
+type distributionResourceModel struct {
+	ID        types.String               `tfsdk:"id"`
+	FlagID    types.Int64                `tfsdk:"flag_id"`
+	SegmentID types.Int64                `tfsdk:"segment_id"`
+	Variants  []distributionVariantModel `tfsdk:"variants"`
+}
+
+type distributionVariantModel struct {
+	VariantID types.Int64 `tfsdk:"variant_id"`
+	Percent   types.Int64 `tfsdk:"percent"`
+}
+
+func newDistributionResource() resource.Resource {
+	return &distributionResource{}
+}
+
+func (r *distributionResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_distribution"
+}
+
+func (r *distributionResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "The variant distribution of a segment. The server requires a distribution to add up to 100, " +
+			"so destroying this resource only removes it from the state. Import with `<flag_id>/<segment_id>`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "ID of the segment.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"flag_id":    flagIDAttribute(),
+			"segment_id": segmentIDAttribute(),
+			"variants": schema.SetNestedAttribute{
+				Description: "Share of the segment's entities each variant gets; the percents add up to 100.",
+				Required:    true,
+				Validators:  []validator.Set{setvalidator.SizeAtLeast(1)},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"variant_id": schema.Int64Attribute{Required: true},
+						"percent": schema.Int64Attribute{
+							Required:   true,
+							Validators: []validator.Int64{int64validator.Between(0, 100)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *distributionResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config distributionResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	total := int64(0)
+	for _, v := range config.Variants {
+		if v.Percent.IsUnknown() {
+			return
+		}
+		total += v.Percent.ValueInt64()
+	}
+	if config.Variants != nil && total != 100 {
+		resp.Diagnostics.AddAttributeError(path.Root("variants"), "Invalid distribution",
+			fmt.Sprintf("the percents add up to %d, not 100", total))
+	}
+}
+
+func (r *distributionResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *distributionResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan distributionResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.replace(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *distributionResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state distributionResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !r.read(ctx, &state, &resp.Diagnostics) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// read refreshes model from the server, returning false if the segment has no distribution
+func (r *distributionResource) read(ctx context.Context, model *distributionResourceModel, diags *diag.Diagnostics) bool {
+	distributions, err := r.client.ListDistributions(ctx, model.FlagID.ValueInt64(), model.SegmentID.ValueInt64())
+	if isNotFound(err) {
+		return false
+	}
+	if err != nil {
+		diags.AddError("Unable to read distribution", err.Error())
+		return true
+	}
+	if len(distributions) == 0 {
+		return false
+	}
+	model.ID = types.StringValue(formatID(model.SegmentID.ValueInt64()))
+	model.Variants = make([]distributionVariantModel, len(distributions))
+	for i, d := range distributions {
+		model.Variants[i] = distributionVariantModel{VariantID: types.Int64Value(d.VariantID), Percent: types.Int64Value(d.Percent)}
+	}
+	return true
+}
+
+func (r *distributionResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan distributionResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.replace(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// replace sets the segment's distribution to model's and refreshes model
+func (r *distributionResource) replace(ctx context.Context, model *distributionResourceModel, diags *diag.Diagnostics) {
+	inputs := make([]flagent.DistributionInput, len(model.Variants))
+	for i, v := range model.Variants {
+		inputs[i] = flagent.DistributionInput{VariantID: v.VariantID.ValueInt64(), Percent: int(v.Percent.ValueInt64())}
+	}
+	if _, err := r.client.ReplaceDistributions(ctx, model.FlagID.ValueInt64(), model.SegmentID.ValueInt64(), inputs); err != nil {
+		diags.AddError("Unable to set distribution", err.Error())
+		return
+	}
+	r.read(ctx, model, diags)
+}
+
+// Delete leaves the distribution on the server, which cannot hold an empty one
+func (r *distributionResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+func (r *distributionResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importIDs(ctx, req, resp, "flag_id", "segment_id")
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+var _ datasource.DataSourceWithConfigure = (*entityTypesDataSource)(nil)
+
+type entityTypesDataSource struct {
+	client *flagent.Client
+}
+
+type entityTypesDataSourceModel struct {
+	EntityTypes []string `tfsdk:"entity_types"`
+}
+
+func newEntityTypesDataSource() datasource.DataSource {
+	return &entityTypesDataSource{}
+}
+
+func (d *entityTypesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_entity_types"
+}
+
+func (d *entityTypesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the entity types used by flags.",
+		Attributes: map[string]schema.Attribute{
+			"entity_types": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *entityTypesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	d.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (d *entityTypesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	entityTypes, err := d.client.ListEntityTypes(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to list entity types", err.Error())
+		return
+	}
+	if entityTypes == nil {
+		entityTypes = []string{}
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &entityTypesDataSourceModel{EntityTypes: entityTypes})...)
+}
@@ -0,0 +1,419 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+)
+
+// fakeServer is an in-memory Flagent API for the acceptance tests. It enforces the
+// server rules the provider depends on: distributions add up to 100, distributed
+// variants cannot be deleted, and archived flags disappear from reads.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	nextID   int64
+	flags    map[int64]*api.Flag
+	archived map[int64]*api.Flag
+	webhooks map[int64]map[string]interface{}
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	s := &fakeServer{
+		flags:    map[int64]*api.Flag{},
+		archived: map[int64]*api.Flag{},
+		webhooks: map[int64]map[string]interface{}{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /flags", s.listFlags)
+	mux.HandleFunc("POST /flags", s.createFlag)
+	mux.HandleFunc("GET /flags/entity_types", s.listEntityTypes)
+	mux.HandleFunc("GET /flags/{flag}", s.withFlag(s.getFlag))
+	mux.HandleFunc("PUT /flags/{flag}", s.withFlag(s.updateFlag))
+	mux.HandleFunc("PUT /flags/{flag}/enabled", s.withFlag(s.setFlagEnabled))
+	mux.HandleFunc("DELETE /flags/{flag}", s.withFlag(s.archiveFlag))
+	mux.HandleFunc("DELETE /flags/{flag}/permanent", s.withFlag(s.deleteFlag))
+	mux.HandleFunc("GET /flags/{flag}/segments", s.withFlag(s.listSegments))
+	mux.HandleFunc("POST /flags/{flag}/segments", s.withFlag(s.createSegment))
+	mux.HandleFunc("PUT /flags/{flag}/segments/{segment}", s.withSegment(s.updateSegment))
+	mux.HandleFunc("DELETE /flags/{flag}/segments/{segment}", s.withSegment(s.deleteSegment))
+	mux.HandleFunc("GET /flags/{flag}/segments/{segment}/constraints", s.withSegment(s.listConstraints))
+	mux.HandleFunc("POST /flags/{flag}/segments/{segment}/constraints", s.withSegment(s.createConstraint))
+	mux.HandleFunc("PUT /flags/{flag}/segments/{segment}/constraints/{constraint}", s.withSegment(s.updateConstraint))
+	mux.HandleFunc("DELETE /flags/{flag}/segments/{segment}/constraints/{constraint}", s.withSegment(s.deleteConstraint))
+	mux.HandleFunc("GET /flags/{flag}/segments/{segment}/distributions", s.withSegment(s.listDistributions))
+	mux.HandleFunc("PUT /flags/{flag}/segments/{segment}/distributions", s.withSegment(s.replaceDistributions))
+	mux.HandleFunc("GET /flags/{flag}/variants", s.withFlag(s.listVariants))
+	mux.HandleFunc("POST /flags/{flag}/variants", s.withFlag(s.createVariant))
+	mux.HandleFunc("PUT /flags/{flag}/variants/{variant}", s.withFlag(s.updateVariant))
+	mux.HandleFunc("DELETE /flags/{flag}/variants/{variant}", s.withFlag(s.deleteVariant))
+	mux.HandleFunc("GET /flags/{flag}/tags", s.withFlag(s.listTags))
+	mux.HandleFunc("POST /flags/{flag}/tags", s.withFlag(s.addTag))
+	mux.HandleFunc("DELETE /flags/{flag}/tags/{tag}", s.withFlag(s.removeTag))
+	mux.HandleFunc("GET /webhooks/{webhook}", s.getWebhook)
+	mux.HandleFunc("POST /webhooks", s.saveWebhook)
+	mux.HandleFunc("PUT /webhooks/{webhook}", s.saveWebhook)
+	mux.HandleFunc("DELETE /webhooks/{webhook}", s.deleteWebhook)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// providerConfig is the provider block pointing at the server
+func (s *fakeServer) providerConfig() string {
+	return `provider "flagent" {
+  endpoint = "` + s.URL + `"
+}
+`
+}
+
+func (s *fakeServer) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	writeJSON(w, map[string]string{"message": message})
+}
+
+func pathID(r *http.Request, name string) int64 {
+	id, _ := strconv.ParseInt(r.PathValue(name), 10, 64)
+	return id
+}
+
+func decode(r *http.Request, v interface{}) {
+	json.NewDecoder(r.Body).Decode(v)
+}
+
+func (s *fakeServer) withFlag(handler func(w http.ResponseWriter, r *http.Request, flag *api.Flag)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flag := s.flags[pathID(r, "flag")]
+		if flag == nil {
+			writeError(w, http.StatusNotFound, "flag not found")
+			return
+		}
+		handler(w, r, flag)
+	}
+}
+
+func (s *fakeServer) withSegment(handler func(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment)) http.HandlerFunc {
+	return s.withFlag(func(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+		for i := range flag.Segments {
+			if flag.Segments[i].Id == pathID(r, "segment") {
+				handler(w, r, flag, &flag.Segments[i])
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "segment not found")
+	})
+}
+
+func (s *fakeServer) listFlags(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	flags := []api.Flag{}
+	if query.Get("offset") != "" && query.Get("offset") != "0" {
+		writeJSON(w, flags)
+		return
+	}
+	for _, id := range s.sortedFlagIDs() {
+		flag := s.flags[id]
+		if key := query.Get("key"); key != "" && flag.Key != key {
+			continue
+		}
+		if like := query.Get("descriptionLike"); like != "" && !strings.Contains(flag.Description, like) {
+			continue
+		}
+		if enabled := query.Get("enabled"); enabled != "" && strconv.FormatBool(flag.Enabled) != enabled {
+			continue
+		}
+		if tags := query.Get("tags"); tags != "" && !slices.ContainsFunc(flag.Tags, func(tag api.Tag) bool {
+			return slices.Contains(strings.Split(tags, ","), tag.Value)
+		}) {
+			continue
+		}
+		flags = append(flags, *flag)
+	}
+	writeJSON(w, flags)
+}
+
+func (s *fakeServer) sortedFlagIDs() []int64 {
+	ids := make([]int64, 0, len(s.flags))
+	for id := range s.flags {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (s *fakeServer) createFlag(w http.ResponseWriter, r *http.Request) {
+	var body struct{ Key, Description string }
+	decode(r, &body)
+	for _, flag := range s.flags {
+		if flag.Key == body.Key {
+			writeError(w, http.StatusBadRequest, "flag key already exists")
+			return
+		}
+	}
+	flag := &api.Flag{Id: s.id(), Key: body.Key, Description: body.Description}
+	s.flags[flag.Id] = flag
+	writeJSON(w, flag)
+}
+
+func (s *fakeServer) listEntityTypes(w http.ResponseWriter, r *http.Request) {
+	entityTypes := []string{}
+	for _, id := range s.sortedFlagIDs() {
+		if v := s.flags[id].EntityType.Get(); v != nil && *v != "" && !slices.Contains(entityTypes, *v) {
+			entityTypes = append(entityTypes, *v)
+		}
+	}
+	writeJSON(w, entityTypes)
+}
+
+func (s *fakeServer) getFlag(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	writeJSON(w, flag)
+}
+
+func (s *fakeServer) updateFlag(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	var body struct {
+		Key, Description, EntityType, Notes *string
+		DataRecordsEnabled                  *bool
+	}
+	decode(r, &body)
+	if body.Key != nil {
+		flag.Key = *body.Key
+	}
+	if body.Description != nil {
+		flag.Description = *body.Description
+	}
+	if body.EntityType != nil {
+		flag.EntityType = *api.NewNullableString(body.EntityType)
+	}
+	if body.Notes != nil {
+		flag.Notes = *api.NewNullableString(body.Notes)
+	}
+	if body.DataRecordsEnabled != nil {
+		flag.DataRecordsEnabled = *body.DataRecordsEnabled
+	}
+	writeJSON(w, flag)
+}
+
+func (s *fakeServer) setFlagEnabled(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	var body struct{ Enabled bool }
+	decode(r, &body)
+	flag.Enabled = body.Enabled
+	writeJSON(w, flag)
+}
+
+func (s *fakeServer) archiveFlag(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	s.archived[flag.Id] = flag
+	delete(s.flags, flag.Id)
+}
+
+func (s *fakeServer) deleteFlag(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	delete(s.flags, flag.Id)
+}
+
+func (s *fakeServer) listSegments(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	segments := slices.Clone(flag.Segments)
+	slices.SortStableFunc(segments, func(a, b api.Segment) int { return int(a.Rank - b.Rank) })
+	writeJSON(w, append([]api.Segment{}, segments...))
+}
+
+func (s *fakeServer) createSegment(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	var body struct {
+		Description    string
+		RolloutPercent int64
+	}
+	decode(r, &body)
+	segment := api.Segment{Id: s.id(), FlagID: flag.Id, Description: body.Description, RolloutPercent: body.RolloutPercent, Rank: 999}
+	flag.Segments = append(flag.Segments, segment)
+	writeJSON(w, segment)
+}
+
+func (s *fakeServer) updateSegment(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment) {
+	var body struct {
+		Description    string
+		RolloutPercent int64
+	}
+	decode(r, &body)
+	segment.Description = body.Description
+	segment.RolloutPercent = body.RolloutPercent
+	writeJSON(w, segment)
+}
+
+func (s *fakeServer) deleteSegment(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment) {
+	id := segment.Id
+	flag.Segments = slices.DeleteFunc(flag.Segments, func(s api.Segment) bool { return s.Id == id })
+}
+
+func (s *fakeServer) listConstraints(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment) {
+	writeJSON(w, append([]api.Constraint{}, segment.Constraints...))
+}
+
+func (s *fakeServer) createConstraint(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment) {
+	var body struct{ Property, Operator, Value string }
+	decode(r, &body)
+	constraint := api.Constraint{Id: s.id(), SegmentID: segment.Id, Property: body.Property, Operator: body.Operator, Value: body.Value}
+	segment.Constraints = append(segment.Constraints, constraint)
+	writeJSON(w, constraint)
+}
+
+func (s *fakeServer) updateConstraint(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment) {
+	var body struct{ Property, Operator, Value string }
+	decode(r, &body)
+	for i := range segment.Constraints {
+		if c := &segment.Constraints[i]; c.Id == pathID(r, "constraint") {
+			c.Property, c.Operator, c.Value = body.Property, body.Operator, body.Value
+			writeJSON(w, c)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "constraint not found")
+}
+
+func (s *fakeServer) deleteConstraint(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment) {
+	id := pathID(r, "constraint")
+	segment.Constraints = slices.DeleteFunc(segment.Constraints, func(c api.Constraint) bool { return c.Id == id })
+}
+
+func (s *fakeServer) listDistributions(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment) {
+	writeJSON(w, append([]api.Distribution{}, segment.Distributions...))
+}
+
+func (s *fakeServer) replaceDistributions(w http.ResponseWriter, r *http.Request, flag *api.Flag, segment *api.Segment) {
+	var body struct {
+		Distributions []struct {
+			VariantID int64
+			Percent   int64
+		}
+	}
+	decode(r, &body)
+	total := int64(0)
+	distributions := []api.Distribution{}
+	for _, d := range body.Distributions {
+		i := slices.IndexFunc(flag.Variants, func(v api.Variant) bool { return v.Id == d.VariantID })
+		if i < 0 {
+			writeError(w, http.StatusBadRequest, "error finding variantID under this flag")
+			return
+		}
+		total += d.Percent
+		distributions = append(distributions, api.Distribution{
+			Id: s.id(), SegmentID: segment.Id, VariantID: d.VariantID,
+			VariantKey: *api.NewNullableString(&flag.Variants[i].Key), Percent: d.Percent,
+		})
+	}
+	if total != 100 {
+		writeError(w, http.StatusBadRequest, "the sum of distributions' percent is not 100")
+		return
+	}
+	segment.Distributions = distributions
+	writeJSON(w, distributions)
+}
+
+func (s *fakeServer) listVariants(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	writeJSON(w, append([]api.Variant{}, flag.Variants...))
+}
+
+func (s *fakeServer) createVariant(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	var body struct {
+		Key        string
+		Attachment map[string]interface{}
+	}
+	decode(r, &body)
+	variant := api.Variant{Id: s.id(), FlagID: flag.Id, Key: body.Key, Attachment: body.Attachment}
+	flag.Variants = append(flag.Variants, variant)
+	writeJSON(w, variant)
+}
+
+func (s *fakeServer) updateVariant(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	var body struct {
+		Key        string
+		Attachment map[string]interface{}
+	}
+	decode(r, &body)
+	for i := range flag.Variants {
+		if v := &flag.Variants[i]; v.Id == pathID(r, "variant") {
+			v.Key, v.Attachment = body.Key, body.Attachment
+			writeJSON(w, v)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "variant not found")
+}
+
+func (s *fakeServer) deleteVariant(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	id := pathID(r, "variant")
+	for _, segment := range flag.Segments {
+		for _, d := range segment.Distributions {
+			if d.VariantID == id && d.Percent > 0 {
+				writeError(w, http.StatusBadRequest, "variant is still distributed")
+				return
+			}
+		}
+	}
+	flag.Variants = slices.DeleteFunc(flag.Variants, func(v api.Variant) bool { return v.Id == id })
+}
+
+func (s *fakeServer) listTags(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	writeJSON(w, append([]api.Tag{}, flag.Tags...))
+}
+
+func (s *fakeServer) addTag(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	var body struct{ Value string }
+	decode(r, &body)
+	tag := api.Tag{Id: s.id(), Value: body.Value}
+	flag.Tags = append(flag.Tags, tag)
+	writeJSON(w, tag)
+}
+
+func (s *fakeServer) removeTag(w http.ResponseWriter, r *http.Request, flag *api.Flag) {
+	id := pathID(r, "tag")
+	flag.Tags = slices.DeleteFunc(flag.Tags, func(t api.Tag) bool { return t.Id == id })
+}
+
+func (s *fakeServer) getWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook := s.webhooks[pathID(r, "webhook")]
+	if webhook == nil {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	writeJSON(w, webhook)
+}
+
+func (s *fakeServer) saveWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook := map[string]interface{}{}
+	decode(r, &webhook)
+	id := pathID(r, "webhook")
+	if r.Method == http.MethodPost {
+		id = s.id()
+	} else if s.webhooks[id] == nil {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	webhook["id"] = id
+	s.webhooks[id] = webhook
+	writeJSON(w, webhook)
+}
+
+func (s *fakeServer) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	delete(s.webhooks, pathID(r, "webhook"))
+}
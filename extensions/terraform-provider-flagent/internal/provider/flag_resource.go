@@ -0,0 +1,280 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+// keyPattern is the server's rule for flag and variant keys
+var keyPattern = regexp.MustCompile(`^[\w\-/.:]{1,63}$`)
+
+var keyValidators = []validator.String{
+	stringvalidator.RegexMatches(keyPattern, "must be 1 to 63 letters, digits, '_', '-', '/', '.' or ':'"),
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*flagResource)(nil)
+	_ resource.ResourceWithImportState = (*flagResource)(nil)
+)
+
+type flagResource struct {
+	client *flagent.Client
+}
+
+type flagResourceModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Key                types.String `tfsdk:"key"`
+	Description        types.String `tfsdk:"description"`
+	Enabled            types.Bool   `tfsdk:"enabled"`
+	EntityType         types.String `tfsdk:"entity_type"`
+	Notes              types.String `tfsdk:"notes"`
+	DataRecordsEnabled types.Bool   `tfsdk:"data_records_enabled"`
+	PermanentlyDelete  types.Bool   `tfsdk:"permanently_delete"`
+}
+
+func newFlagResource() resource.Resource {
+	return &flagResource{}
+}
+
+func (r *flagResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_flag"
+}
+
+func (r *flagResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A feature flag. Its segments, variants and tags are separate resources. " +
+			"Import with the flag ID or key.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Flag ID.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"key": schema.StringAttribute{
+				Description: "Unique flag key used in evaluations.",
+				Required:    true,
+				Validators:  keyValidators,
+			},
+			"description": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(""),
+			},
+			"enabled": schema.BoolAttribute{
+				Description: "Whether the flag is evaluated.",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"entity_type": schema.StringAttribute{
+				Description: "Type of the entities the flag is evaluated for, e.g. `user`.",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(""),
+			},
+			"notes": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(""),
+			},
+			"data_records_enabled": schema.BoolAttribute{
+				Description: "Whether evaluations of the flag are recorded.",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"permanently_delete": schema.BoolAttribute{
+				Description: "Delete the flag permanently on destroy instead of archiving it.",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+		},
+	}
+}
+
+func (r *flagResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *flagResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan flagResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	flag, err := r.client.CreateFlag(ctx, &flagent.CreateFlagInput{
+		Key:         plan.Key.ValueString(),
+		Description: plan.Description.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create flag", err.Error())
+		return
+	}
+	// Save the ID first so a failure below leaves the flag in state to fix or destroy
+	plan.ID = types.StringValue(formatID(flag.Id))
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), plan.ID)...)
+
+	if plan.EntityType.ValueString() != "" || plan.Notes.ValueString() != "" || plan.DataRecordsEnabled.ValueBool() {
+		entityType, notes, dataRecords := plan.EntityType.ValueString(), plan.Notes.ValueString(), plan.DataRecordsEnabled.ValueBool()
+		if _, err := r.client.UpdateFlag(ctx, flag.Id, &flagent.UpdateFlagInput{
+			EntityType:         &entityType,
+			Notes:              &notes,
+			DataRecordsEnabled: &dataRecords,
+		}); err != nil {
+			resp.Diagnostics.AddError("Unable to update flag", err.Error())
+			return
+		}
+	}
+	if plan.Enabled.ValueBool() {
+		if _, err := r.client.SetFlagEnabled(ctx, flag.Id, true); err != nil {
+			resp.Diagnostics.AddError("Unable to enable flag", err.Error())
+			return
+		}
+	}
+	r.read(ctx, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *flagResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state flagResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !r.read(ctx, &state, &resp.Diagnostics) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// read refreshes model from the server, returning false if the flag is gone
+func (r *flagResource) read(ctx context.Context, model *flagResourceModel, diags *diag.Diagnostics) bool {
+	flagID := parseID(model.ID.ValueString(), diags)
+	if diags.HasError() {
+		return true
+	}
+	flag, err := r.client.GetFlag(ctx, flagID)
+	if isNotFound(err) {
+		return false
+	}
+	if err != nil {
+		diags.AddError("Unable to read flag", err.Error())
+		return true
+	}
+	model.Key = types.StringValue(flag.Key)
+	model.Description = types.StringValue(flag.Description)
+	model.Enabled = types.BoolValue(flag.Enabled)
+	model.EntityType = types.StringValue(stringValue(flag.EntityType))
+	model.Notes = types.StringValue(stringValue(flag.Notes))
+	model.DataRecordsEnabled = types.BoolValue(flag.DataRecordsEnabled)
+	if model.PermanentlyDelete.IsNull() {
+		model.PermanentlyDelete = types.BoolValue(false)
+	}
+	return true
+}
+
+func (r *flagResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state flagResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	flagID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	input := &flagent.UpdateFlagInput{}
+	changed := false
+	setString := func(field **string, planned, current types.String) {
+		if !planned.Equal(current) {
+			value := planned.ValueString()
+			*field = &value
+			changed = true
+		}
+	}
+	setString(&input.Key, plan.Key, state.Key)
+	setString(&input.Description, plan.Description, state.Description)
+	setString(&input.EntityType, plan.EntityType, state.EntityType)
+	setString(&input.Notes, plan.Notes, state.Notes)
+	if !plan.DataRecordsEnabled.Equal(state.DataRecordsEnabled) {
+		dataRecords := plan.DataRecordsEnabled.ValueBool()
+		input.DataRecordsEnabled = &dataRecords
+		changed = true
+	}
+	if changed {
+		if _, err := r.client.UpdateFlag(ctx, flagID, input); err != nil {
+			resp.Diagnostics.AddError("Unable to update flag", err.Error())
+			return
+		}
+	}
+	if !plan.Enabled.Equal(state.Enabled) {
+		if _, err := r.client.SetFlagEnabled(ctx, flagID, plan.Enabled.ValueBool()); err != nil {
+			resp.Diagnostics.AddError("Unable to enable or disable flag", err.Error())
+			return
+		}
+	}
+
+	plan.ID = state.ID
+	r.read(ctx, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *flagResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state flagResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	flagID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var err error
+	if state.PermanentlyDelete.ValueBool() {
+		err = r.client.PermanentlyDeleteFlag(ctx, flagID)
+	} else {
+		err = r.client.DeleteFlag(ctx, flagID)
+	}
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Unable to delete flag", err.Error())
+	}
+}
+
+// ImportState accepts a flag ID or key
+func (r *flagResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if _, err := strconv.ParseInt(req.ID, 10, 64); err == nil {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+		return
+	}
+	flags, err := r.client.ListFlags(ctx, &flagent.ListFlagsOptions{Key: req.ID, Limit: 1})
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to look up flag", err.Error())
+		return
+	}
+	if len(flags) == 0 {
+		resp.Diagnostics.AddError("Flag not found", fmt.Sprintf("no flag with ID or key %q", req.ID))
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), formatID(flags[0].Id))...)
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccFlagResource(t *testing.T) {
+	server := newFakeServer(t)
+	config := func(description string, enabled bool) string {
+		return server.providerConfig() + fmt.Sprintf(`
+resource "flagent_flag" "checkout" {
+  key         = "checkout_v2"
+  description = %q
+  enabled     = %t
+  entity_type = "user"
+}
+`, description, enabled)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProviders,
+		CheckDestroy: func(*terraform.State) error {
+			if len(server.flags) > 0 || len(server.archived) != 1 {
+				return fmt.Errorf("flag was not archived")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config("New checkout", true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("flagent_flag.checkout", "id", "1"),
+					resource.TestCheckResourceAttr("flagent_flag.checkout", "key", "checkout_v2"),
+					resource.TestCheckResourceAttr("flagent_flag.checkout", "enabled", "true"),
+					resource.TestCheckResourceAttr("flagent_flag.checkout", "entity_type", "user"),
+					resource.TestCheckResourceAttr("flagent_flag.checkout", "notes", ""),
+				),
+			},
+			{
+				ResourceName:      "flagent_flag.checkout",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "flagent_flag.checkout",
+				ImportState:       true,
+				ImportStateId:     "checkout_v2",
+				ImportStateVerify: true,
+			},
+			{
+				Config: config("Checkout v2", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("flagent_flag.checkout", "id", "1"),
+					resource.TestCheckResourceAttr("flagent_flag.checkout", "description", "Checkout v2"),
+					resource.TestCheckResourceAttr("flagent_flag.checkout", "enabled", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFlagResourcePermanentDelete(t *testing.T) {
+	server := newFakeServer(t)
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProviders,
+		CheckDestroy: func(*terraform.State) error {
+			if len(server.flags)+len(server.archived) > 0 {
+				return fmt.Errorf("flag was not deleted permanently")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{{
+			Config: server.providerConfig() + `
+resource "flagent_flag" "temporary" {
+  key                = "temporary_banner"
+  permanently_delete = true
+}
+`,
+			Check: resource.TestCheckResourceAttr("flagent_flag.temporary", "enabled", "false"),
+		}},
+	})
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+var _ datasource.DataSourceWithConfigure = (*flagsDataSource)(nil)
+
+type flagsDataSource struct {
+	client *flagent.Client
+}
+
+type flagsDataSourceModel struct {
+	Key             types.String          `tfsdk:"key"`
+	DescriptionLike types.String          `tfsdk:"description_like"`
+	Tags            []string              `tfsdk:"tags"`
+	Enabled         types.Bool            `tfsdk:"enabled"`
+	Flags           []flagDataSourceModel `tfsdk:"flags"`
+}
+
+type flagDataSourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	Key         types.String `tfsdk:"key"`
+	Description types.String `tfsdk:"description"`
+	Enabled     types.Bool   `tfsdk:"enabled"`
+	EntityType  types.String `tfsdk:"entity_type"`
+	Tags        []string     `tfsdk:"tags"`
+}
+
+func newFlagsDataSource() datasource.DataSource {
+	return &flagsDataSource{}
+}
+
+func (d *flagsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_flags"
+}
+
+func (d *flagsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the active flags, optionally filtered.",
+		Attributes: map[string]schema.Attribute{
+			"key": schema.StringAttribute{
+				Description: "Only the flag with this key.",
+				Optional:    true,
+			},
+			"description_like": schema.StringAttribute{
+				Description: "Only flags whose description contains this text.",
+				Optional:    true,
+			},
+			"tags": schema.ListAttribute{
+				Description: "Only flags with any of these tags.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"enabled": schema.BoolAttribute{
+				Description: "Only enabled or only disabled flags.",
+				Optional:    true,
+			},
+			"flags": schema.ListNestedAttribute{
+				Computed: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id":          schema.StringAttribute{Computed: true},
+						"key":         schema.StringAttribute{Computed: true},
+						"description": schema.StringAttribute{Computed: true},
+						"enabled":     schema.BoolAttribute{Computed: true},
+						"entity_type": schema.StringAttribute{Computed: true},
+						"tags": schema.ListAttribute{
+							ElementType: types.StringType,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *flagsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	d.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (d *flagsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config flagsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	opts := &flagent.ListFlagsOptions{
+		Preload:         true,
+		Key:             config.Key.ValueString(),
+		DescriptionLike: config.DescriptionLike.ValueString(),
+		Tags:            config.Tags,
+	}
+	if !config.Enabled.IsNull() {
+		enabled := config.Enabled.ValueBool()
+		opts.Enabled = &enabled
+	}
+
+	config.Flags = []flagDataSourceModel{}
+	it := d.client.IterateFlags(ctx, opts)
+	for it.Next() {
+		flag := it.Flag()
+		tags := make([]string, len(flag.Tags))
+		for i, tag := range flag.Tags {
+			tags[i] = tag.Value
+		}
+		config.Flags = append(config.Flags, flagDataSourceModel{
+			ID:          types.StringValue(formatID(flag.Id)),
+			Key:         types.StringValue(flag.Key),
+			Description: types.StringValue(flag.Description),
+			Enabled:     types.BoolValue(flag.Enabled),
+			EntityType:  types.StringValue(stringValue(flag.EntityType)),
+			Tags:        tags,
+		})
+	}
+	if err := it.Err(); err != nil {
+		resp.Diagnostics.AddError("Unable to list flags", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+)
+
+// clientFrom returns the client the provider passed to a resource or data source. The
+// data is nil while Terraform validates configuration before the provider is configured.
+func clientFrom(data any, diags *diag.Diagnostics) *flagent.Client {
+	if data == nil {
+		return nil
+	}
+	client, ok := data.(*flagent.Client)
+	if !ok {
+		diags.AddError("Unexpected provider data", fmt.Sprintf("expected *flagent.Client, got %T", data))
+	}
+	return client
+}
+
+// isNotFound reports whether err means the object is gone from the server
+func isNotFound(err error) bool {
+	return errors.Is(err, flagent.ErrNotFound) || errors.Is(err, flagent.ErrFlagNotFound)
+}
+
+// stringValue returns the value of a nullable string, or "" if it is null
+func stringValue(s api.NullableString) string {
+	if v := s.Get(); v != nil {
+		return *v
+	}
+	return ""
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// parseID parses the decimal ID stored in a resource's id attribute
+func parseID(id string, diags *diag.Diagnostics) int64 {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		diags.AddError("Invalid resource ID", fmt.Sprintf("ID %q is not a number", id))
+	}
+	return n
+}
+
+// importIDs parses an import ID of the form "1/2/3" into the attributes names. The
+// attribute "id" is stored as a string, the others as numbers.
+func importIDs(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse, names ...string) {
+	parts := strings.Split(req.ID, "/")
+	format := "<" + strings.Join(names, ">/<") + ">"
+	if len(parts) != len(names) {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("expected %s, got %q", format, req.ID))
+		return
+	}
+	for i, name := range names {
+		n, err := strconv.ParseInt(parts[i], 10, 64)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("expected %s with numeric IDs, got %q", format, req.ID))
+			return
+		}
+		if name == "id" {
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(name), formatID(n))...)
+		} else {
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(name), n)...)
+		}
+	}
+}
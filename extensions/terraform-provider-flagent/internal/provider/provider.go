@@ -0,0 +1,140 @@
+// Package provider implements the Flagent Terraform provider on top of the Go SDK
+package provider
+
+import (
+	"context"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+// Environment variables consulted for provider settings missing from the configuration
+const (
+	envEndpoint = "FLAGENT_ENDPOINT"
+	envAPIKey   = "FLAGENT_API_KEY"
+	envToken    = "FLAGENT_TOKEN"
+	envEmail    = "FLAGENT_EMAIL"
+	envPassword = "FLAGENT_PASSWORD"
+)
+
+var _ provider.Provider = (*flagentProvider)(nil)
+
+type flagentProvider struct {
+	version string
+}
+
+type flagentProviderModel struct {
+	Endpoint types.String `tfsdk:"endpoint"`
+	APIKey   types.String `tfsdk:"api_key"`
+	Token    types.String `tfsdk:"token"`
+	Email    types.String `tfsdk:"email"`
+	Password types.String `tfsdk:"password"`
+}
+
+// New returns a constructor for the provider, as providerserver.Serve expects
+func New(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &flagentProvider{version: version}
+	}
+}
+
+func (p *flagentProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "flagent"
+	resp.Version = p.version
+}
+
+func (p *flagentProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages Flagent feature flags, their segments, constraints, variants, distributions and tags, and webhooks.",
+		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				Description: "API base URL, e.g. `https://flagent.example.com/api/v1`. Defaults to `" + envEndpoint + "`.",
+				Optional:    true,
+			},
+			"api_key": schema.StringAttribute{
+				Description: "Tenant API key, sent as `X-API-Key`. Defaults to `" + envAPIKey + "`.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"token": schema.StringAttribute{
+				Description: "Bearer token, sent as `Authorization: Bearer`. Defaults to `" + envToken + "`.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"email": schema.StringAttribute{
+				Description: "User to log in as at `/auth/login`, so audit logs show who made a change. Defaults to `" + envEmail + "`.",
+				Optional:    true,
+			},
+			"password": schema.StringAttribute{
+				Description: "Password of `email`. Defaults to `" + envPassword + "`.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (p *flagentProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var config flagentProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	endpoint := stringOrEnv(config.Endpoint, envEndpoint)
+	if endpoint == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("endpoint"), "Missing Flagent endpoint",
+			"Set the endpoint attribute or the "+envEndpoint+" environment variable to the API base URL.")
+		return
+	}
+	opts := []flagent.ClientOption{
+		flagent.WithTenantAPIKey(stringOrEnv(config.APIKey, envAPIKey)),
+		flagent.WithAPIKey(stringOrEnv(config.Token, envToken)),
+	}
+	email, password := stringOrEnv(config.Email, envEmail), stringOrEnv(config.Password, envPassword)
+	if email != "" {
+		opts = append(opts, flagent.WithLogin(email, password))
+	}
+
+	client, err := flagent.NewClient(endpoint, opts...)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create Flagent client", err.Error())
+		return
+	}
+	resp.DataSourceData = client
+	resp.ResourceData = client
+}
+
+func (p *flagentProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		newFlagResource,
+		newSegmentResource,
+		newConstraintResource,
+		newVariantResource,
+		newDistributionResource,
+		newTagResource,
+		newWebhookResource,
+	}
+}
+
+func (p *flagentProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		newFlagsDataSource,
+		newEntityTypesDataSource,
+	}
+}
+
+// stringOrEnv returns the configured value, or the environment variable if it is unset
+func stringOrEnv(value types.String, env string) string {
+	if !value.IsNull() && !value.IsUnknown() {
+		return value.ValueString()
+	}
+	return os.Getenv(env)
+}
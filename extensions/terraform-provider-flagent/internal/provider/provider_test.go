@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// testAccProviders serves the provider in-process to the acceptance tests, which run
+// Terraform against a fakeServer when TF_ACC is set
+var testAccProviders = map[string]func() (tfprotov6.ProviderServer, error){
+	"flagent": providerserver.NewProtocol6WithError(New("test")()),
+}
+
+// importID returns an ImportStateIdFunc joining attributes of a resource in state with "/"
+func importID(address string, attributes ...string) func(*terraform.State) (string, error) {
+	return func(state *terraform.State) (string, error) {
+		rs, ok := state.RootModule().Resources[address]
+		if !ok {
+			return "", fmt.Errorf("%s not in state", address)
+		}
+		id := ""
+		for i, name := range attributes {
+			if i > 0 {
+				id += "/"
+			}
+			id += rs.Primary.Attributes[name]
+		}
+		return id, nil
+	}
+}
+
+func TestProviderSchemas(t *testing.T) {
+	ctx := context.Background()
+	p := New("test")()
+	for _, newResource := range p.Resources(ctx) {
+		r := newResource()
+		var meta resource.MetadataResponse
+		r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "flagent"}, &meta)
+		var resp resource.SchemaResponse
+		r.Schema(ctx, resource.SchemaRequest{}, &resp)
+		if diags := resp.Schema.ValidateImplementation(ctx); diags.HasError() {
+			t.Errorf("%s: %v", meta.TypeName, diags)
+		}
+	}
+	for _, newDataSource := range p.DataSources(ctx) {
+		d := newDataSource()
+		var meta datasource.MetadataResponse
+		d.Metadata(ctx, datasource.MetadataRequest{ProviderTypeName: "flagent"}, &meta)
+		var resp datasource.SchemaResponse
+		d.Schema(ctx, datasource.SchemaRequest{}, &resp)
+		if diags := resp.Schema.ValidateImplementation(ctx); diags.HasError() {
+			t.Errorf("%s: %v", meta.TypeName, diags)
+		}
+	}
+}
+
+// resourceSchema returns the schema of a resource
+func resourceSchema(t *testing.T, r resource.Resource) schema.Schema {
+	t.Helper()
+	var resp resource.SchemaResponse
+	r.Schema(context.Background(), resource.SchemaRequest{}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("schema: %v", resp.Diagnostics)
+	}
+	return resp.Schema
+}
+
+// validateAttribute runs the validators of a top-level attribute on value
+func validateAttribute(t *testing.T, s schema.Schema, name string, value attr.Value) diag.Diagnostics {
+	t.Helper()
+	ctx := context.Background()
+	var diags diag.Diagnostics
+	switch a := s.Attributes[name].(type) {
+	case schema.StringAttribute:
+		for _, v := range a.Validators {
+			resp := &validator.StringResponse{}
+			v.ValidateString(ctx, validator.StringRequest{Path: path.Root(name), ConfigValue: value.(types.String)}, resp)
+			diags.Append(resp.Diagnostics...)
+		}
+	case schema.Int64Attribute:
+		for _, v := range a.Validators {
+			resp := &validator.Int64Response{}
+			v.ValidateInt64(ctx, validator.Int64Request{Path: path.Root(name), ConfigValue: value.(types.Int64)}, resp)
+			diags.Append(resp.Diagnostics...)
+		}
+	case schema.SetAttribute:
+		for _, v := range a.Validators {
+			resp := &validator.SetResponse{}
+			v.ValidateSet(ctx, validator.SetRequest{Path: path.Root(name), ConfigValue: value.(types.Set)}, resp)
+			diags.Append(resp.Diagnostics...)
+		}
+	default:
+		t.Fatalf("no validators for attribute %s (%T)", name, a)
+	}
+	return diags
+}
+
+func TestResourceValidators(t *testing.T) {
+	events := func(values ...string) types.Set {
+		elements := make([]attr.Value, len(values))
+		for i, v := range values {
+			elements[i] = types.StringValue(v)
+		}
+		return types.SetValueMust(types.StringType, elements)
+	}
+	schemas := map[string]schema.Schema{
+		"flag":       resourceSchema(t, newFlagResource()),
+		"variant":    resourceSchema(t, newVariantResource()),
+		"tag":        resourceSchema(t, newTagResource()),
+		"constraint": resourceSchema(t, newConstraintResource()),
+		"segment":    resourceSchema(t, newSegmentResource()),
+		"webhook":    resourceSchema(t, newWebhookResource()),
+	}
+	for _, tc := range []struct {
+		resource, attribute string
+		value               attr.Value
+		valid               bool
+	}{
+		// Keys follow the server's rule: ^[\w\d-/\.:]+$, at most 63 characters
+		{"flag", "key", types.StringValue("checkout_v2"), true},
+		{"flag", "key", types.StringValue("Checkout.V2"), true},
+		{"flag", "key", types.StringValue("release_1.2"), true},
+		{"flag", "key", types.StringValue("team/checkout:v2"), true},
+		{"flag", "key", types.StringValue(strings.Repeat("k", 63)), true},
+		{"flag", "key", types.StringValue(strings.Repeat("k", 64)), false},
+		{"flag", "key", types.StringValue(""), false},
+		{"flag", "key", types.StringValue("new checkout"), false},
+		{"flag", "key", types.StringValue("checkout=v2"), false},
+		{"variant", "key", types.StringValue("on"), true},
+		{"variant", "key", types.StringValue("Treatment.B"), true},
+		{"variant", "key", types.StringValue("variant b"), false},
+		{"tag", "value", types.StringValue("team:payments"), true},
+		{"tag", "value", types.StringValue("managed by terraform"), true},
+		{"tag", "value", types.StringValue("owner=me"), false},
+		{"constraint", "operator", types.StringValue("EQ"), true},
+		{"constraint", "operator", types.StringValue("LIKE"), false},
+		{"constraint", "property", types.StringValue(""), false},
+		{"segment", "rollout_percent", types.Int64Value(100), true},
+		{"segment", "rollout_percent", types.Int64Value(101), false},
+		{"webhook", "events", events("flag.created", "anomaly.detected"), true},
+		{"webhook", "events", events(), false},
+		{"webhook", "events", events("flag.archived"), false},
+	} {
+		diags := validateAttribute(t, schemas[tc.resource], tc.attribute, tc.value)
+		if diags.HasError() == tc.valid {
+			t.Errorf("%s.%s = %v: valid = %t, diagnostics: %v", tc.resource, tc.attribute, tc.value, !diags.HasError(), diags)
+		}
+	}
+}
+
+func TestVariantAttachmentValidation(t *testing.T) {
+	ctx := context.Background()
+	r := newVariantResource().(*variantResource)
+	s := resourceSchema(t, r)
+	typ := s.Type().TerraformType(ctx).(tftypes.Object)
+	for attachment, valid := range map[string]bool{
+		`{"color": "blue"}`: true,
+		`["blue"]`:          false,
+		`{"color":`:         false,
+	} {
+		values := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+		for name, attrType := range typ.AttributeTypes {
+			values[name] = tftypes.NewValue(attrType, nil)
+		}
+		values["attachment"] = tftypes.NewValue(tftypes.String, attachment)
+		req := resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: s, Raw: tftypes.NewValue(typ, values)}}
+		var resp resource.ValidateConfigResponse
+		r.ValidateConfig(ctx, req, &resp)
+		if resp.Diagnostics.HasError() == valid {
+			t.Errorf("attachment %s: valid = %t, diagnostics: %v", attachment, !resp.Diagnostics.HasError(), resp.Diagnostics)
+		}
+	}
+}
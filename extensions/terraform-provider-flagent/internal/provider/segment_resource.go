@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+var (
+	_ resource.ResourceWithConfigure   = (*segmentResource)(nil)
+	_ resource.ResourceWithImportState = (*segmentResource)(nil)
+)
+
+type segmentResource struct {
+	client *flagent.Client
+}
+
+type segmentResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	FlagID         types.Int64  `tfsdk:"flag_id"`
+	Description    types.String `tfsdk:"description"`
+	RolloutPercent types.Int64  `tfsdk:"rollout_percent"`
+	Rank           types.Int64  `tfsdk:"rank"`
+}
+
+func newSegmentResource() resource.Resource {
+	return &segmentResource{}
+}
+
+func (r *segmentResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_segment"
+}
+
+func (r *segmentResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A segment of a flag. Segments are evaluated in rank order and new segments " +
+			"are ranked last, in the order they are created. Import with `<flag_id>/<segment_id>`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Segment ID.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"flag_id":     flagIDAttribute(),
+			"description": schema.StringAttribute{Required: true},
+			"rollout_percent": schema.Int64Attribute{
+				Description: "Share of the entities matching the constraints that get a variant (0 to 100).",
+				Required:    true,
+				Validators:  []validator.Int64{int64validator.Between(0, 100)},
+			},
+			"rank": schema.Int64Attribute{
+				Description:   "Evaluation order of the segment within its flag.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+		},
+	}
+}
+
+// flagIDAttribute is the flag_id attribute of the resources that belong to a flag
+func flagIDAttribute() schema.Int64Attribute {
+	return schema.Int64Attribute{
+		Description:   "ID of the flag.",
+		Required:      true,
+		PlanModifiers: []planmodifier.Int64{int64planmodifier.RequiresReplace()},
+	}
+}
+
+func (r *segmentResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *segmentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan segmentResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	segment, err := r.client.CreateSegment(ctx, plan.FlagID.ValueInt64(), plan.input())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create segment", err.Error())
+		return
+	}
+	plan.ID = types.StringValue(formatID(segment.Id))
+	r.read(ctx, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *segmentResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state segmentResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !r.read(ctx, &state, &resp.Diagnostics) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// read refreshes model from the server, returning false if the segment is gone
+func (r *segmentResource) read(ctx context.Context, model *segmentResourceModel, diags *diag.Diagnostics) bool {
+	segmentID := parseID(model.ID.ValueString(), diags)
+	if diags.HasError() {
+		return true
+	}
+	segments, err := r.client.ListSegments(ctx, model.FlagID.ValueInt64())
+	if isNotFound(err) {
+		return false
+	}
+	if err != nil {
+		diags.AddError("Unable to read segment", err.Error())
+		return true
+	}
+	for _, s := range segments {
+		if s.Id == segmentID {
+			model.Description = types.StringValue(s.Description)
+			model.RolloutPercent = types.Int64Value(s.RolloutPercent)
+			model.Rank = types.Int64Value(s.Rank)
+			return true
+		}
+	}
+	return false
+}
+
+func (r *segmentResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan segmentResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	segmentID := parseID(plan.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.client.UpdateSegment(ctx, plan.FlagID.ValueInt64(), segmentID, plan.input()); err != nil {
+		resp.Diagnostics.AddError("Unable to update segment", err.Error())
+		return
+	}
+	r.read(ctx, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *segmentResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state segmentResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	segmentID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.DeleteSegment(ctx, state.FlagID.ValueInt64(), segmentID); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Unable to delete segment", err.Error())
+	}
+}
+
+func (r *segmentResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importIDs(ctx, req, resp, "flag_id", "id")
+}
+
+func (m *segmentResourceModel) input() *flagent.SegmentInput {
+	return &flagent.SegmentInput{Description: m.Description.ValueString(), RolloutPercent: int(m.RolloutPercent.ValueInt64())}
+}
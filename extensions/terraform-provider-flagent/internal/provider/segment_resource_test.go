@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// rolloutConfig is a flag with two variants split 50/50 (or as given) over one
+// constrained segment, the layout most experiments use
+func rolloutConfig(server *fakeServer, rollout, control int, country string) string {
+	return server.providerConfig() + fmt.Sprintf(`
+resource "flagent_flag" "checkout" {
+  key = "checkout_v2"
+}
+
+resource "flagent_variant" "control" {
+  flag_id = flagent_flag.checkout.id
+  key     = "control"
+}
+
+resource "flagent_variant" "treatment" {
+  flag_id    = flagent_flag.checkout.id
+  key        = "treatment"
+  attachment = jsonencode({ color = "green" })
+}
+
+resource "flagent_segment" "eu" {
+  flag_id         = flagent_flag.checkout.id
+  description     = "EU users"
+  rollout_percent = %d
+
+  depends_on = [flagent_variant.control, flagent_variant.treatment]
+}
+
+resource "flagent_constraint" "country" {
+  flag_id    = flagent_flag.checkout.id
+  segment_id = flagent_segment.eu.id
+  property   = "country"
+  operator   = "IN"
+  value      = %q
+}
+
+resource "flagent_distribution" "eu" {
+  flag_id    = flagent_flag.checkout.id
+  segment_id = flagent_segment.eu.id
+  variants = [
+    { variant_id = flagent_variant.control.id, percent = %d },
+    { variant_id = flagent_variant.treatment.id, percent = %d },
+  ]
+}
+
+resource "flagent_tag" "team" {
+  flag_id = flagent_flag.checkout.id
+  value   = "team:payments"
+}
+`, rollout, country, control, 100-control)
+}
+
+func TestAccRolloutResources(t *testing.T) {
+	server := newFakeServer(t)
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: rolloutConfig(server, 50, 50, `["DE","FR"]`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("flagent_segment.eu", "rollout_percent", "50"),
+					resource.TestCheckResourceAttr("flagent_segment.eu", "rank", "999"),
+					resource.TestCheckResourceAttr("flagent_constraint.country", "operator", "IN"),
+					resource.TestCheckResourceAttr("flagent_variant.control", "attachment", ""),
+					resource.TestCheckResourceAttr("flagent_variant.treatment", "attachment", `{"color":"green"}`),
+					resource.TestCheckResourceAttr("flagent_distribution.eu", "variants.#", "2"),
+					resource.TestCheckTypeSetElemNestedAttrs("flagent_distribution.eu", "variants.*", map[string]string{"percent": "50"}),
+					resource.TestCheckResourceAttr("flagent_tag.team", "value", "team:payments"),
+				),
+			},
+			{
+				ResourceName:      "flagent_segment.eu",
+				ImportState:       true,
+				ImportStateIdFunc: importID("flagent_segment.eu", "flag_id", "id"),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "flagent_constraint.country",
+				ImportState:       true,
+				ImportStateIdFunc: importID("flagent_constraint.country", "flag_id", "segment_id", "id"),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "flagent_variant.treatment",
+				ImportState:       true,
+				ImportStateIdFunc: importID("flagent_variant.treatment", "flag_id", "id"),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "flagent_distribution.eu",
+				ImportState:       true,
+				ImportStateIdFunc: importID("flagent_distribution.eu", "flag_id", "segment_id"),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "flagent_tag.team",
+				ImportState:       true,
+				ImportStateIdFunc: importID("flagent_tag.team", "flag_id", "id"),
+				ImportStateVerify: true,
+			},
+			{
+				Config: rolloutConfig(server, 100, 20, `["DE","FR","ES"]`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("flagent_segment.eu", "rollout_percent", "100"),
+					resource.TestCheckResourceAttr("flagent_constraint.country", "value", `["DE","FR","ES"]`),
+					resource.TestCheckTypeSetElemNestedAttrs("flagent_distribution.eu", "variants.*", map[string]string{"percent": "20"}),
+					resource.TestCheckTypeSetElemNestedAttrs("flagent_distribution.eu", "variants.*", map[string]string{"percent": "80"}),
+				),
+			},
+		},
+	})
+}
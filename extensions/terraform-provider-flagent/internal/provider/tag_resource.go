@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+// tagPattern is the server's rule for tag values
+var tagPattern = regexp.MustCompile(`^[ \w\-/.:]{1,63}$`)
+
+var (
+	_ resource.ResourceWithConfigure   = (*tagResource)(nil)
+	_ resource.ResourceWithImportState = (*tagResource)(nil)
+)
+
+type tagResource struct {
+	client *flagent.Client
+}
+
+type tagResourceModel struct {
+	ID     types.String `tfsdk:"id"`
+	FlagID types.Int64  `tfsdk:"flag_id"`
+	Value  types.String `tfsdk:"value"`
+}
+
+func newTagResource() resource.Resource {
+	return &tagResource{}
+}
+
+func (r *tagResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tag"
+}
+
+func (r *tagResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A tag on a flag. Import with `<flag_id>/<tag_id>`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Tag ID.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"flag_id": flagIDAttribute(),
+			"value": schema.StringAttribute{
+				Description:   "Tag value, e.g. `team:payments`.",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(tagPattern, "must be 1 to 63 letters, digits, spaces, '-', '_', '/', '.' or ':'"),
+				},
+			},
+		},
+	}
+}
+
+func (r *tagResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *tagResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan tagResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tag, err := r.client.AddFlagTag(ctx, plan.FlagID.ValueInt64(), plan.Value.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to tag flag", err.Error())
+		return
+	}
+	plan.ID = types.StringValue(formatID(tag.Id))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *tagResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state tagResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tagID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tags, err := r.client.ListFlagTags(ctx, state.FlagID.ValueInt64())
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Unable to read tag", err.Error())
+		return
+	}
+	for _, tag := range tags {
+		if tag.Id == tagID {
+			state.Value = types.StringValue(tag.Value)
+			resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+			return
+		}
+	}
+	resp.State.RemoveResource(ctx)
+}
+
+// Update is never called: every attribute forces a new tag
+func (r *tagResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+}
+
+func (r *tagResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state tagResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tagID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.RemoveFlagTag(ctx, state.FlagID.ValueInt64(), tagID); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Unable to remove tag", err.Error())
+	}
+}
+
+func (r *tagResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importIDs(ctx, req, resp, "flag_id", "id")
+}
@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+var (
+	_ resource.ResourceWithConfigure      = (*variantResource)(nil)
+	_ resource.ResourceWithImportState    = (*variantResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*variantResource)(nil)
+)
+
+type variantResource struct {
+	client *flagent.Client
+}
+
+type variantResourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	FlagID     types.Int64  `tfsdk:"flag_id"`
+	Key        types.String `tfsdk:"key"`
+	Attachment types.String `tfsdk:"attachment"`
+}
+
+func newVariantResource() resource.Resource {
+	return &variantResource{}
+}
+
+func (r *variantResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_variant"
+}
+
+func (r *variantResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A variant of a flag. The server refuses to delete a variant that a segment still " +
+			"distributes to, so destroy or change the distribution first. Import with `<flag_id>/<variant_id>`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Variant ID.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"flag_id": flagIDAttribute(),
+			"key": schema.StringAttribute{
+				Description: "Variant key returned by evaluations.",
+				Required:    true,
+				Validators:  keyValidators,
+			},
+			"attachment": schema.StringAttribute{
+				Description: "JSON object returned with the variant, e.g. `jsonencode({ color = \"blue\" })`.",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *variantResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var attachment types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("attachment"), &attachment)...)
+	if attachment.IsNull() || attachment.IsUnknown() {
+		return
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(attachment.ValueString()), &object); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("attachment"), "Invalid attachment", "attachment must be a JSON object: "+err.Error())
+	}
+}
+
+func (r *variantResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *variantResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan variantResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	variant, err := r.client.CreateVariant(ctx, plan.FlagID.ValueInt64(), plan.input())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create variant", err.Error())
+		return
+	}
+	plan.ID = types.StringValue(formatID(variant.Id))
+	r.read(ctx, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *variantResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state variantResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !r.read(ctx, &state, &resp.Diagnostics) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// read refreshes model from the server, returning false if the variant is gone. An
+// attachment equal to the model's as JSON keeps the model's formatting.
+func (r *variantResource) read(ctx context.Context, model *variantResourceModel, diags *diag.Diagnostics) bool {
+	variantID := parseID(model.ID.ValueString(), diags)
+	if diags.HasError() {
+		return true
+	}
+	variants, err := r.client.ListVariants(ctx, model.FlagID.ValueInt64())
+	if isNotFound(err) {
+		return false
+	}
+	if err != nil {
+		diags.AddError("Unable to read variant", err.Error())
+		return true
+	}
+	for _, v := range variants {
+		if v.Id != variantID {
+			continue
+		}
+		model.Key = types.StringValue(v.Key)
+		switch {
+		case len(v.Attachment) == 0 && model.Attachment.IsNull():
+		case !model.Attachment.IsNull() && attachmentEqual(model.Attachment.ValueString(), v.Attachment):
+		default:
+			data, err := json.Marshal(v.Attachment)
+			if err != nil {
+				diags.AddError("Unable to read variant attachment", err.Error())
+				return true
+			}
+			model.Attachment = types.StringValue(string(data))
+		}
+		return true
+	}
+	return false
+}
+
+func (r *variantResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan variantResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	variantID := parseID(plan.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.client.UpdateVariant(ctx, plan.FlagID.ValueInt64(), variantID, plan.input()); err != nil {
+		resp.Diagnostics.AddError("Unable to update variant", err.Error())
+		return
+	}
+	r.read(ctx, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *variantResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state variantResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	variantID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.DeleteVariant(ctx, state.FlagID.ValueInt64(), variantID); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Unable to delete variant", err.Error())
+	}
+}
+
+func (r *variantResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importIDs(ctx, req, resp, "flag_id", "id")
+}
+
+// input converts the model; ValidateConfig has checked the attachment is a JSON object
+func (m *variantResourceModel) input() *flagent.VariantInput {
+	input := &flagent.VariantInput{Key: m.Key.ValueString()}
+	if !m.Attachment.IsNull() {
+		json.Unmarshal([]byte(m.Attachment.ValueString()), &input.Attachment)
+	}
+	return input
+}
+
+// attachmentEqual compares a JSON attachment with a decoded one; no attachment equals {}
+func attachmentEqual(encoded string, attachment map[string]interface{}) bool {
+	if attachment == nil {
+		attachment = map[string]interface{}{}
+	}
+	var a, b interface{}
+	if err := json.Unmarshal([]byte(encoded), &a); err != nil {
+		return false
+	}
+	data, err := json.Marshal(attachment)
+	if err != nil || json.Unmarshal(data, &b) != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+var (
+	_ resource.ResourceWithConfigure   = (*webhookResource)(nil)
+	_ resource.ResourceWithImportState = (*webhookResource)(nil)
+)
+
+type webhookResource struct {
+	client *flagent.Client
+}
+
+type webhookResourceModel struct {
+	ID      types.String `tfsdk:"id"`
+	URL     types.String `tfsdk:"url"`
+	Events  []string     `tfsdk:"events"`
+	Secret  types.String `tfsdk:"secret"`
+	Enabled types.Bool   `tfsdk:"enabled"`
+}
+
+func newWebhookResource() resource.Resource {
+	return &webhookResource{}
+}
+
+func (r *webhookResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_webhook"
+}
+
+func (r *webhookResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	events := make([]string, 0, len(flagent.AllWebhookEvents()))
+	for _, e := range flagent.AllWebhookEvents() {
+		events = append(events, string(e))
+	}
+	resp.Schema = schema.Schema{
+		Description: "A webhook notified of flag changes. Import with the webhook ID.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Webhook ID.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"url": schema.StringAttribute{
+				Description: "URL the events are posted to.",
+				Required:    true,
+			},
+			"events": schema.SetAttribute{
+				Description: "Events to deliver: `flag.created`, `flag.updated`, `flag.deleted`, `flag.enabled`, `flag.disabled` or `anomaly.detected`.",
+				ElementType: types.StringType,
+				Required:    true,
+				Validators: []validator.Set{
+					setvalidator.SizeAtLeast(1),
+					setvalidator.ValueStringsAre(stringvalidator.OneOf(events...)),
+				},
+			},
+			"secret": schema.StringAttribute{
+				Description: "Signs deliveries with HMAC-SHA256 when set.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"enabled": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+		},
+	}
+}
+
+func (r *webhookResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = clientFrom(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *webhookResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan webhookResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	webhook, err := r.client.Webhooks().Create(ctx, plan.input())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create webhook", err.Error())
+		return
+	}
+	plan.update(webhook)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *webhookResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state webhookResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	webhookID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	webhook, err := r.client.Webhooks().Get(ctx, webhookID)
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to read webhook", err.Error())
+		return
+	}
+	state.update(webhook)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *webhookResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan webhookResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	webhookID := parseID(plan.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	webhook, err := r.client.Webhooks().Update(ctx, webhookID, plan.input())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to update webhook", err.Error())
+		return
+	}
+	plan.update(webhook)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *webhookResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state webhookResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	webhookID := parseID(state.ID.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.Webhooks().Delete(ctx, webhookID); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Unable to delete webhook", err.Error())
+	}
+}
+
+func (r *webhookResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importIDs(ctx, req, resp, "id")
+}
+
+func (m *webhookResourceModel) input() *flagent.WebhookInput {
+	events := make([]flagent.WebhookEvent, len(m.Events))
+	for i, e := range m.Events {
+		events[i] = flagent.WebhookEvent(e)
+	}
+	enabled := m.Enabled.ValueBool()
+	return &flagent.WebhookInput{URL: m.URL.ValueString(), Events: events, Secret: m.Secret.ValueString(), Enabled: &enabled}
+}
+
+// update copies the server's webhook into the model. A secret the server does not
+// return is kept.
+func (m *webhookResourceModel) update(webhook *flagent.Webhook) {
+	m.ID = types.StringValue(formatID(webhook.ID))
+	m.URL = types.StringValue(webhook.URL)
+	m.Events = make([]string, len(webhook.Events))
+	for i, e := range webhook.Events {
+		m.Events[i] = string(e)
+	}
+	if webhook.Secret != "" {
+		m.Secret = types.StringValue(webhook.Secret)
+	}
+	m.Enabled = types.BoolValue(webhook.Enabled)
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccWebhookResource(t *testing.T) {
+	server := newFakeServer(t)
+	config := func(enabled bool) string {
+		return server.providerConfig() + fmt.Sprintf(`
+resource "flagent_webhook" "audit" {
+  url     = "https://hooks.example.com/flagent"
+  events  = ["flag.created", "flag.updated"]
+  secret  = "s3cret"
+  enabled = %t
+}
+`, enabled)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProviders,
+		CheckDestroy: func(*terraform.State) error {
+			if len(server.webhooks) > 0 {
+				return fmt.Errorf("webhook was not deleted")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config(true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("flagent_webhook.audit", "events.#", "2"),
+					resource.TestCheckTypeSetElemAttr("flagent_webhook.audit", "events.*", "flag.created"),
+					resource.TestCheckResourceAttr("flagent_webhook.audit", "enabled", "true"),
+				),
+			},
+			{
+				ResourceName:            "flagent_webhook.audit",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"secret"},
+			},
+			{
+				Config: config(false),
+				Check:  resource.TestCheckResourceAttr("flagent_webhook.audit", "enabled", "false"),
+			},
+		},
+	})
+}
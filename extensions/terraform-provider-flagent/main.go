@@ -0,0 +1,29 @@
+// Command terraform-provider-flagent is the Terraform provider for Flagent feature flags
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+
+	"github.com/MaxLuxs/Flagent/extensions/terraform-provider-flagent/internal/provider"
+)
+
+// version is set by the release build with -ldflags "-X main.version=..."
+var version = "dev"
+
+func main() {
+	var debug bool
+	flag.BoolVar(&debug, "debug", false, "run the provider with support for debuggers like delve")
+	flag.Parse()
+
+	err := providerserver.Serve(context.Background(), provider.New(version), providerserver.ServeOpts{
+		Address: "registry.terraform.io/maxluxs/flagent",
+		Debug:   debug,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
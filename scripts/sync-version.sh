@@ -85,6 +85,7 @@ done
 
 # Go
 if [ -f sdk/go/client.go ]; then sed -i '' "s/defaultUserAgent = \"flagent-go-client\/[^\"]*\"/defaultUserAgent = \"flagent-go-client\/$VERSION\"/" sdk/go/client.go; fi
+if [ -f sdk/go/client.go ]; then sed -i '' "s/^const Version = \"[^\"]*\"/const Version = \"$VERSION\"/" sdk/go/client.go; fi
 
 # Kotlin SDK fallback dependency versions (when not building from source)
 for f in sdk/kotlin-enhanced/build.gradle.kts sdk/kotlin-debug-ui/build.gradle.kts; do
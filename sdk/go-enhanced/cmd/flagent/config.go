@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables, which override the config file
+const (
+	envURL    = "FLAGENT_URL"
+	envAPIKey = "FLAGENT_API_KEY"
+	envToken  = "FLAGENT_TOKEN"
+	envOutput = "FLAGENT_OUTPUT"
+	envConfig = "FLAGENT_CONFIG"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// fileConfig is the config file, by default ~/.config/flagent/config.yaml:
+//
+//	url: https://flagent.example.com
+//	api_key: sk-xxx
+//	output: json
+type fileConfig struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+	Token  string `yaml:"token"`
+	Output string `yaml:"output"`
+}
+
+// options are the flags shared by the commands; empty values fall back to the
+// environment, then to the config file
+type options struct {
+	config  string
+	output  string
+	url     string
+	apiKey  string
+	token   string
+	timeout time.Duration
+
+	file fileConfig
+}
+
+// newFlagSet creates the flag set of a command with the output and config flags.
+// usage is the synopsis after "flagent", e.g. "flags get <key|id> [flags]".
+func (c *cli) newFlagSet(usage string) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet("flagent "+strings.Fields(usage)[0], flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: flagent %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	o := &options{}
+	fs.StringVar(&o.config, "config", "", "config file (default $"+envConfig+" or ~/.config/flagent/config.yaml)")
+	fs.StringVar(&o.output, "output", "", "output format: table or json (default $"+envOutput+" or table)")
+	fs.StringVar(&o.output, "o", "", "shorthand for --output")
+	return fs, o
+}
+
+// addServerFlags adds the flags of commands that call the server
+func (o *options) addServerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.url, "url", "", "Flagent URL, e.g. https://flagent.example.com (default $"+envURL+")")
+	fs.StringVar(&o.apiKey, "api-key", "", "tenant API key, sent as X-API-Key (default $"+envAPIKey+")")
+	fs.StringVar(&o.token, "token", "", "bearer token (default $"+envToken+")")
+	fs.DurationVar(&o.timeout, "timeout", 30*time.Second, "request timeout")
+}
+
+// parse parses args, allowing flags after positional arguments, and resolves the
+// options against the environment and config file. It returns the positional arguments.
+func (c *cli) parse(fs *flag.FlagSet, o *options, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			// The flag package has printed the error and usage
+			return nil, &exitError{code: exitUsage}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if err := o.loadFile(c.getenv); err != nil {
+		return nil, err
+	}
+	o.output = firstNonEmpty(o.output, c.getenv(envOutput), o.file.Output, outputTable)
+	if o.output != outputTable && o.output != outputJSON {
+		return nil, usagef("unknown output format %q (expected table or json)", o.output)
+	}
+	o.url = firstNonEmpty(o.url, c.getenv(envURL), o.file.URL)
+	o.apiKey = firstNonEmpty(o.apiKey, c.getenv(envAPIKey), o.file.APIKey)
+	o.token = firstNonEmpty(o.token, c.getenv(envToken), o.file.Token)
+	return positional, nil
+}
+
+// loadFile reads the config file. A missing file is only an error when it was
+// named explicitly.
+func (o *options) loadFile(getenv func(string) string) error {
+	path := firstNonEmpty(o.config, getenv(envConfig))
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath(getenv)
+		if path == "" {
+			return nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return usagef("failed to read config file: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &o.file); err != nil {
+		return usagef("invalid config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// defaultConfigPath is $XDG_CONFIG_HOME/flagent/config.yaml, or ~/.config/flagent/config.yaml
+func defaultConfigPath(getenv func(string) string) string {
+	dir := getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home := getenv("HOME")
+		if home == "" {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "flagent", "config.yaml")
+}
+
+// client creates the SDK client for the resolved options
+func (o *options) client() (*flagent.Client, error) {
+	if o.url == "" {
+		return nil, usagef("no Flagent URL: pass --url, set %s or add url to the config file", envURL)
+	}
+	return flagent.NewClient(apiURL(o.url),
+		flagent.WithTimeout(o.timeout),
+		flagent.WithTenantAPIKey(o.apiKey),
+		flagent.WithAPIKey(o.token),
+	)
+}
+
+// apiURL accepts the server URL with or without the /api/v1 suffix
+func apiURL(url string) string {
+	url = strings.TrimSuffix(url, "/")
+	if strings.HasSuffix(url, "/api/v1") {
+		return url
+	}
+	return strings.TrimSuffix(url, "/api") + "/api/v1"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
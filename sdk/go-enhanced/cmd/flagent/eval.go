@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	enhanced "github.com/MaxLuxs/Flagent/sdk/go-enhanced"
+)
+
+// evalResult is the output of eval, the same for server and snapshot evaluation
+type evalResult struct {
+	FlagID            int64                  `json:"flagID,omitempty"`
+	FlagKey           string                 `json:"flagKey"`
+	EntityID          string                 `json:"entityID,omitempty"`
+	Enabled           bool                   `json:"enabled"`
+	VariantID         int64                  `json:"variantID,omitempty"`
+	VariantKey        string                 `json:"variantKey,omitempty"`
+	VariantAttachment map[string]interface{} `json:"variantAttachment,omitempty"`
+	SegmentID         int64                  `json:"segmentID,omitempty"`
+	// Reason is only reported by snapshot evaluation (MATCH, NO_MATCH, FLAG_DISABLED, ...)
+	Reason    string   `json:"reason,omitempty"`
+	Source    string   `json:"source"`
+	DebugLogs []string `json:"debugLogs,omitempty"`
+}
+
+func runEval(c *cli, args []string) error {
+	fs, o := c.newFlagSet("eval <flag-key> [flags]")
+	o.addServerFlags(fs)
+	flagKey := fs.String("flag-key", "", "flag key (instead of the argument)")
+	entityID := fs.String("entity-id", "", "entity ID, which decides the variant")
+	entityType := fs.String("entity-type", "", "entity type")
+	contextJSON := fs.String("context", "", `entity context as a JSON object, e.g. '{"tier":"premium"}'`)
+	snapshotPath := fs.String("snapshot", "", "evaluate locally against this snapshot file (see snapshot fetch) instead of the server")
+	debug := fs.Bool("debug", false, "include the evaluation debug log")
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(positional) > 1, len(positional) == 1 && *flagKey != "":
+		return usagef("eval takes one flag key")
+	case len(positional) == 1:
+		*flagKey = positional[0]
+	case *flagKey == "":
+		return usagef("eval requires a flag key")
+	}
+	var entityContext map[string]interface{}
+	if *contextJSON != "" {
+		if err := json.Unmarshal([]byte(*contextJSON), &entityContext); err != nil {
+			return usagef("--context must be a JSON object: %v", err)
+		}
+	}
+
+	var result *evalResult
+	if *snapshotPath != "" {
+		snapshot, err := loadSnapshot(*snapshotPath)
+		if err != nil {
+			return err
+		}
+		req := &enhanced.OfflineEvaluationRequest{
+			FlagKey:       flagKey,
+			EntityID:      *entityID,
+			EntityContext: entityContext,
+			EnableDebug:   *debug,
+		}
+		if *entityType != "" {
+			req.EntityType = entityType
+		}
+		local := enhanced.NewLocalEvaluator().EvaluateContext(c.ctx, req, snapshot)
+		if local.Reason == "FLAG_NOT_FOUND" {
+			return flagent.NewFlagNotFoundError(fmt.Sprintf("flag %q not found in %s", *flagKey, *snapshotPath), nil)
+		}
+		result = localEvalResult(local, *flagKey, *entityID)
+	} else {
+		client, err := o.client()
+		if err != nil {
+			return err
+		}
+		evalCtx := &flagent.EvaluationContext{FlagKey: flagKey, EntityContext: entityContext, EnableDebug: *debug}
+		if *entityID != "" {
+			evalCtx.EntityID = entityID
+		}
+		if *entityType != "" {
+			evalCtx.EntityType = entityType
+		}
+		remote, err := client.Evaluate(c.ctx, evalCtx)
+		if err != nil {
+			return err
+		}
+		result = remoteEvalResult(remote, *flagKey, *entityID)
+	}
+
+	if o.output == outputJSON {
+		return writeJSON(c.stdout, result)
+	}
+	t := newTable(c.stdout)
+	t.row("Flag:", result.FlagKey)
+	t.row("Enabled:", result.Enabled)
+	t.row("Variant:", result.VariantKey)
+	if result.SegmentID != 0 {
+		t.row("Segment:", result.SegmentID)
+	}
+	if result.Reason != "" {
+		t.row("Reason:", result.Reason)
+	}
+	if len(result.VariantAttachment) > 0 {
+		attachment, _ := json.Marshal(result.VariantAttachment)
+		t.row("Attachment:", string(attachment))
+	}
+	if err := t.flush(); err != nil {
+		return err
+	}
+	if len(result.DebugLogs) > 0 {
+		fmt.Fprintf(c.stdout, "\nDebug log:\n  %s\n", strings.Join(result.DebugLogs, "\n  "))
+	}
+	return nil
+}
+
+func localEvalResult(r *enhanced.LocalEvaluationResult, flagKey, entityID string) *evalResult {
+	result := &evalResult{
+		FlagKey:           flagKey,
+		EntityID:          entityID,
+		Enabled:           r.IsEnabled(),
+		VariantAttachment: r.VariantAttachment,
+		Reason:            r.Reason,
+		Source:            "snapshot",
+		DebugLogs:         r.DebugLogs,
+	}
+	if r.FlagID != nil {
+		result.FlagID = *r.FlagID
+	}
+	if r.VariantID != nil {
+		result.VariantID = *r.VariantID
+	}
+	if r.VariantKey != nil {
+		result.VariantKey = *r.VariantKey
+	}
+	if r.SegmentID != nil {
+		result.SegmentID = *r.SegmentID
+	}
+	return result
+}
+
+func remoteEvalResult(r *flagent.EvaluationResult, flagKey, entityID string) *evalResult {
+	result := &evalResult{
+		FlagKey:  flagKey,
+		EntityID: entityID,
+		Enabled:  r.IsEnabled(),
+		Source:   "server",
+	}
+	if r.VariantKey != nil {
+		result.VariantKey = *r.VariantKey
+	}
+	if r.EvalResult == nil {
+		return result
+	}
+	result.FlagID = r.GetFlagID()
+	result.VariantID = r.GetVariantID()
+	result.SegmentID = r.GetSegmentID()
+	result.VariantAttachment = r.VariantAttachment
+	if r.EvalDebugLog != nil {
+		for _, segment := range r.EvalDebugLog.SegmentDebugLogs {
+			result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("segment %d: %s", segment.GetSegmentID(), segment.GetMsg()))
+		}
+		if msg := r.EvalDebugLog.GetMsg(); msg != "" {
+			result.DebugLogs = append(result.DebugLogs, msg)
+		}
+	}
+	return result
+}
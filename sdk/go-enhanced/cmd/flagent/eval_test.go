@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	enhanced "github.com/MaxLuxs/Flagent/sdk/go-enhanced"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalServer(t *testing.T) {
+	var body map[string]interface{}
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "POST /evaluation", r.Method+" "+r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"flagID":            7,
+			"flagKey":           "new_checkout",
+			"segmentID":         3,
+			"variantID":         2,
+			"variantKey":        "treatment",
+			"variantAttachment": map[string]interface{}{"color": "green"},
+			"evalDebugLog": map[string]interface{}{
+				"segmentDebugLogs": []interface{}{map[string]interface{}{"segmentID": 3, "msg": "matched"}},
+			},
+		})
+	})
+	env := map[string]string{envURL: url}
+
+	t.Run("table", func(t *testing.T) {
+		r := runCLI(t, env, "eval", "new_checkout", "--entity-id", "user-1", "--context", `{"country":"DE"}`, "--debug")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, `Flag:        new_checkout
+Enabled:     true
+Variant:     treatment
+Segment:     3
+Attachment:  {"color":"green"}
+
+Debug log:
+  segment 3: matched
+`, r.stdout)
+		assert.Equal(t, "new_checkout", body["flagKey"])
+		assert.Equal(t, "user-1", body["entityID"])
+		assert.Equal(t, map[string]interface{}{"country": "DE"}, body["entityContext"])
+		assert.Equal(t, true, body["enableDebug"])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		r := runCLI(t, env, "eval", "--flag-key", "new_checkout", "--entity-type", "user", "-o", "json")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		var result evalResult
+		require.NoError(t, json.Unmarshal([]byte(r.stdout), &result))
+		assert.Equal(t, "server", result.Source)
+		assert.Equal(t, int64(7), result.FlagID)
+		assert.Equal(t, "treatment", result.VariantKey)
+		assert.Equal(t, "user", body["entityType"])
+	})
+
+	t.Run("invalid context", func(t *testing.T) {
+		r := runCLI(t, env, "eval", "new_checkout", "--context", "country=DE")
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, "--context must be a JSON object")
+	})
+
+	t.Run("missing flag key", func(t *testing.T) {
+		r := runCLI(t, env, "eval")
+		assert.Equal(t, exitUsage, r.code)
+	})
+}
+
+func TestEvalSnapshot(t *testing.T) {
+	t.Run("match", func(t *testing.T) {
+		r := runCLI(t, nil, "eval", "new_checkout", "--snapshot", exportFixture, "--entity-id", "user-1", "--context", `{"country":"US"}`, "-o", "json")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		var result evalResult
+		require.NoError(t, json.Unmarshal([]byte(r.stdout), &result))
+		assert.Equal(t, "snapshot", result.Source)
+		assert.Equal(t, "MATCH", result.Reason)
+		assert.Equal(t, "treatment", result.VariantKey)
+		assert.True(t, result.Enabled)
+	})
+
+	t.Run("disabled flag", func(t *testing.T) {
+		path := writeSnapshot(t, "snapshot.json", &enhanced.LocalFlag{ID: 1, Key: "dark_mode"})
+		r := runCLI(t, nil, "eval", "dark_mode", "--snapshot", path)
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "Flag:     dark_mode\nEnabled:  false\nVariant:  \nReason:   FLAG_DISABLED\n", r.stdout)
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		r := runCLI(t, nil, "eval", "old_banner", "--snapshot", exportFixture)
+		assert.Equal(t, exitNotFound, r.code)
+		assert.Contains(t, r.stderr, `flag "old_banner" not found`)
+	})
+}
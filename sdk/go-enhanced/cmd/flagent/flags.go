@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+func runFlags(c *cli, args []string) error {
+	return c.subcommand("flags", args, map[string]func(*cli, []string) error{
+		"list":    flagsList,
+		"get":     flagsGet,
+		"create":  flagsCreate,
+		"enable":  func(c *cli, args []string) error { return flagsSetEnabled(c, args, true) },
+		"disable": func(c *cli, args []string) error { return flagsSetEnabled(c, args, false) },
+	})
+}
+
+func flagsList(c *cli, args []string) error {
+	fs, o := c.newFlagSet("flags list [flags]")
+	o.addServerFlags(fs)
+	limit := fs.Int("limit", 0, "maximum number of flags (default all)")
+	offset := fs.Int("offset", 0, "number of flags to skip")
+	key := fs.String("key", "", "only the flag with this key")
+	tags := fs.String("tags", "", "only flags with any of these comma-separated tags")
+	enabled := fs.String("enabled", "", "only enabled (true) or disabled (false) flags")
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("flags list takes no arguments")
+	}
+	opts := &flagent.ListFlagsOptions{Limit: *limit, Offset: *offset, Key: *key}
+	if *tags != "" {
+		opts.Tags = strings.Split(*tags, ",")
+	}
+	if *enabled != "" {
+		b, err := strconv.ParseBool(*enabled)
+		if err != nil {
+			return usagef("--enabled must be true or false, not %q", *enabled)
+		}
+		opts.Enabled = &b
+	}
+	client, err := o.client()
+	if err != nil {
+		return err
+	}
+
+	var flags []flagent.Flag
+	if *limit > 0 {
+		flags, err = client.ListFlags(c.ctx, opts)
+	} else {
+		it := client.IterateFlags(c.ctx, opts)
+		for it.Next() {
+			flags = append(flags, it.Flag())
+		}
+		err = it.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	if o.output == outputJSON {
+		if flags == nil {
+			flags = []flagent.Flag{}
+		}
+		return writeJSON(c.stdout, flags)
+	}
+	t := newTable(c.stdout, "ID", "KEY", "ENABLED", "DESCRIPTION")
+	for _, f := range flags {
+		t.row(f.Id, f.Key, f.Enabled, f.Description)
+	}
+	return t.flush()
+}
+
+func flagsGet(c *cli, args []string) error {
+	fs, o := c.newFlagSet("flags get <key|id> [flags]")
+	o.addServerFlags(fs)
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("flags get takes one flag key or ID")
+	}
+	client, err := o.client()
+	if err != nil {
+		return err
+	}
+	flag, err := findFlag(c.ctx, client, positional[0])
+	if err != nil {
+		return err
+	}
+	if o.output == outputJSON {
+		return writeJSON(c.stdout, flag)
+	}
+	return printFlag(c, flag)
+}
+
+// printFlag shows a flag's fields followed by its segments in rank order
+func printFlag(c *cli, flag *flagent.Flag) error {
+	tags := make([]string, len(flag.Tags))
+	for i, tag := range flag.Tags {
+		tags[i] = tag.Value
+	}
+	variants := make([]string, len(flag.Variants))
+	for i, v := range flag.Variants {
+		variants[i] = v.Key
+	}
+	t := newTable(c.stdout)
+	t.row("ID:", flag.Id)
+	t.row("Key:", flag.Key)
+	t.row("Enabled:", flag.Enabled)
+	t.row("Description:", flag.Description)
+	t.row("Entity type:", flag.GetEntityType())
+	t.row("Tags:", strings.Join(tags, ", "))
+	t.row("Variants:", strings.Join(variants, ", "))
+	if err := t.flush(); err != nil {
+		return err
+	}
+	if len(flag.Segments) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(c.stdout)
+	t = newTable(c.stdout, "SEGMENT", "RANK", "ROLLOUT", "DESCRIPTION", "CONSTRAINTS", "DISTRIBUTION")
+	for _, s := range flag.Segments {
+		constraints := make([]string, len(s.Constraints))
+		for i, con := range s.Constraints {
+			constraints[i] = con.Property + " " + con.Operator + " " + con.Value
+		}
+		distribution := make([]string, len(s.Distributions))
+		for i, d := range s.Distributions {
+			distribution[i] = fmt.Sprintf("%s %d%%", d.GetVariantKey(), d.Percent)
+		}
+		t.row(s.Id, s.Rank, fmt.Sprintf("%d%%", s.RolloutPercent), s.Description,
+			strings.Join(constraints, " AND "), strings.Join(distribution, ", "))
+	}
+	return t.flush()
+}
+
+func flagsCreate(c *cli, args []string) error {
+	fs, o := c.newFlagSet("flags create --key <key> [flags]  |  flag create --from-branch [branch] [flags]")
+	o.addServerFlags(fs)
+	key := fs.String("key", "", "flag key (generated by the server if empty)")
+	description := fs.String("description", "", "flag description")
+	template := fs.String("template", "", "create the flag from a template, e.g. simple_boolean_flag")
+	enabled := fs.Bool("enabled", false, "enable the flag after creating it")
+	fromBranch := fs.Bool("from-branch", false, "derive the key from the given or current git branch; an existing flag is not an error")
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	client, err := o.client()
+	if err != nil {
+		return err
+	}
+
+	if *fromBranch {
+		if *key != "" {
+			return usagef("--key and --from-branch cannot be combined")
+		}
+		if len(positional) > 1 {
+			return usagef("--from-branch takes at most one branch")
+		}
+		branch := ""
+		if len(positional) == 1 {
+			branch = positional[0]
+		} else if branch = c.currentBranch(); branch == "" {
+			return usagef("no branch given and the current git branch is unknown")
+		}
+		*key = branchFlagKey(branch)
+		if *description == "" {
+			*description = "Auto from branch: " + branch
+		}
+		existing, err := client.ListFlags(c.ctx, &flagent.ListFlagsOptions{Key: *key, Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return c.printFlagResult("Flag %s already exists (id %d)", &existing[0], o)
+		}
+	} else if len(positional) > 0 {
+		return usagef("unexpected argument %q", positional[0])
+	}
+
+	flag, err := client.CreateFlag(c.ctx, &flagent.CreateFlagInput{Key: *key, Description: *description, Template: *template})
+	if err != nil {
+		return err
+	}
+	if *enabled {
+		if flag, err = client.SetFlagEnabled(c.ctx, flag.Id, true); err != nil {
+			return err
+		}
+	}
+	return c.printFlagResult("Created flag %s (id %d)", flag, o)
+}
+
+func flagsSetEnabled(c *cli, args []string, enabled bool) error {
+	verb := map[bool]string{true: "enable", false: "disable"}[enabled]
+	fs, o := c.newFlagSet("flags " + verb + " <key|id> [flags]")
+	o.addServerFlags(fs)
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("flags %s takes one flag key or ID", verb)
+	}
+	client, err := o.client()
+	if err != nil {
+		return err
+	}
+	flag, err := findFlag(c.ctx, client, positional[0])
+	if err != nil {
+		return err
+	}
+	if flag, err = client.SetFlagEnabled(c.ctx, flag.Id, enabled); err != nil {
+		return err
+	}
+	return c.printFlagResult(strings.ToUpper(verb[:1])+verb[1:]+"d flag %s (id %d)", flag, o)
+}
+
+// printFlagResult prints the flag as JSON, or format with its key and ID
+func (c *cli) printFlagResult(format string, flag *flagent.Flag, o *options) error {
+	if o.output == outputJSON {
+		return writeJSON(c.stdout, flag)
+	}
+	_, err := fmt.Fprintf(c.stdout, format+"\n", flag.Key, flag.Id)
+	return err
+}
+
+// findFlag loads a flag with its segments and variants by ID or, for a
+// non-numeric reference, by key
+func findFlag(ctx context.Context, client *flagent.Client, ref string) (*flagent.Flag, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		return client.GetFlag(ctx, id)
+	}
+	flags, err := client.ListFlags(ctx, &flagent.ListFlagsOptions{Key: ref, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(flags) == 0 {
+		return nil, flagent.NewFlagNotFoundError(fmt.Sprintf("flag %q not found", ref), nil)
+	}
+	return client.GetFlag(ctx, flags[0].Id)
+}
+
+var invalidKeyChars = regexp.MustCompile(`[^a-zA-Z0-9_\-.:]`)
+
+// branchFlagKey converts a branch name to a flag key with the trunk-based
+// development convention, e.g. feature/new-payment -> feature_new-payment.
+// It matches the server's TrunkUtils.branchToFlagKey, which names the flags
+// created by the GitHub webhook.
+func branchFlagKey(branch string) string {
+	key := strings.TrimPrefix(branch, "refs/heads/")
+	key = strings.ReplaceAll(key, "/", "_")
+	key = strings.ToLower(invalidKeyChars.ReplaceAllString(key, "_"))
+	if strings.TrimSpace(key) == "" {
+		return "unnamed"
+	}
+	return key
+}
+
+// currentBranch is the checked-out git branch. On a detached HEAD, as in most CI
+// checkouts, it falls back to the branch the CI system reports.
+func (c *cli) currentBranch() string {
+	out, err := exec.CommandContext(c.ctx, "git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if branch := strings.TrimSpace(string(out)); err == nil && branch != "HEAD" {
+		return branch
+	}
+	return firstNonEmpty(c.getenv("GITHUB_HEAD_REF"), c.getenv("GITHUB_REF_NAME"), c.getenv("CI_COMMIT_REF_NAME"))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkoutFlag is a flag with one constrained segment split between two variants
+var checkoutFlag = flagent.Flag{
+	Id: 7, Key: "new_checkout", Description: "New checkout flow", Enabled: true,
+	EntityType: *api.NewNullableString(flagent.StringPtr("user")),
+	Tags:       []flagent.Tag{{Id: 1, Value: "team:payments"}},
+	Variants:   []flagent.Variant{{Id: 1, FlagID: 7, Key: "control"}, {Id: 2, FlagID: 7, Key: "treatment"}},
+	Segments: []flagent.Segment{{
+		Id: 3, FlagID: 7, Description: "EU users", Rank: 0, RolloutPercent: 50,
+		Constraints: []flagent.Constraint{{Id: 4, SegmentID: 3, Property: "country", Operator: "IN", Value: `["DE","FR"]`}},
+		Distributions: []flagent.Distribution{
+			{Id: 5, SegmentID: 3, VariantID: 1, VariantKey: *api.NewNullableString(flagent.StringPtr("control")), Percent: 50},
+			{Id: 6, SegmentID: 3, VariantID: 2, VariantKey: *api.NewNullableString(flagent.StringPtr("treatment")), Percent: 50},
+		},
+	}},
+}
+
+func TestFlagsList(t *testing.T) {
+	var query map[string][]string
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/flags", r.URL.Path)
+		query = r.URL.Query()
+		writeJSONResponse(w, http.StatusOK, []flagent.Flag{checkoutFlag})
+	})
+	env := map[string]string{envURL: url}
+
+	t.Run("table", func(t *testing.T) {
+		r := runCLI(t, env, "flags", "list", "--tags", "team:payments", "--enabled", "true", "--limit", "10")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "ID  KEY           ENABLED  DESCRIPTION\n7   new_checkout  true     New checkout flow\n", r.stdout)
+		assert.Equal(t, []string{"team:payments"}, query["tags"])
+		assert.Equal(t, []string{"true"}, query["enabled"])
+		assert.Equal(t, []string{"10"}, query["limit"])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		r := runCLI(t, env, "flags", "list", "--key", "new_checkout", "-o", "json")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		var flags []map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(r.stdout), &flags))
+		require.Len(t, flags, 1)
+		assert.Equal(t, "new_checkout", flags[0]["key"])
+		assert.Equal(t, []string{"new_checkout"}, query["key"])
+	})
+
+	t.Run("invalid enabled", func(t *testing.T) {
+		r := runCLI(t, env, "flags", "list", "--enabled", "maybe")
+		assert.Equal(t, exitUsage, r.code)
+	})
+}
+
+func TestFlagsGet(t *testing.T) {
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/flags" && r.URL.Query().Get("key") == "new_checkout":
+			writeJSONResponse(w, http.StatusOK, []flagent.Flag{checkoutFlag})
+		case r.URL.Path == "/flags":
+			writeJSONResponse(w, http.StatusOK, []flagent.Flag{})
+		case r.URL.Path == "/flags/7":
+			writeJSONResponse(w, http.StatusOK, checkoutFlag)
+		default:
+			writeJSONResponse(w, http.StatusNotFound, map[string]string{"message": "not found"})
+		}
+	})
+	env := map[string]string{envURL: url}
+
+	t.Run("by key", func(t *testing.T) {
+		r := runCLI(t, env, "flags", "get", "new_checkout")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, `ID:           7
+Key:          new_checkout
+Enabled:      true
+Description:  New checkout flow
+Entity type:  user
+Tags:         team:payments
+Variants:     control, treatment
+
+SEGMENT  RANK  ROLLOUT  DESCRIPTION  CONSTRAINTS             DISTRIBUTION
+3        0     50%      EU users     country IN ["DE","FR"]  control 50%, treatment 50%
+`, r.stdout)
+	})
+
+	t.Run("by ID as JSON", func(t *testing.T) {
+		r := runCLI(t, env, "flags", "get", "7", "-o", "json")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		var flag map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(r.stdout), &flag))
+		assert.Equal(t, "new_checkout", flag["key"])
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		r := runCLI(t, env, "flags", "get", "old_banner")
+		assert.Equal(t, exitNotFound, r.code)
+		assert.Contains(t, r.stderr, `flag "old_banner" not found`)
+	})
+
+	t.Run("missing argument", func(t *testing.T) {
+		r := runCLI(t, env, "flags", "get")
+		assert.Equal(t, exitUsage, r.code)
+	})
+}
+
+func TestFlagsCreate(t *testing.T) {
+	var calls []string
+	var created map[string]interface{}
+	existing := []flagent.Flag{}
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /flags":
+			writeJSONResponse(w, http.StatusOK, existing)
+		case "POST /flags":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			writeJSONResponse(w, http.StatusOK, flagent.Flag{Id: 9, Key: created["key"].(string), Description: created["description"].(string)})
+		case "PUT /flags/9/enabled":
+			writeJSONResponse(w, http.StatusOK, flagent.Flag{Id: 9, Key: created["key"].(string), Enabled: true})
+		default:
+			writeJSONResponse(w, http.StatusNotFound, map[string]string{"message": "not found"})
+		}
+	})
+	env := map[string]string{envURL: url}
+
+	t.Run("enabled", func(t *testing.T) {
+		calls = nil
+		r := runCLI(t, env, "flags", "create", "--key", "dark_mode", "--description", "Dark mode", "--enabled")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "Created flag dark_mode (id 9)\n", r.stdout)
+		assert.Equal(t, "Dark mode", created["description"])
+		assert.Equal(t, []string{"POST /flags", "PUT /flags/9/enabled"}, calls)
+	})
+
+	t.Run("from branch", func(t *testing.T) {
+		calls = nil
+		r := runCLI(t, env, "flag", "create", "--from-branch", "refs/heads/feature/New-Payment", "-o", "json")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "feature_new-payment", created["key"])
+		assert.Equal(t, "Auto from branch: refs/heads/feature/New-Payment", created["description"])
+		assert.Equal(t, []string{"GET /flags", "POST /flags"}, calls)
+		assert.Contains(t, r.stdout, `"key": "feature_new-payment"`)
+	})
+
+	t.Run("from branch when the flag exists", func(t *testing.T) {
+		calls = nil
+		existing = []flagent.Flag{{Id: 4, Key: "fix_flag-123"}}
+		defer func() { existing = []flagent.Flag{} }()
+		r := runCLI(t, env, "flag", "create", "--from-branch", "fix/FLAG-123")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "Flag fix_flag-123 already exists (id 4)\n", r.stdout)
+		assert.Equal(t, []string{"GET /flags"}, calls)
+	})
+
+	t.Run("key with from branch", func(t *testing.T) {
+		r := runCLI(t, env, "flag", "create", "--from-branch", "--key", "x_flag")
+		assert.Equal(t, exitUsage, r.code)
+	})
+}
+
+func TestFlagsSetEnabled(t *testing.T) {
+	var body map[string]interface{}
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /flags/7":
+			writeJSONResponse(w, http.StatusOK, checkoutFlag)
+		case "PUT /flags/7/enabled":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			writeJSONResponse(w, http.StatusOK, flagent.Flag{Id: 7, Key: "new_checkout", Enabled: body["enabled"].(bool)})
+		default:
+			writeJSONResponse(w, http.StatusNotFound, map[string]string{"message": "not found"})
+		}
+	})
+	env := map[string]string{envURL: url}
+
+	r := runCLI(t, env, "flags", "disable", "7")
+	require.Equal(t, exitOK, r.code, r.stderr)
+	assert.Equal(t, "Disabled flag new_checkout (id 7)\n", r.stdout)
+	assert.Equal(t, false, body["enabled"])
+
+	r = runCLI(t, env, "flags", "enable", "7")
+	require.Equal(t, exitOK, r.code, r.stderr)
+	assert.Equal(t, "Enabled flag new_checkout (id 7)\n", r.stdout)
+	assert.Equal(t, true, body["enabled"])
+}
+
+func TestBranchFlagKey(t *testing.T) {
+	for branch, want := range map[string]string{
+		"feature/new-payment":    "feature_new-payment",
+		"fix/FLAG-123":           "fix_flag-123",
+		"refs/heads/feature/foo": "feature_foo",
+		"release/v1.2 hotfix":    "release_v1.2_hotfix",
+		"":                       "unnamed",
+	} {
+		assert.Equal(t, want, branchFlagKey(branch), branch)
+	}
+}
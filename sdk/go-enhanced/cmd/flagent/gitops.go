@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+// gitOpsFormat picks the format of a GitOps file from its extension; explicit wins
+func gitOpsFormat(path, explicit string) (flagent.GitOpsFormat, error) {
+	switch explicit {
+	case "":
+	case string(flagent.GitOpsYAML), string(flagent.GitOpsJSON):
+		return flagent.GitOpsFormat(explicit), nil
+	default:
+		return "", usagef("unknown format %q (expected yaml or json)", explicit)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return flagent.GitOpsJSON, nil
+	}
+	return flagent.GitOpsYAML, nil
+}
+
+func runExport(c *cli, args []string) error {
+	fs, o := c.newFlagSet("export [flags]")
+	o.addServerFlags(fs)
+	file := fs.String("file", "", "write the flags to this file instead of stdout")
+	format := fs.String("format", "", "yaml or json (default from the file extension, else yaml)")
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("export takes no arguments")
+	}
+	gitOps, err := gitOpsFormat(*file, *format)
+	if err != nil {
+		return err
+	}
+	client, err := o.client()
+	if err != nil {
+		return err
+	}
+	data, err := client.ExportFlags(c.ctx, gitOps)
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		_, err = c.stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*file, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "Exported flags to %s\n", *file)
+	return nil
+}
+
+func runImport(c *cli, args []string) error {
+	fs, o := c.newFlagSet("import --file <path> [flags]")
+	o.addServerFlags(fs)
+	file := fs.String("file", "", "GitOps file to import (or the argument)")
+	format := fs.String("format", "", "yaml or json (default from the file extension, else yaml)")
+	validate := fs.Bool("validate", false, "only check the file, without contacting the server")
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(positional) > 1, len(positional) == 1 && *file != "":
+		return usagef("import takes one file")
+	case len(positional) == 1:
+		*file = positional[0]
+	case *file == "":
+		return usagef("import requires --file")
+	}
+	gitOps, err := gitOpsFormat(*file, *format)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	parsed, err := flagent.ParseGitOpsFile(data, gitOps)
+	if err != nil {
+		return err
+	}
+
+	if *validate {
+		if err := parsed.Validate(); err != nil {
+			return err
+		}
+		if o.output == outputJSON {
+			return writeJSON(c.stdout, map[string]interface{}{"valid": true, "flags": len(parsed.Flags)})
+		}
+		_, err := fmt.Fprintf(c.stdout, "%s is valid (%d flags)\n", *file, len(parsed.Flags))
+		return err
+	}
+
+	client, err := o.client()
+	if err != nil {
+		return err
+	}
+	result, err := client.ImportFlags(c.ctx, parsed)
+	if err != nil {
+		return err
+	}
+	if o.output == outputJSON {
+		err = writeJSON(c.stdout, result)
+	} else {
+		err = printImportResult(c, result)
+	}
+	if err == nil && len(result.Skipped) > 0 {
+		return &exitError{code: exitIncomplete, err: fmt.Errorf("%d of %d flags were not imported", len(result.Skipped), len(parsed.Flags))}
+	}
+	return err
+}
+
+func printImportResult(c *cli, result *flagent.ImportResult) error {
+	fmt.Fprintf(c.stdout, "Created: %d\nUpdated: %d\n", len(result.Created), len(result.Updated))
+	t := newTable(c.stdout)
+	for _, key := range result.Created {
+		t.row("  +", key)
+	}
+	for _, key := range result.Updated {
+		t.row("  ~", key)
+	}
+	for _, skipped := range result.Skipped {
+		t.row("  !", skipped.Key, skipped.Reason)
+	}
+	return t.flush()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gitOpsYAML = `version: "1"
+flags:
+  - key: new_checkout
+    description: New checkout flow
+    enabled: true
+  - key: dark_mode
+    enabled: false
+  - key: beta_banner
+    enabled: true
+`
+
+func TestExport(t *testing.T) {
+	var format string
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/export/gitops", r.URL.Path)
+		format = r.URL.Query().Get("format")
+		w.Write([]byte("flags: []\n"))
+	})
+	env := map[string]string{envURL: url}
+
+	t.Run("stdout", func(t *testing.T) {
+		r := runCLI(t, env, "export")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "flags: []\n", r.stdout)
+		assert.Equal(t, "yaml", format)
+	})
+
+	t.Run("format from extension", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "flags.json")
+		r := runCLI(t, env, "export", "--file", path)
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "json", format)
+		assert.Equal(t, "Exported flags to "+path+"\n", r.stderr)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "flags: []\n", string(data))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		r := runCLI(t, env, "export", "--format", "toml")
+		assert.Equal(t, exitUsage, r.code)
+	})
+}
+
+func TestImport(t *testing.T) {
+	var imported map[string]string
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /flags":
+			writeJSONResponse(w, http.StatusOK, []flagent.Flag{{Id: 7, Key: "new_checkout"}})
+		case "POST /import":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&imported))
+			writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+				"created": 1, "updated": 1, "errors": []string{"beta_banner: segment rank conflict"},
+			})
+		default:
+			writeJSONResponse(w, http.StatusNotFound, map[string]string{"message": "not found"})
+		}
+	})
+	env := map[string]string{envURL: url}
+	path := writeFile(t, "flags.yaml", gitOpsYAML)
+
+	t.Run("skipped flags", func(t *testing.T) {
+		r := runCLI(t, env, "import", "--file", path)
+		assert.Equal(t, exitIncomplete, r.code)
+		assert.Equal(t, `Created: 1
+Updated: 1
+  +  dark_mode
+  ~  new_checkout
+  !  beta_banner  segment rank conflict
+`, r.stdout)
+		assert.Equal(t, "flagent: 1 of 3 flags were not imported\n", r.stderr)
+		assert.Equal(t, "json", imported["format"])
+		assert.Contains(t, imported["content"], "dark_mode")
+	})
+
+	t.Run("sync as JSON", func(t *testing.T) {
+		r := runCLI(t, env, "sync", path, "-o", "json")
+		assert.Equal(t, exitIncomplete, r.code)
+		var result flagent.ImportResult
+		require.NoError(t, json.Unmarshal([]byte(r.stdout), &result))
+		assert.Equal(t, []string{"dark_mode"}, result.Created)
+		assert.Equal(t, []flagent.SkippedFlag{{Key: "beta_banner", Reason: "segment rank conflict"}}, result.Skipped)
+		assert.Contains(t, r.stdout, `"key": "beta_banner"`, "keys are camelCase like the other JSON output")
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		r := runCLI(t, nil, "import", "--validate", path)
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, path+" is valid (3 flags)\n", r.stdout)
+	})
+
+	t.Run("validate invalid file", func(t *testing.T) {
+		invalid := writeFile(t, "flags.yaml", "version: \"1\"\nflags:\n  - key: dark_mode\n  - key: dark_mode\n")
+		r := runCLI(t, nil, "import", "--validate", invalid)
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, "flag dark_mode: duplicate key")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		r := runCLI(t, env, "import")
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, "import requires --file")
+	})
+}
@@ -0,0 +1,177 @@
+// Command flagent manages Flagent flags from the shell and CI: list and toggle
+// flags, evaluate them against the server or a local snapshot, fetch and diff
+// snapshots, and export or import GitOps files.
+//
+// Build it from a checkout of the repository; go install with a version does not
+// work, because this module uses the Go SDK through a replace directive:
+//
+//	cd sdk/go-enhanced && go build ./cmd/flagent
+//
+// Run "flagent help" for the commands and docs/guides/cli-reference.md for details.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// Exit codes, documented in docs/guides/cli-reference.md
+const (
+	exitOK         = 0
+	exitFailure    = 1 // server, network or file error
+	exitUsage      = 2 // bad arguments or configuration
+	exitNotFound   = 3 // the flag does not exist
+	exitAuth       = 4 // the credentials were rejected
+	exitIncomplete = 5 // import skipped some flags
+	exitDiffer     = 6 // snapshot diff --exit-code found differences
+)
+
+// command is a subcommand; run receives the arguments after its name
+type command struct {
+	name    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+// commands in the order "flagent help" lists them
+var commands = []command{
+	{"flags", "List, show, create, enable and disable flags", runFlags},
+	{"eval", "Evaluate a flag on the server or against a snapshot file", runEval},
+	{"snapshot", "Fetch, inspect and diff flag snapshots", runSnapshot},
+	{"export", "Export flags to a GitOps file", runExport},
+	{"import", "Import flags from a GitOps file", runImport},
+	{"sync", "Alias for import", runImport},
+	{"version", "Print the CLI version", runVersion},
+}
+
+// aliases maps alternative command names; "flag create --from-branch" is the
+// spelling used by the trunk-based development guide
+var aliases = map[string]string{"flag": "flags"}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	stop()
+	os.Exit(code)
+}
+
+// cli is the state shared by the commands of one invocation
+type cli struct {
+	ctx    context.Context
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+}
+
+// run executes the command line args and returns the exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	c := &cli{ctx: ctx, stdout: stdout, stderr: stderr, getenv: getenv}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage(stdout)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	name := args[0]
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return c.exit(cmd.run(c, args[1:]))
+		}
+	}
+	fmt.Fprintf(stderr, "flagent: unknown command %q\n\n", args[0])
+	c.usage(stderr)
+	return exitUsage
+}
+
+func (c *cli) usage(w io.Writer) {
+	fmt.Fprint(w, "Flagent CLI - manage feature flags from the shell and CI\n\nUsage:\n  flagent <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprint(w, "\nRun \"flagent <command> -h\" for the arguments of a command.\n")
+}
+
+// exit prints err and maps it to an exit code
+func (c *cli) exit(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	var coded *exitError
+	if errors.As(err, &coded) {
+		if coded.err != nil {
+			fmt.Fprintf(c.stderr, "flagent: %v\n", coded.err)
+		}
+		return coded.code
+	}
+	fmt.Fprintf(c.stderr, "flagent: %v\n", err)
+
+	var invalid *flagent.InvalidConfigError
+	switch {
+	case errors.As(err, new(*usageError)), errors.As(err, &invalid):
+		return exitUsage
+	case errors.Is(err, flagent.ErrFlagNotFound), errors.Is(err, flagent.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, flagent.ErrUnauthorized), errors.Is(err, flagent.ErrForbidden):
+		return exitAuth
+	}
+	return exitFailure
+}
+
+// usageError is a mistake in the command line
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// exitError ends the command with a specific exit code, printing err if set
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit code %d", e.code)
+	}
+	return e.err.Error()
+}
+
+// subcommand dispatches args to one of subcommands, e.g. "flags list"
+func (c *cli) subcommand(parent string, args []string, subcommands map[string]func(*cli, []string) error) error {
+	if len(args) == 0 {
+		return usagef("%s requires a subcommand: %s", parent, strings.Join(sortedKeys(subcommands), ", "))
+	}
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return usagef("unknown %s subcommand %q (expected %s)", parent, args[0], strings.Join(sortedKeys(subcommands), ", "))
+	}
+	return run(c, args[1:])
+}
+
+func runVersion(c *cli, args []string) error {
+	fmt.Fprintf(c.stdout, "flagent %s\n", version)
+	return nil
+}
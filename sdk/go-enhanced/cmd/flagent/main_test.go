@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// result is the outcome of one CLI invocation
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// runCLI runs the CLI with env as the only environment variables
+func runCLI(t *testing.T, env map[string]string, args ...string) result {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	getenv := func(name string) string { return env[name] }
+	code := run(context.Background(), args, &stdout, &stderr, getenv)
+	return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+// newAPIServer serves handler under /api/v1 and returns the server's base URL
+func newAPIServer(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	server := httptest.NewServer(http.StripPrefix("/api/v1", handler))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	t.Run("help", func(t *testing.T) {
+		r := runCLI(t, nil, "help")
+		assert.Equal(t, exitOK, r.code)
+		assert.Contains(t, r.stdout, "snapshot  Fetch, inspect and diff flag snapshots")
+	})
+
+	t.Run("no command", func(t *testing.T) {
+		r := runCLI(t, nil)
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stdout, "Usage:")
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		r := runCLI(t, nil, "deploy")
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, `unknown command "deploy"`)
+	})
+
+	t.Run("unknown subcommand", func(t *testing.T) {
+		r := runCLI(t, nil, "flags", "rename")
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, `unknown flags subcommand "rename" (expected create, disable, enable, get, list)`)
+	})
+
+	t.Run("command help", func(t *testing.T) {
+		r := runCLI(t, nil, "flags", "list", "-h")
+		assert.Equal(t, exitOK, r.code)
+		assert.Contains(t, r.stderr, "Usage: flagent flags list [flags]")
+		assert.Contains(t, r.stderr, "-tags")
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		r := runCLI(t, nil, "flags", "list", "--colour")
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, "flag provided but not defined: -colour")
+	})
+
+	t.Run("version", func(t *testing.T) {
+		r := runCLI(t, nil, "version")
+		assert.Equal(t, exitOK, r.code)
+		assert.Equal(t, "flagent dev\n", r.stdout)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	var headers http.Header
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		writeJSONResponse(w, http.StatusOK, []interface{}{})
+	})
+
+	t.Run("config file", func(t *testing.T) {
+		config := writeFile(t, "config.yaml", "url: "+url+"\napi_key: file-key\ntoken: file-token\noutput: json\n")
+		r := runCLI(t, map[string]string{envConfig: config}, "flags", "list")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "[]\n", r.stdout)
+		assert.Equal(t, "file-key", headers.Get("X-API-Key"))
+		assert.Equal(t, "Bearer file-token", headers.Get("Authorization"))
+	})
+
+	t.Run("default config file", func(t *testing.T) {
+		home := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "flagent"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "flagent", "config.yaml"), []byte("url: "+url+"\n"), 0644))
+		r := runCLI(t, map[string]string{"HOME": home}, "flags", "list")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "ID  KEY  ENABLED  DESCRIPTION\n", r.stdout)
+	})
+
+	t.Run("environment overrides config file", func(t *testing.T) {
+		config := writeFile(t, "config.yaml", "url: http://unused.invalid\napi_key: file-key\noutput: json\n")
+		env := map[string]string{envConfig: config, envURL: url, envAPIKey: "env-key", envOutput: "table"}
+		r := runCLI(t, env, "flags", "list")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "env-key", headers.Get("X-API-Key"))
+		assert.Contains(t, r.stdout, "DESCRIPTION")
+	})
+
+	t.Run("flags override environment", func(t *testing.T) {
+		env := map[string]string{envURL: "http://unused.invalid", envAPIKey: "env-key"}
+		r := runCLI(t, env, "flags", "list", "--url", url+"/api/v1/", "--api-key", "flag-key", "-o", "json")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "flag-key", headers.Get("X-API-Key"))
+		assert.Equal(t, "[]\n", r.stdout)
+	})
+
+	t.Run("missing URL", func(t *testing.T) {
+		r := runCLI(t, nil, "flags", "list")
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, "no Flagent URL")
+	})
+
+	t.Run("missing explicit config file", func(t *testing.T) {
+		r := runCLI(t, nil, "flags", "list", "--config", filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, "failed to read config file")
+	})
+
+	t.Run("unknown output format", func(t *testing.T) {
+		r := runCLI(t, map[string]string{envURL: url}, "flags", "list", "--output", "csv")
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, `unknown output format "csv"`)
+	})
+}
+
+func TestAPIURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"http://localhost:18000":          "http://localhost:18000/api/v1",
+		"http://localhost:18000/":         "http://localhost:18000/api/v1",
+		"https://flagent.example.com/api": "https://flagent.example.com/api/v1",
+		"https://example.com/api/v1/":     "https://example.com/api/v1",
+	} {
+		assert.Equal(t, want, apiURL(in), in)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	for status, want := range map[int]int{
+		http.StatusBadRequest:   exitFailure,
+		http.StatusUnauthorized: exitAuth,
+		http.StatusForbidden:    exitAuth,
+		http.StatusNotFound:     exitNotFound,
+	} {
+		url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+			writeJSONResponse(w, status, map[string]string{"message": "nope"})
+		})
+		r := runCLI(t, map[string]string{envURL: url}, "flags", "get", "42")
+		assert.Equal(t, want, r.code, "status %d", status)
+		assert.True(t, strings.HasPrefix(r.stderr, "flagent: "), r.stderr)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	enhanced "github.com/MaxLuxs/Flagent/sdk/go-enhanced"
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+)
+
+// sqliteHeader starts every SQLite database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+func runSnapshot(c *cli, args []string) error {
+	return c.subcommand("snapshot", args, map[string]func(*cli, []string) error{
+		"fetch":   snapshotFetch,
+		"inspect": snapshotInspect,
+		"diff":    snapshotDiff,
+	})
+}
+
+func snapshotFetch(c *cli, args []string) error {
+	fs, o := c.newFlagSet("snapshot fetch [flags]")
+	o.addServerFlags(fs)
+	file := fs.String("file", "", "write the snapshot to this file instead of stdout")
+	sqlite := fs.Bool("sqlite", false, "download the SQLite export (/export/sqlite) instead of the JSON snapshot; requires --file")
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("snapshot fetch takes no arguments")
+	}
+	if *sqlite && *file == "" {
+		return usagef("--sqlite requires --file")
+	}
+	client, err := o.client()
+	if err != nil {
+		return err
+	}
+
+	fetcher := enhanced.NewSnapshotFetcher(client)
+	var snapshot *enhanced.FlagSnapshot
+	var data []byte
+	if *sqlite {
+		snapshot, data, err = fetcher.FetchSQLiteSnapshot(c.ctx, 0)
+	} else {
+		if snapshot, err = fetcher.FetchSnapshot(c.ctx, 0); err == nil {
+			data, err = json.MarshalIndent(snapshot, "", "  ")
+			data = append(data, '\n')
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		_, err = c.stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*file, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "Saved %d flags (revision %q) to %s\n", len(snapshot.Flags), snapshot.Revision, *file)
+	return nil
+}
+
+// loadSnapshot reads a snapshot written by snapshot fetch: the JSON format of
+// FileSnapshotStorage or a SQLite export
+func loadSnapshot(path string) (*enhanced.FlagSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, sqliteHeader) {
+		return enhanced.LoadSQLiteSnapshot(path, 0)
+	}
+	var snapshot enhanced.FlagSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("%s is not a snapshot: %w", path, err)
+	}
+	if snapshot.Flags == nil {
+		return nil, fmt.Errorf("%s is not a snapshot: no flags field", path)
+	}
+	return &snapshot, nil
+}
+
+// snapshotFlags returns the flags of a snapshot ordered by key
+func snapshotFlags(snapshot *enhanced.FlagSnapshot) []*enhanced.LocalFlag {
+	flags := make([]*enhanced.LocalFlag, 0, len(snapshot.Flags))
+	for _, f := range snapshot.Flags {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags
+}
+
+// snapshotSummary is the JSON output of snapshot inspect
+type snapshotSummary struct {
+	Revision  string        `json:"revision"`
+	FetchedAt time.Time     `json:"fetchedAt"`
+	Flags     []flagSummary `json:"flags"`
+}
+
+type flagSummary struct {
+	ID       int64  `json:"id"`
+	Key      string `json:"key"`
+	Enabled  bool   `json:"enabled"`
+	Segments int    `json:"segments"`
+	Variants int    `json:"variants"`
+}
+
+func snapshotInspect(c *cli, args []string) error {
+	fs, o := c.newFlagSet("snapshot inspect <file> [flags]")
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("snapshot inspect takes one snapshot file")
+	}
+	snapshot, err := loadSnapshot(positional[0])
+	if err != nil {
+		return err
+	}
+
+	summary := snapshotSummary{
+		Revision:  snapshot.Revision,
+		FetchedAt: time.UnixMilli(snapshot.FetchedAt).UTC(),
+		Flags:     []flagSummary{},
+	}
+	for _, f := range snapshotFlags(snapshot) {
+		summary.Flags = append(summary.Flags, flagSummary{
+			ID: f.ID, Key: f.Key, Enabled: f.Enabled, Segments: len(f.Segments), Variants: len(f.Variants),
+		})
+	}
+	if o.output == outputJSON {
+		return writeJSON(c.stdout, summary)
+	}
+	fmt.Fprintf(c.stdout, "Revision: %s\nFetched:  %s\nFlags:    %d\n\n", summary.Revision, summary.FetchedAt.Format(time.RFC3339), len(summary.Flags))
+	t := newTable(c.stdout, "ID", "KEY", "ENABLED", "SEGMENTS", "VARIANTS")
+	for _, f := range summary.Flags {
+		t.row(f.ID, f.Key, f.Enabled, f.Segments, f.Variants)
+	}
+	return t.flush()
+}
+
+// snapshotDiffResult is the JSON output of snapshot diff
+type snapshotDiffResult struct {
+	Added   []string      `json:"added"`
+	Removed []string      `json:"removed"`
+	Changed []flagChanges `json:"changed"`
+}
+
+type flagChanges struct {
+	Key     string   `json:"key"`
+	Changes []string `json:"changes"`
+}
+
+func (d *snapshotDiffResult) empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Changed) == 0
+}
+
+func snapshotDiff(c *cli, args []string) error {
+	fs, o := c.newFlagSet("snapshot diff <old-file> <new-file> [flags]")
+	exitCode := fs.Bool("exit-code", false, fmt.Sprintf("exit with %d if the snapshots differ", exitDiffer))
+	positional, err := c.parse(fs, o, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usagef("snapshot diff takes two snapshot files")
+	}
+	from, err := loadSnapshot(positional[0])
+	if err != nil {
+		return err
+	}
+	to, err := loadSnapshot(positional[1])
+	if err != nil {
+		return err
+	}
+
+	diff := diffSnapshots(from, to)
+	if o.output == outputJSON {
+		err = writeJSON(c.stdout, diff)
+	} else {
+		err = printSnapshotDiff(c, diff)
+	}
+	if err == nil && *exitCode && !diff.empty() {
+		return &exitError{code: exitDiffer}
+	}
+	return err
+}
+
+// diffSnapshots matches flags by key. Within a flag, segments, constraints and
+// variants are matched by ID, so both snapshots should come from the same server.
+func diffSnapshots(from, to *enhanced.FlagSnapshot) *snapshotDiffResult {
+	diff := &snapshotDiffResult{Added: []string{}, Removed: []string{}, Changed: []flagChanges{}}
+	old := make(map[string]*enhanced.LocalFlag, len(from.Flags))
+	for _, f := range from.Flags {
+		old[f.Key] = f
+	}
+	for _, f := range snapshotFlags(to) {
+		previous, ok := old[f.Key]
+		delete(old, f.Key)
+		if !ok {
+			diff.Added = append(diff.Added, f.Key)
+			continue
+		}
+		changes := flagent.DiffFlags(localToFlag(previous), localToFlag(f))
+		if len(changes) == 0 {
+			continue
+		}
+		lines := make([]string, len(changes))
+		for i, change := range changes {
+			lines[i] = change.String()
+		}
+		diff.Changed = append(diff.Changed, flagChanges{Key: f.Key, Changes: lines})
+	}
+	diff.Removed = sortedKeys(old)
+	return diff
+}
+
+func printSnapshotDiff(c *cli, diff *snapshotDiffResult) error {
+	if diff.empty() {
+		_, err := fmt.Fprintln(c.stdout, "No differences.")
+		return err
+	}
+	for _, key := range diff.Added {
+		fmt.Fprintf(c.stdout, "+ %s\n", key)
+	}
+	for _, key := range diff.Removed {
+		fmt.Fprintf(c.stdout, "- %s\n", key)
+	}
+	for _, f := range diff.Changed {
+		fmt.Fprintf(c.stdout, "~ %s\n", f.Key)
+		for _, change := range f.Changes {
+			fmt.Fprintf(c.stdout, "    %s\n", change)
+		}
+	}
+	_, err := fmt.Fprintf(c.stdout, "\n%d added, %d removed, %d changed.\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	return err
+}
+
+// localToFlag converts a snapshot flag to the SDK's Flag so it can be compared with DiffFlags
+func localToFlag(local *enhanced.LocalFlag) *flagent.Flag {
+	flag := &flagent.Flag{
+		Id:          local.ID,
+		Key:         local.Key,
+		Description: local.Description,
+		Enabled:     local.Enabled,
+		EntityType:  *api.NewNullableString(&local.EntityType),
+	}
+	for _, v := range local.Variants {
+		flag.Variants = append(flag.Variants, flagent.Variant{Id: v.ID, FlagID: v.FlagID, Key: v.Key, Attachment: v.Attachment})
+	}
+	for _, s := range local.Segments {
+		segment := flagent.Segment{
+			Id:             s.ID,
+			FlagID:         s.FlagID,
+			Description:    s.Description,
+			Rank:           int64(s.Rank),
+			RolloutPercent: int64(s.RolloutPercent),
+		}
+		for _, con := range s.Constraints {
+			segment.Constraints = append(segment.Constraints, flagent.Constraint{
+				Id: con.ID, SegmentID: s.ID, Property: con.Property, Operator: con.Operator, Value: con.Value,
+			})
+		}
+		for _, d := range s.Distributions {
+			variantKey := d.VariantKey
+			segment.Distributions = append(segment.Distributions, flagent.Distribution{
+				Id: d.ID, SegmentID: s.ID, VariantID: d.VariantID, VariantKey: *api.NewNullableString(&variantKey), Percent: int64(d.Percent),
+			})
+		}
+		flag.Segments = append(flag.Segments, segment)
+	}
+	return flag
+}
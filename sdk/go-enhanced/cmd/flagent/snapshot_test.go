@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	enhanced "github.com/MaxLuxs/Flagent/sdk/go-enhanced"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportFixture is a SQLite export with new_checkout and 118 filler flags
+const exportFixture = "../../testdata/export.sqlite"
+
+// writeSnapshot writes flags as a JSON snapshot file and returns its path
+func writeSnapshot(t *testing.T, name string, flags ...*enhanced.LocalFlag) string {
+	t.Helper()
+	snapshot := enhanced.FlagSnapshot{Flags: map[int64]*enhanced.LocalFlag{}, FetchedAt: 1767225600000, Revision: "r1"}
+	for _, f := range flags {
+		snapshot.Flags[f.ID] = f
+	}
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	return writeFile(t, name, string(data))
+}
+
+func TestSnapshotFetch(t *testing.T) {
+	url := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/export/eval_cache/json", r.URL.Path)
+		writeJSONResponse(w, http.StatusOK, map[string]interface{}{"flags": []interface{}{checkoutFlag}, "revision": "r42"})
+	})
+	env := map[string]string{envURL: url}
+
+	t.Run("to file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.json")
+		r := runCLI(t, env, "snapshot", "fetch", "--file", path)
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, `Saved 1 flags (revision "r42") to `+path+"\n", r.stderr)
+
+		snapshot, err := loadSnapshot(path)
+		require.NoError(t, err)
+		assert.Equal(t, "r42", snapshot.Revision)
+		flag := snapshot.GetFlagByKey("new_checkout")
+		require.NotNil(t, flag)
+		require.Len(t, flag.Segments, 1)
+		assert.Equal(t, 50, flag.Segments[0].RolloutPercent)
+	})
+
+	t.Run("to stdout", func(t *testing.T) {
+		r := runCLI(t, env, "snapshot", "fetch")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Contains(t, r.stdout, `"revision": "r42"`)
+	})
+
+	t.Run("sqlite without file", func(t *testing.T) {
+		r := runCLI(t, env, "snapshot", "fetch", "--sqlite")
+		assert.Equal(t, exitUsage, r.code)
+		assert.Contains(t, r.stderr, "--sqlite requires --file")
+	})
+}
+
+func TestSnapshotInspect(t *testing.T) {
+	t.Run("JSON snapshot", func(t *testing.T) {
+		path := writeSnapshot(t, "snapshot.json",
+			&enhanced.LocalFlag{ID: 2, Key: "dark_mode", Enabled: true, Variants: []*enhanced.LocalVariant{{ID: 1, Key: "on"}}},
+			&enhanced.LocalFlag{ID: 1, Key: "beta_banner"},
+		)
+		r := runCLI(t, nil, "snapshot", "inspect", path)
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, `Revision: r1
+Fetched:  2026-01-01T00:00:00Z
+Flags:    2
+
+ID  KEY          ENABLED  SEGMENTS  VARIANTS
+1   beta_banner  false    0         0
+2   dark_mode    true     0         1
+`, r.stdout)
+	})
+
+	t.Run("SQLite export as JSON", func(t *testing.T) {
+		r := runCLI(t, nil, "snapshot", "inspect", exportFixture, "-o", "json")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		var summary snapshotSummary
+		require.NoError(t, json.Unmarshal([]byte(r.stdout), &summary))
+		assert.Len(t, summary.Flags, 119)
+		assert.Contains(t, summary.Flags, flagSummary{ID: 1, Key: "new_checkout", Enabled: true, Segments: 2, Variants: 2})
+	})
+
+	t.Run("not a snapshot", func(t *testing.T) {
+		r := runCLI(t, nil, "snapshot", "inspect", writeFile(t, "flags.json", `{"name":"x"}`))
+		assert.Equal(t, exitFailure, r.code)
+		assert.Contains(t, r.stderr, "is not a snapshot")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		r := runCLI(t, nil, "snapshot", "inspect", filepath.Join(t.TempDir(), "missing.json"))
+		assert.Equal(t, exitFailure, r.code)
+		assert.Contains(t, r.stderr, "no such file")
+	})
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	segment := func(rollout int) []*enhanced.LocalSegment {
+		return []*enhanced.LocalSegment{{ID: 3, FlagID: 1, Description: "Everyone", RolloutPercent: rollout}}
+	}
+	old := writeSnapshot(t, "old.json",
+		&enhanced.LocalFlag{ID: 1, Key: "new_checkout", Enabled: true, Segments: segment(20)},
+		&enhanced.LocalFlag{ID: 2, Key: "old_banner", Enabled: true},
+	)
+	changed := writeSnapshot(t, "new.json",
+		&enhanced.LocalFlag{ID: 1, Key: "new_checkout", Enabled: true, Segments: segment(50)},
+		&enhanced.LocalFlag{ID: 4, Key: "dark_mode"},
+	)
+
+	t.Run("table", func(t *testing.T) {
+		r := runCLI(t, nil, "snapshot", "diff", old, changed)
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, `+ dark_mode
+- old_banner
+~ new_checkout
+    segment 3 rollout 20%→50%
+
+1 added, 1 removed, 1 changed.
+`, r.stdout)
+	})
+
+	t.Run("json with exit code", func(t *testing.T) {
+		r := runCLI(t, nil, "snapshot", "diff", "--exit-code", old, changed, "-o", "json")
+		assert.Equal(t, exitDiffer, r.code)
+		assert.Empty(t, r.stderr)
+		var diff snapshotDiffResult
+		require.NoError(t, json.Unmarshal([]byte(r.stdout), &diff))
+		assert.Equal(t, []string{"dark_mode"}, diff.Added)
+		assert.Equal(t, []string{"old_banner"}, diff.Removed)
+		require.Len(t, diff.Changed, 1)
+		assert.Equal(t, "new_checkout", diff.Changed[0].Key)
+	})
+
+	t.Run("no differences", func(t *testing.T) {
+		r := runCLI(t, nil, "snapshot", "diff", "--exit-code", old, old)
+		require.Equal(t, exitOK, r.code, r.stderr)
+		assert.Equal(t, "No differences.\n", r.stdout)
+	})
+
+	t.Run("one file", func(t *testing.T) {
+		r := runCLI(t, nil, "snapshot", "diff", old)
+		assert.Equal(t, exitUsage, r.code)
+	})
+}
@@ -16,6 +16,9 @@ type Config struct {
 	// SnapshotRefreshInterval is the interval for automatic snapshot refresh
 	// Set to 0 to disable auto-refresh
 	SnapshotRefreshInterval time.Duration
+
+	// Telemetry receives traces and metrics (nil disables instrumentation)
+	Telemetry Telemetry
 }
 
 // DefaultConfig returns the default configuration
@@ -51,3 +54,9 @@ func (c *Config) WithSnapshotRefreshInterval(interval time.Duration) *Config {
 	c.SnapshotRefreshInterval = interval
 	return c
 }
+
+// WithTelemetry enables instrumentation, e.g. OpenTelemetry via the otel module
+func (c *Config) WithTelemetry(telemetry Telemetry) *Config {
+	c.Telemetry = telemetry
+	return c
+}
@@ -1,6 +1,7 @@
 package flagentenhanced
 
 import (
+	"context"
 	"fmt"
 	"hash/crc32"
 	"regexp"
@@ -15,13 +16,40 @@ const (
 )
 
 // LocalEvaluator evaluates flags locally without API calls
-type LocalEvaluator struct{}
+type LocalEvaluator struct {
+	telemetry Telemetry
+}
 
 // NewLocalEvaluator creates a new local evaluator
 func NewLocalEvaluator() *LocalEvaluator {
 	return &LocalEvaluator{}
 }
 
+// WithTelemetry records evaluations made with EvaluateContext and EvaluateBatchContext
+func (e *LocalEvaluator) WithTelemetry(telemetry Telemetry) *LocalEvaluator {
+	e.telemetry = telemetry
+	return e
+}
+
+// EvaluateContext evaluates a flag like Evaluate and records the result with the
+// evaluator's Telemetry
+func (e *LocalEvaluator) EvaluateContext(ctx context.Context, req *OfflineEvaluationRequest, snapshot *FlagSnapshot) *LocalEvaluationResult {
+	result := e.Evaluate(req, snapshot)
+	if e.telemetry != nil {
+		e.telemetry.RecordEvaluation(ctx, localFlagKey(req, result), localVariantKey(result), localReason(result))
+	}
+	return result
+}
+
+// EvaluateBatchContext evaluates multiple flags like EvaluateBatch, recording each result
+func (e *LocalEvaluator) EvaluateBatchContext(ctx context.Context, requests []*OfflineEvaluationRequest, snapshot *FlagSnapshot) []*LocalEvaluationResult {
+	results := make([]*LocalEvaluationResult, len(requests))
+	for i, req := range requests {
+		results[i] = e.EvaluateContext(ctx, req, snapshot)
+	}
+	return results
+}
+
 // Evaluate evaluates a flag using local snapshot
 func (e *LocalEvaluator) Evaluate(req *OfflineEvaluationRequest, snapshot *FlagSnapshot) *LocalEvaluationResult {
 	// Find flag by key or ID
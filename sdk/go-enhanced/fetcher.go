@@ -2,12 +2,15 @@ package flagentenhanced
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	flagent "github.com/MaxLuxs/Flagent/sdk/go"
 )
 
+var errNoClient = errors.New("no Flagent client configured")
+
 // SnapshotFetcher fetches flag snapshots from the server
 type SnapshotFetcher struct {
 	client *flagent.Client
@@ -22,6 +25,10 @@ func NewSnapshotFetcher(client *flagent.Client) *SnapshotFetcher {
 
 // FetchSnapshot fetches a fresh snapshot from the server
 func (f *SnapshotFetcher) FetchSnapshot(ctx context.Context, ttlMs int64) (*FlagSnapshot, error) {
+	if f.client == nil {
+		return nil, errNoClient
+	}
+
 	// Get snapshot from server (using export endpoint)
 	serverSnapshot, err := f.client.GetSnapshot(ctx)
 	if err != nil {
@@ -137,6 +144,26 @@ func (f *SnapshotFetcher) FetchSnapshot(ctx context.Context, ttlMs int64) (*Flag
 	return localSnapshot, nil
 }
 
+// FetchSQLiteSnapshot downloads the SQLite export and builds a snapshot from it.
+// The raw file is returned as well so it can be persisted.
+func (f *SnapshotFetcher) FetchSQLiteSnapshot(ctx context.Context, ttlMs int64) (*FlagSnapshot, []byte, error) {
+	if f.client == nil {
+		return nil, nil, errNoClient
+	}
+
+	data, err := f.client.ExportSQLite(ctx, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch SQLite export: %w", err)
+	}
+
+	snapshot, err := ParseSQLiteSnapshot(data, ttlMs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse SQLite export: %w", err)
+	}
+
+	return snapshot, data, nil
+}
+
 // getRevision extracts revision from server snapshot
 func getRevision(snapshot *flagent.FlagSnapshot) string {
 	if snapshot.Revision == nil {
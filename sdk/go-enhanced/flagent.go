@@ -36,6 +36,8 @@ type Options struct {
 	HTTPClient *http.Client
 	// Timeout is used when HTTPClient is nil (default: 30s).
 	Timeout time.Duration
+	// CircuitBreaker guards remote evaluation and snapshot downloads (optional).
+	CircuitBreaker *flagent.CircuitBreaker
 
 	// Offline enables client-side evaluation (OfflineManager). If false, server-side Manager is used.
 	Offline bool
@@ -51,6 +53,13 @@ type Options struct {
 	AutoRefresh      bool
 	RefreshInterval  time.Duration
 	SnapshotTTL      time.Duration
+	// SnapshotSource selects the eval cache (default) or the SQLite export
+	SnapshotSource SnapshotSource
+	// SQLitePath is where the SQLite export is read from and stored (optional)
+	SQLitePath string
+
+	// Telemetry receives traces and metrics in either mode (optional)
+	Telemetry Telemetry
 
 	EnableDebugLogging bool
 }
@@ -90,6 +99,10 @@ func NewFlagent(ctx context.Context, baseURL string, opts Options) (Client, erro
 		clientOpts = append(clientOpts, flagent.WithTimeout(opts.Timeout))
 	}
 
+	if opts.CircuitBreaker != nil {
+		clientOpts = append(clientOpts, flagent.WithCircuitBreaker(opts.CircuitBreaker))
+	}
+
 	baseClient, err := flagent.NewClient(baseURL, clientOpts...)
 	if err != nil {
 		return nil, err
@@ -102,7 +115,14 @@ func NewFlagent(ctx context.Context, baseURL string, opts Options) (Client, erro
 			WithAutoRefresh(opts.AutoRefresh).
 			WithRefreshInterval(opts.RefreshInterval).
 			WithSnapshotTTL(opts.SnapshotTTL).
-			WithDebugLogging(opts.EnableDebugLogging)
+			WithDebugLogging(opts.EnableDebugLogging).
+			WithTelemetry(opts.Telemetry)
+		if opts.SnapshotSource != "" {
+			offlineCfg.WithSnapshotSource(opts.SnapshotSource)
+		}
+		if opts.SQLitePath != "" {
+			offlineCfg.WithSQLiteFile(opts.SQLitePath)
+		}
 		om := NewOfflineManager(baseClient, offlineCfg)
 		if err := om.Bootstrap(ctx, false); err != nil {
 			om.Close()
@@ -115,7 +135,8 @@ func NewFlagent(ctx context.Context, baseURL string, opts Options) (Client, erro
 		WithCacheTTL(opts.CacheTTL).
 		WithEnableCache(opts.EnableCache).
 		WithSnapshotRefreshInterval(opts.SnapshotRefreshInterval).
-		WithDebugLogging(opts.EnableDebugLogging)
+		WithDebugLogging(opts.EnableDebugLogging).
+		WithTelemetry(opts.Telemetry)
 	mgr := NewManager(baseClient, cfg)
 	return &serverClientAdapter{manager: mgr}, nil
 }
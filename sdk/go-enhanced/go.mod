@@ -5,12 +5,12 @@ go 1.21
 require (
 	github.com/MaxLuxs/Flagent/sdk/go v0.0.0
 	github.com/stretchr/testify v1.8.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/MaxLuxs/Flagent/sdk/go => ../go
@@ -55,20 +55,25 @@ func (m *Manager) Evaluate(ctx context.Context, flagKey string, entityID string,
 
 	// Check cache if enabled
 	if m.config.EnableCache && m.cache != nil {
-		if result, ok := m.cache.Get(cacheKey); ok {
+		result, ok := m.cache.Get(cacheKey)
+		m.telemetry().RecordCacheLookup(ctx, flagKey, ok)
+		if ok {
 			if m.config.EnableDebugLogging {
 				log.Printf("[Flagent] Cache hit for flag=%s, entity=%s", flagKey, entityID)
 			}
+			m.telemetry().RecordEvaluation(ctx, flagKey, variantKey(result), ReasonCached)
 			return result, nil
 		}
 	}
 
 	// Evaluate from server
-	result, err := m.client.Evaluate(ctx, &flagent.EvaluationContext{
+	spanCtx, end := m.telemetry().StartEvaluation(ctx, flagKey)
+	result, err := m.client.Evaluate(spanCtx, &flagent.EvaluationContext{
 		FlagKey:       flagent.StringPtr(flagKey),
 		EntityID:      flagent.StringPtr(entityID),
 		EntityContext: entityContext,
 	})
+	end(variantKey(result), err)
 	if err != nil {
 		return nil, err
 	}
@@ -86,10 +91,13 @@ func (m *Manager) Evaluate(ctx context.Context, flagKey string, entityID string,
 
 // EvaluateBatch evaluates multiple flags for multiple entities
 func (m *Manager) EvaluateBatch(ctx context.Context, flagKeys []string, entities []flagent.EvaluationEntity) ([]*flagent.EvaluationResult, error) {
-	return m.client.EvaluateBatch(ctx, &flagent.BatchEvaluationRequest{
+	ctx, end := m.telemetry().StartBatchEvaluation(ctx, len(flagKeys)*len(entities))
+	results, err := m.client.EvaluateBatch(ctx, &flagent.BatchEvaluationRequest{
 		FlagKeys: flagKeys,
 		Entities: entities,
 	})
+	end(results, err)
+	return results, err
 }
 
 // IsEnabled checks if a flag is enabled for a given entity
@@ -113,6 +121,32 @@ func (m *Manager) GetVariant(ctx context.Context, flagKey string, entityID strin
 	return *result.VariantKey, nil
 }
 
+// IsEnabledOrDefault is IsEnabled with a fallback: when the flag cannot be evaluated,
+// e.g. because the client's circuit breaker is open, it returns defaultValue
+func (m *Manager) IsEnabledOrDefault(ctx context.Context, flagKey string, entityID string, entityContext map[string]interface{}, defaultValue bool) bool {
+	enabled, err := m.IsEnabled(ctx, flagKey, entityID, entityContext)
+	if err != nil {
+		if m.config.EnableDebugLogging {
+			log.Printf("[Flagent] Using default for flag=%s: %v", flagKey, err)
+		}
+		return defaultValue
+	}
+	return enabled
+}
+
+// GetVariantOrDefault is GetVariant with a fallback: when the flag cannot be evaluated,
+// e.g. because the client's circuit breaker is open, it returns defaultVariant
+func (m *Manager) GetVariantOrDefault(ctx context.Context, flagKey string, entityID string, entityContext map[string]interface{}, defaultVariant string) string {
+	variant, err := m.GetVariant(ctx, flagKey, entityID, entityContext)
+	if err != nil {
+		if m.config.EnableDebugLogging {
+			log.Printf("[Flagent] Using default for flag=%s: %v", flagKey, err)
+		}
+		return defaultVariant
+	}
+	return variant
+}
+
 // ClearCache clears all cached entries
 func (m *Manager) ClearCache() {
 	if m.cache != nil {
@@ -133,6 +167,19 @@ func (m *Manager) EvictExpired() {
 	}
 }
 
+// telemetry returns the configured Telemetry, or a no-op one
+func (m *Manager) telemetry() Telemetry {
+	return telemetryOrNoop(m.config.Telemetry)
+}
+
+// variantKey returns the variant of a server-side result, or ""
+func variantKey(result *flagent.EvaluationResult) string {
+	if result == nil || result.VariantKey == nil {
+		return ""
+	}
+	return *result.VariantKey
+}
+
 // generateCacheKey generates a cache key for evaluation
 func (m *Manager) generateCacheKey(flagKey string, entityID string, entityContext map[string]interface{}) string {
 	// Simple implementation - can be improved with better hashing
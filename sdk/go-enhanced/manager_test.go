@@ -239,3 +239,28 @@ func TestManagerEvaluateBatch(t *testing.T) {
 	assert.Equal(t, "f1", results[0].GetFlagKey())
 	assert.Equal(t, "f2", results[1].GetFlagKey())
 }
+
+func TestManagerCircuitBreakerFallback(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	breaker := flagent.NewCircuitBreaker(flagent.CircuitBreakerConfig{WindowSize: 2, MinimumCalls: 2})
+	client, err := flagent.NewClient(server.URL, flagent.WithMaxRetries(0), flagent.WithCircuitBreaker(breaker))
+	require.NoError(t, err)
+	manager := NewManager(client, DefaultConfig())
+	defer manager.Close()
+
+	ctx := context.Background()
+	assert.True(t, manager.IsEnabledOrDefault(ctx, "checkout", "user1", nil, true))
+	assert.Equal(t, "control", manager.GetVariantOrDefault(ctx, "checkout", "user1", nil, "control"))
+	assert.Equal(t, flagent.CircuitOpen, breaker.State())
+
+	_, err = manager.Evaluate(ctx, "checkout", "user1", nil)
+	assert.ErrorIs(t, err, flagent.ErrCircuitOpen)
+	assert.False(t, manager.IsEnabledOrDefault(ctx, "checkout", "user1", nil, false))
+	assert.Equal(t, 2, calls, "open circuit fails fast")
+}
@@ -2,6 +2,17 @@ package flagentenhanced
 
 import "time"
 
+// SnapshotSource selects the server artifact an OfflineManager builds snapshots from
+type SnapshotSource string
+
+const (
+	// SnapshotSourceEvalCache uses the JSON eval cache (GET /export/eval_cache/json)
+	SnapshotSourceEvalCache SnapshotSource = "eval_cache"
+
+	// SnapshotSourceSQLite uses the SQLite database export (GET /export/sqlite)
+	SnapshotSourceSQLite SnapshotSource = "sqlite"
+)
+
 // OfflineConfig represents configuration for offline/client-side evaluation
 type OfflineConfig struct {
 	// EnablePersistence enables persistent storage of snapshots
@@ -19,6 +30,16 @@ type OfflineConfig struct {
 	// SnapshotTTL is the time-to-live for snapshots (default: 5 minutes)
 	SnapshotTTL time.Duration
 
+	// SnapshotSource selects where snapshots come from (default: SnapshotSourceEvalCache)
+	SnapshotSource SnapshotSource
+
+	// SQLitePath is the SQLite export file to read and keep up to date when
+	// SnapshotSource is SnapshotSourceSQLite (default: StorageDir/flagent.sqlite)
+	SQLitePath string
+
+	// Telemetry receives traces and metrics (nil disables instrumentation)
+	Telemetry Telemetry
+
 	// EnableDebugLogging enables debug logging
 	EnableDebugLogging bool
 }
@@ -31,6 +52,7 @@ func DefaultOfflineConfig() *OfflineConfig {
 		AutoRefresh:        true,
 		RefreshInterval:    60 * time.Second,
 		SnapshotTTL:        5 * time.Minute,
+		SnapshotSource:     SnapshotSourceEvalCache,
 		EnableDebugLogging: false,
 	}
 }
@@ -65,6 +87,25 @@ func (c *OfflineConfig) WithSnapshotTTL(ttl time.Duration) *OfflineConfig {
 	return c
 }
 
+// WithSnapshotSource sets the snapshot source
+func (c *OfflineConfig) WithSnapshotSource(source SnapshotSource) *OfflineConfig {
+	c.SnapshotSource = source
+	return c
+}
+
+// WithSQLiteFile uses the SQLite export at path as the snapshot source
+func (c *OfflineConfig) WithSQLiteFile(path string) *OfflineConfig {
+	c.SnapshotSource = SnapshotSourceSQLite
+	c.SQLitePath = path
+	return c
+}
+
+// WithTelemetry enables instrumentation, e.g. OpenTelemetry via the otel module
+func (c *OfflineConfig) WithTelemetry(telemetry Telemetry) *OfflineConfig {
+	c.Telemetry = telemetry
+	return c
+}
+
 // WithDebugLogging enables or disables debug logging
 func (c *OfflineConfig) WithDebugLogging(enable bool) *OfflineConfig {
 	c.EnableDebugLogging = enable
@@ -30,6 +30,9 @@ type OfflineManager struct {
 	// Real-time updates
 	sseClient       *SSEClient
 	sseStopOnce     sync.Once
+
+	// Telemetry
+	unobserveSnapshotAge func()
 }
 
 // NewOfflineManager creates a new offline manager
@@ -40,26 +43,41 @@ func NewOfflineManager(client *flagent.Client, config *OfflineConfig) *OfflineMa
 
 	// Create storage
 	var storage SnapshotStorage
-	if config.EnablePersistence {
-		storageDir := config.StorageDir
-		if storageDir == "" {
-			// Default to ~/.flagent
-			home, _ := os.UserHomeDir()
-			storageDir = filepath.Join(home, ".flagent")
+	storageDir := config.StorageDir
+	if storageDir == "" {
+		// Default to ~/.flagent
+		home, _ := os.UserHomeDir()
+		storageDir = filepath.Join(home, ".flagent")
+	}
+	switch {
+	case config.SnapshotSource == SnapshotSourceSQLite && (config.EnablePersistence || config.SQLitePath != ""):
+		sqlitePath := config.SQLitePath
+		if sqlitePath == "" {
+			sqlitePath = filepath.Join(storageDir, "flagent.sqlite")
 		}
+		storage = NewSQLiteSnapshotStorage(sqlitePath, config.SnapshotTTL)
+	case config.EnablePersistence:
 		storage = NewFileSnapshotStorage(storageDir)
-	} else {
+	default:
 		storage = NewInMemorySnapshotStorage()
 	}
 
-	return &OfflineManager{
+	manager := &OfflineManager{
 		client:      client,
 		config:      config,
-		evaluator:   NewLocalEvaluator(),
+		evaluator:   NewLocalEvaluator().WithTelemetry(config.Telemetry),
 		fetcher:     NewSnapshotFetcher(client),
 		storage:     storage,
 		stopRefresh: make(chan struct{}),
 	}
+
+	unregister, err := manager.telemetry().ObserveSnapshotAge(config.SnapshotSource, manager.snapshotAge)
+	if err != nil && config.EnableDebugLogging {
+		log.Printf("[Flagent] Failed to observe snapshot age: %v", err)
+	}
+	manager.unobserveSnapshotAge = unregister
+
+	return manager
 }
 
 // Bootstrap initializes the manager by loading cached snapshot or fetching from server
@@ -126,7 +144,7 @@ func (m *OfflineManager) Evaluate(ctx context.Context, flagKey string, entityID
 		EnableDebug:   m.config.EnableDebugLogging,
 	}
 
-	return m.evaluator.Evaluate(req, snapshot), nil
+	return m.evaluator.EvaluateContext(ctx, req, snapshot), nil
 }
 
 // IsEnabled checks if a flag is enabled for a given entity
@@ -157,9 +175,12 @@ func (m *OfflineManager) EvaluateBatch(ctx context.Context, requests []*OfflineE
 		return nil, err
 	}
 
-	return m.evaluator.EvaluateBatch(requests, snapshot), nil
+	return m.evaluator.EvaluateBatchContext(ctx, requests, snapshot), nil
 }
 
+// OfflineManager can be refreshed by a flagent.WebhookReceiver
+var _ flagent.WebhookRefresher = (*OfflineManager)(nil)
+
 // Refresh manually refreshes the snapshot from server
 func (m *OfflineManager) Refresh(ctx context.Context) error {
 	m.snapshotMutex.Lock()
@@ -188,6 +209,20 @@ func (m *OfflineManager) GetSnapshotAge() (int64, error) {
 	return time.Now().UnixMilli() - m.snapshot.FetchedAt, nil
 }
 
+// telemetry returns the configured Telemetry, or a no-op one
+func (m *OfflineManager) telemetry() Telemetry {
+	return telemetryOrNoop(m.config.Telemetry)
+}
+
+// snapshotAge reports the age of the current snapshot, if any, for telemetry
+func (m *OfflineManager) snapshotAge() (time.Duration, bool) {
+	age, err := m.GetSnapshotAge()
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(age) * time.Millisecond, true
+}
+
 // IsSnapshotExpired returns true if the snapshot is expired
 func (m *OfflineManager) IsSnapshotExpired() bool {
 	m.snapshotMutex.RLock()
@@ -211,7 +246,9 @@ func (m *OfflineManager) ClearCache() error {
 	return m.storage.Clear()
 }
 
-// EnableRealtimeUpdates enables real-time updates via SSE
+// EnableRealtimeUpdates enables real-time updates via SSE. If the server has no SSE
+// endpoint (404), the SSE client stops with an error matching flagent.ErrUnsupported
+// instead of reconnecting, and the manager keeps relying on periodic refresh.
 func (m *OfflineManager) EnableRealtimeUpdates(baseURL string, flagKeys []string, flagIDs []int64) error {
 	if m.sseClient != nil {
 		return errors.New("real-time updates already enabled")
@@ -219,6 +256,7 @@ func (m *OfflineManager) EnableRealtimeUpdates(baseURL string, flagKeys []string
 
 	sseConfig := DefaultSSEConfig()
 	sseConfig.EnableDebugLogging = m.config.EnableDebugLogging
+	sseConfig.Telemetry = m.config.Telemetry
 
 	m.sseClient = NewSSEClient(baseURL, nil, sseConfig)
 	m.sseClient.Connect(flagKeys, flagIDs)
@@ -308,6 +346,9 @@ func (m *OfflineManager) handleSSEEvents() {
 func (m *OfflineManager) Close() {
 	m.refreshStopOnce.Do(func() {
 		close(m.stopRefresh)
+		if m.unobserveSnapshotAge != nil {
+			m.unobserveSnapshotAge()
+		}
 	})
 
 	m.DisableRealtimeUpdates()
@@ -342,7 +383,13 @@ func (m *OfflineManager) getSnapshot() (*FlagSnapshot, error) {
 
 // fetchAndSave fetches a fresh snapshot and saves it
 func (m *OfflineManager) fetchAndSave(ctx context.Context) error {
+	if m.config.SnapshotSource == SnapshotSourceSQLite {
+		return m.fetchAndSaveSQLite(ctx)
+	}
+
+	ctx, end := m.telemetry().StartSnapshotFetch(ctx, SnapshotSourceEvalCache)
 	snapshot, err := m.fetcher.FetchSnapshot(ctx, m.config.SnapshotTTL.Milliseconds())
+	end(snapshot, err)
 	if err != nil {
 		return err
 	}
@@ -363,6 +410,37 @@ func (m *OfflineManager) fetchAndSave(ctx context.Context) error {
 	return nil
 }
 
+// fetchAndSaveSQLite downloads the SQLite export and persists the file itself
+// when the storage supports it
+func (m *OfflineManager) fetchAndSaveSQLite(ctx context.Context) error {
+	ctx, end := m.telemetry().StartSnapshotFetch(ctx, SnapshotSourceSQLite)
+	snapshot, data, err := m.fetcher.FetchSQLiteSnapshot(ctx, m.config.SnapshotTTL.Milliseconds())
+	end(snapshot, err)
+	if err != nil {
+		return err
+	}
+
+	m.snapshot = snapshot
+
+	if sqliteStorage, ok := m.storage.(*SQLiteSnapshotStorage); ok {
+		err = sqliteStorage.SaveSQLite(data)
+	} else {
+		err = m.storage.Save(snapshot)
+	}
+	if err != nil {
+		// Log error but don't fail - snapshot is still in memory
+		if m.config.EnableDebugLogging {
+			log.Printf("[Flagent] Failed to save SQLite export to storage: %v", err)
+		}
+	}
+
+	if m.config.EnableDebugLogging {
+		log.Printf("[Flagent] Fetched and saved SQLite export with %d flags", len(snapshot.Flags))
+	}
+
+	return nil
+}
+
 // startAutoRefresh starts automatic background refresh
 func (m *OfflineManager) startAutoRefresh() {
 	if !m.config.AutoRefresh || m.config.RefreshInterval <= 0 {
@@ -3,8 +3,10 @@ package flagentenhanced
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -253,3 +255,28 @@ func TestOfflineManager_EvaluateBatch(t *testing.T) {
 	assert.Len(t, results, 1)
 	assert.True(t, results[0].IsEnabled())
 }
+
+func TestSSEClient_ServerWithoutSSE(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/realtime/sse", r.URL.Path)
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	config := DefaultSSEConfig()
+	config.ReconnectDelay = time.Millisecond
+	sse := NewSSEClient(server.URL, nil, config)
+	sse.Connect(nil, nil)
+	defer sse.Disconnect()
+
+	select {
+	case err := <-sse.Errors():
+		assert.True(t, errors.Is(err, flagent.ErrUnsupported))
+	case <-time.After(2 * time.Second):
+		t.Fatal("no error reported")
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "a missing endpoint is not retried")
+}
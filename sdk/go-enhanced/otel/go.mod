@@ -0,0 +1,28 @@
+module github.com/MaxLuxs/Flagent/sdk/go-enhanced/otel
+
+go 1.21
+
+require (
+	github.com/MaxLuxs/Flagent/sdk/go v0.0.0
+	github.com/MaxLuxs/Flagent/sdk/go-enhanced v0.0.0
+	github.com/stretchr/testify v1.8.4
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/metric v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/sdk/metric v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	github.com/MaxLuxs/Flagent/sdk/go => ../../go
+	github.com/MaxLuxs/Flagent/sdk/go-enhanced => ../
+)
@@ -0,0 +1,287 @@
+// Package flagentotel reports Go Enhanced SDK evaluations, snapshot refreshes and SSE
+// connections as OpenTelemetry traces and metrics.
+//
+// It is a separate module so that only programs that use it depend on OpenTelemetry:
+//
+//	telemetry, err := flagentotel.NewTelemetry(tracerProvider, meterProvider)
+//	manager := enhanced.NewManager(client, enhanced.DefaultConfig().WithTelemetry(telemetry))
+package flagentotel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	enhanced "github.com/MaxLuxs/Flagent/sdk/go-enhanced"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/MaxLuxs/Flagent/sdk/go-enhanced/otel"
+
+	// providerName is reported as feature_flag.provider_name
+	providerName = "flagent"
+
+	spanEvaluate      = "flagent.evaluate"
+	spanEvaluateBatch = "flagent.evaluate_batch"
+	spanSnapshotFetch = "flagent.snapshot.fetch"
+
+	// eventEvaluation is added to the caller's span for evaluations that make no network call
+	eventEvaluation = "feature_flag.evaluation"
+)
+
+// Attribute keys from the OpenTelemetry feature flag semantic conventions
+const (
+	attrFlagKey      = attribute.Key("feature_flag.key")
+	attrVariant      = attribute.Key("feature_flag.variant")
+	attrProviderName = attribute.Key("feature_flag.provider_name")
+	attrReason       = attribute.Key("feature_flag.evaluation.reason")
+	attrErrorType    = attribute.Key("error.type")
+
+	attrSnapshotSource = attribute.Key("flagent.snapshot.source")
+	attrSnapshotFlags  = attribute.Key("flagent.snapshot.flags")
+	attrBatchSize      = attribute.Key("flagent.batch.size")
+)
+
+// Telemetry emits OpenTelemetry traces and metrics for evaluations, snapshot refreshes
+// and SSE connections. It implements enhanced.Telemetry.
+//
+// Spans:
+//   - flagent.evaluate and flagent.evaluate_batch for remote evaluations
+//   - flagent.snapshot.fetch for snapshot downloads
+//
+// Local and cached evaluations add a feature_flag.evaluation event to the caller's
+// active span instead. Metrics:
+//   - flagent.evaluations, by flag, variant and reason
+//   - flagent.cache.hits and flagent.cache.misses
+//   - flagent.snapshot.age, in seconds
+//   - flagent.snapshot.refresh_failures
+//   - flagent.sse.reconnects
+type Telemetry struct {
+	tracer trace.Tracer
+	meter  metric.Meter
+
+	evaluations     metric.Int64Counter
+	cacheHits       metric.Int64Counter
+	cacheMisses     metric.Int64Counter
+	snapshotAge     metric.Float64ObservableGauge
+	refreshFailures metric.Int64Counter
+	sseReconnects   metric.Int64Counter
+}
+
+var _ enhanced.Telemetry = (*Telemetry)(nil)
+
+// NewTelemetry creates instrumentation on the given providers.
+// Nil providers fall back to the global ones (otel.GetTracerProvider, otel.GetMeterProvider).
+func NewTelemetry(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) (*Telemetry, error) {
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+
+	t := &Telemetry{
+		tracer: tracerProvider.Tracer(instrumentationName),
+		meter:  meterProvider.Meter(instrumentationName),
+	}
+
+	var err error
+	if t.evaluations, err = t.meter.Int64Counter("flagent.evaluations",
+		metric.WithDescription("Flag evaluations by flag, variant and reason"),
+		metric.WithUnit("{evaluation}")); err != nil {
+		return nil, err
+	}
+	if t.cacheHits, err = t.meter.Int64Counter("flagent.cache.hits",
+		metric.WithDescription("Evaluations answered from the evaluation cache"),
+		metric.WithUnit("{evaluation}")); err != nil {
+		return nil, err
+	}
+	if t.cacheMisses, err = t.meter.Int64Counter("flagent.cache.misses",
+		metric.WithDescription("Evaluations not found in the evaluation cache"),
+		metric.WithUnit("{evaluation}")); err != nil {
+		return nil, err
+	}
+	if t.snapshotAge, err = t.meter.Float64ObservableGauge("flagent.snapshot.age",
+		metric.WithDescription("Time since the snapshot used for local evaluation was fetched"),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if t.refreshFailures, err = t.meter.Int64Counter("flagent.snapshot.refresh_failures",
+		metric.WithDescription("Failed snapshot fetches"),
+		metric.WithUnit("{failure}")); err != nil {
+		return nil, err
+	}
+	if t.sseReconnects, err = t.meter.Int64Counter("flagent.sse.reconnects",
+		metric.WithDescription("SSE reconnection attempts"),
+		metric.WithUnit("{reconnect}")); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// evaluationAttributes returns the feature flag attributes of one evaluation
+func evaluationAttributes(flagKey, variant string, reason enhanced.EvaluationReason) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attrFlagKey.String(flagKey),
+		attrProviderName.String(providerName),
+		attrReason.String(string(reason)),
+	}
+	if variant != "" {
+		attrs = append(attrs, attrVariant.String(variant))
+	}
+	return attrs
+}
+
+// endSpan records err on span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StartEvaluation starts the flagent.evaluate span of a server-side evaluation
+func (t *Telemetry) StartEvaluation(ctx context.Context, flagKey string) (context.Context, func(string, error)) {
+	if t == nil {
+		return ctx, func(string, error) {}
+	}
+	ctx, span := t.tracer.Start(ctx, spanEvaluate, trace.WithAttributes(
+		attrFlagKey.String(flagKey), attrProviderName.String(providerName)))
+	return ctx, func(variant string, err error) {
+		attrs := evaluationAttributes(flagKey, variant, remoteReason(variant, err))
+		if err != nil {
+			attrs = append(attrs, attrErrorType.String(errorType(err)))
+		}
+		span.SetAttributes(attrs...)
+		t.evaluations.Add(ctx, 1, metric.WithAttributes(attrs...))
+		endSpan(span, err)
+	}
+}
+
+// StartBatchEvaluation starts the flagent.evaluate_batch span of a server-side batch
+// evaluation, counting each result when it ends
+func (t *Telemetry) StartBatchEvaluation(ctx context.Context, size int) (context.Context, func([]*flagent.EvaluationResult, error)) {
+	if t == nil {
+		return ctx, func([]*flagent.EvaluationResult, error) {}
+	}
+	ctx, span := t.tracer.Start(ctx, spanEvaluateBatch, trace.WithAttributes(
+		attrProviderName.String(providerName), attrBatchSize.Int(size)))
+	return ctx, func(results []*flagent.EvaluationResult, err error) {
+		for _, result := range results {
+			if result == nil {
+				continue
+			}
+			variant := ""
+			if result.VariantKey != nil {
+				variant = *result.VariantKey
+			}
+			t.evaluations.Add(ctx, 1, metric.WithAttributes(
+				evaluationAttributes(result.GetFlagKey(), variant, remoteReason(variant, nil))...))
+		}
+		endSpan(span, err)
+	}
+}
+
+// RecordEvaluation records an evaluation that made no network call (local or cached)
+// as an event on the caller's active span
+func (t *Telemetry) RecordEvaluation(ctx context.Context, flagKey, variant string, reason enhanced.EvaluationReason) {
+	if t == nil {
+		return
+	}
+	attrs := evaluationAttributes(flagKey, variant, reason)
+	t.evaluations.Add(ctx, 1, metric.WithAttributes(attrs...))
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.AddEvent(eventEvaluation, trace.WithAttributes(attrs...))
+	}
+}
+
+// RecordCacheLookup counts an evaluation cache hit or miss
+func (t *Telemetry) RecordCacheLookup(ctx context.Context, flagKey string, hit bool) {
+	if t == nil {
+		return
+	}
+	attrs := metric.WithAttributes(attrFlagKey.String(flagKey))
+	if hit {
+		t.cacheHits.Add(ctx, 1, attrs)
+	} else {
+		t.cacheMisses.Add(ctx, 1, attrs)
+	}
+}
+
+// StartSnapshotFetch starts the flagent.snapshot.fetch span of a snapshot download,
+// counting failures when it ends
+func (t *Telemetry) StartSnapshotFetch(ctx context.Context, source enhanced.SnapshotSource) (context.Context, func(*enhanced.FlagSnapshot, error)) {
+	if t == nil {
+		return ctx, func(*enhanced.FlagSnapshot, error) {}
+	}
+	sourceAttr := attrSnapshotSource.String(string(source))
+	ctx, span := t.tracer.Start(ctx, spanSnapshotFetch, trace.WithAttributes(sourceAttr))
+	return ctx, func(snapshot *enhanced.FlagSnapshot, err error) {
+		if err != nil {
+			t.refreshFailures.Add(ctx, 1, metric.WithAttributes(sourceAttr))
+		} else if snapshot != nil {
+			span.SetAttributes(attrSnapshotFlags.Int(len(snapshot.Flags)))
+		}
+		endSpan(span, err)
+	}
+}
+
+// ObserveSnapshotAge reports the age returned by age on every collection, while age
+// reports a snapshot, until unregister is called
+func (t *Telemetry) ObserveSnapshotAge(source enhanced.SnapshotSource, age func() (time.Duration, bool)) (func(), error) {
+	if t == nil {
+		return func() {}, nil
+	}
+	attrs := metric.WithAttributes(attrSnapshotSource.String(string(source)))
+	registration, err := t.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		if d, ok := age(); ok {
+			o.ObserveFloat64(t.snapshotAge, d.Seconds(), attrs)
+		}
+		return nil
+	}, t.snapshotAge)
+	if err != nil {
+		return nil, err
+	}
+	return func() { registration.Unregister() }, nil
+}
+
+// RecordSSEReconnect counts an SSE reconnection attempt
+func (t *Telemetry) RecordSSEReconnect(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.sseReconnects.Add(ctx, 1)
+}
+
+// remoteReason maps the outcome of a server-side evaluation to an evaluation reason
+func remoteReason(variant string, err error) enhanced.EvaluationReason {
+	switch {
+	case err != nil:
+		return enhanced.ReasonError
+	case variant != "":
+		return enhanced.ReasonTargetingMatch
+	default:
+		return enhanced.ReasonDefault
+	}
+}
+
+// errorType classifies err for the error.type attribute
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, flagent.ErrFlagNotFound):
+		return "flag_not_found"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, flagent.ErrServerUnavailable), errors.Is(err, flagent.ErrCircuitOpen):
+		return "provider_not_ready"
+	default:
+		return "general"
+	}
+}
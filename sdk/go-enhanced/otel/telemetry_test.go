@@ -0,0 +1,266 @@
+package flagentotel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	enhanced "github.com/MaxLuxs/Flagent/sdk/go-enhanced"
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func makeEvalResult(flagKey, variantKey string) *api.EvalResult {
+	r := &api.EvalResult{}
+	r.SetFlagKey(flagKey)
+	r.VariantKey = *api.NewNullableString(&variantKey)
+	return r
+}
+
+type telemetryRecorder struct {
+	telemetry *Telemetry
+	tracer    *sdktrace.TracerProvider
+	spans     *tracetest.InMemoryExporter
+	metrics   *sdkmetric.ManualReader
+}
+
+func newTelemetryRecorder(t *testing.T) *telemetryRecorder {
+	t.Helper()
+	spans := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	telemetry, err := NewTelemetry(tp, mp)
+	require.NoError(t, err)
+	return &telemetryRecorder{telemetry: telemetry, tracer: tp, spans: spans, metrics: reader}
+}
+
+// span returns the ended span with the given name
+func (r *telemetryRecorder) span(t *testing.T, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range r.spans.GetSpans() {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span %q", name)
+	return tracetest.SpanStub{}
+}
+
+// collect returns the data points of the named metric
+func (r *telemetryRecorder) collect(t *testing.T, name string) metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, r.metrics.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m.Data
+			}
+		}
+	}
+	return nil
+}
+
+// count sums the named counter over data points carrying all of attrs
+func (r *telemetryRecorder) count(t *testing.T, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+	sum, _ := r.collect(t, name).(metricdata.Sum[int64])
+	var total int64
+	for _, dp := range sum.DataPoints {
+		matches := true
+		for _, kv := range attrs {
+			if v, ok := dp.Attributes.Value(kv.Key); !ok || v != kv.Value {
+				matches = false
+			}
+		}
+		if matches {
+			total += dp.Value
+		}
+	}
+	return total
+}
+
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) string {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestTelemetry_Manager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(makeEvalResult("checkout", "treatment"))
+	}))
+	defer server.Close()
+
+	client, err := flagent.NewClient(server.URL)
+	require.NoError(t, err)
+	rec := newTelemetryRecorder(t)
+	manager := enhanced.NewManager(client, enhanced.DefaultConfig().WithTelemetry(rec.telemetry))
+	defer manager.Close()
+
+	ctx, parent := rec.tracer.Tracer("test").Start(context.Background(), "handle request")
+	_, err = manager.Evaluate(ctx, "checkout", "user1", nil)
+	require.NoError(t, err)
+	_, err = manager.Evaluate(ctx, "checkout", "user1", nil)
+	require.NoError(t, err)
+	parent.End()
+
+	t.Run("remote evaluation span carries the variant", func(t *testing.T) {
+		span := rec.span(t, spanEvaluate)
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+		assert.Equal(t, "checkout", attrValue(span.Attributes, attrFlagKey))
+		assert.Equal(t, "treatment", attrValue(span.Attributes, attrVariant))
+		assert.Equal(t, providerName, attrValue(span.Attributes, attrProviderName))
+		assert.Equal(t, string(enhanced.ReasonTargetingMatch), attrValue(span.Attributes, attrReason))
+	})
+
+	t.Run("cached evaluation is an event on the caller's span", func(t *testing.T) {
+		events := rec.span(t, "handle request").Events
+		require.Len(t, events, 1)
+		assert.Equal(t, eventEvaluation, events[0].Name)
+		assert.Equal(t, "treatment", attrValue(events[0].Attributes, attrVariant))
+		assert.Equal(t, string(enhanced.ReasonCached), attrValue(events[0].Attributes, attrReason))
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		assert.Equal(t, int64(1), rec.count(t, "flagent.evaluations", attrReason.String(string(enhanced.ReasonTargetingMatch))))
+		assert.Equal(t, int64(1), rec.count(t, "flagent.evaluations", attrReason.String(string(enhanced.ReasonCached))))
+		assert.Equal(t, int64(1), rec.count(t, "flagent.cache.hits"))
+		assert.Equal(t, int64(1), rec.count(t, "flagent.cache.misses"))
+	})
+}
+
+func TestTelemetry_ManagerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := flagent.NewClient(server.URL)
+	require.NoError(t, err)
+	rec := newTelemetryRecorder(t)
+	manager := enhanced.NewManager(client, enhanced.DefaultConfig().WithEnableCache(false).WithTelemetry(rec.telemetry))
+	defer manager.Close()
+
+	_, err = manager.Evaluate(context.Background(), "missing", "user1", nil)
+	require.Error(t, err)
+
+	span := rec.span(t, spanEvaluate)
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Equal(t, string(enhanced.ReasonError), attrValue(span.Attributes, attrReason))
+	assert.Equal(t, "flag_not_found", attrValue(span.Attributes, attrErrorType))
+	assert.Equal(t, int64(1), rec.count(t, "flagent.evaluations", attrReason.String(string(enhanced.ReasonError))))
+	assert.Nil(t, rec.collect(t, "flagent.cache.misses"), "no cache lookups when caching is off")
+}
+
+func TestTelemetry_OfflineManager(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(flagent.FlagSnapshot{Flags: []api.Flag{{
+			Id:      1,
+			Key:     "test_flag",
+			Enabled: true,
+			Segments: []api.Segment{{
+				Id:             1,
+				FlagID:         1,
+				Rank:           1,
+				RolloutPercent: 100,
+				Constraints:    []api.Constraint{},
+				Distributions: []api.Distribution{
+					{Id: 1, VariantID: 1, VariantKey: *api.NewNullableString(api.PtrString("control")), Percent: 100},
+				},
+			}},
+			Variants: []api.Variant{{Id: 1, FlagID: 1, Key: "control"}},
+		}}})
+	}))
+	defer server.Close()
+
+	client, err := flagent.NewClient(server.URL, flagent.WithMaxRetries(0))
+	require.NoError(t, err)
+	rec := newTelemetryRecorder(t)
+	manager := enhanced.NewOfflineManager(client, enhanced.DefaultOfflineConfig().
+		WithPersistence(false).
+		WithAutoRefresh(false).
+		WithTelemetry(rec.telemetry))
+	defer manager.Close()
+
+	ctx := context.Background()
+	require.NoError(t, manager.Bootstrap(ctx, false))
+
+	t.Run("snapshot fetch span", func(t *testing.T) {
+		span := rec.span(t, spanSnapshotFetch)
+		assert.Equal(t, string(enhanced.SnapshotSourceEvalCache), attrValue(span.Attributes, attrSnapshotSource))
+		assert.Equal(t, "1", attrValue(span.Attributes, attrSnapshotFlags))
+	})
+
+	t.Run("local evaluations are events on the caller's span", func(t *testing.T) {
+		spanCtx, parent := rec.tracer.Tracer("test").Start(ctx, "handle request")
+		_, err := manager.Evaluate(spanCtx, "test_flag", "user1", nil)
+		require.NoError(t, err)
+		_, err = manager.Evaluate(spanCtx, "unknown_flag", "user1", nil)
+		require.NoError(t, err)
+		parent.End()
+
+		events := rec.span(t, "handle request").Events
+		require.Len(t, events, 2)
+		assert.Equal(t, "control", attrValue(events[0].Attributes, attrVariant))
+		assert.Equal(t, string(enhanced.ReasonTargetingMatch), attrValue(events[0].Attributes, attrReason))
+		assert.Equal(t, string(enhanced.ReasonError), attrValue(events[1].Attributes, attrReason))
+		assert.Equal(t, int64(2), rec.count(t, "flagent.evaluations"))
+	})
+
+	t.Run("snapshot age", func(t *testing.T) {
+		gauge, ok := rec.collect(t, "flagent.snapshot.age").(metricdata.Gauge[float64])
+		require.True(t, ok)
+		require.Len(t, gauge.DataPoints, 1)
+		assert.GreaterOrEqual(t, gauge.DataPoints[0].Value, 0.0)
+		assert.Less(t, gauge.DataPoints[0].Value, 60.0)
+	})
+
+	t.Run("refresh failures", func(t *testing.T) {
+		fail = true
+		require.Error(t, manager.Refresh(ctx))
+		assert.Equal(t, int64(1), rec.count(t, "flagent.snapshot.refresh_failures"))
+	})
+}
+
+func TestTelemetry_SSEReconnects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	rec := newTelemetryRecorder(t)
+	config := enhanced.DefaultSSEConfig()
+	config.ReconnectDelay = time.Millisecond
+	config.MaxReconnectAttempts = 3
+	config.Telemetry = rec.telemetry
+	sse := enhanced.NewSSEClient(server.URL, nil, config)
+	sse.Connect(nil, nil)
+	defer sse.Disconnect()
+
+	assert.Eventually(t, func() bool {
+		return rec.count(t, "flagent.sse.reconnects") == 3
+	}, 2*time.Second, 10*time.Millisecond)
+}
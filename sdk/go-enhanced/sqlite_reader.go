@@ -0,0 +1,382 @@
+package flagentenhanced
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// sqliteReader is a minimal read-only reader for the SQLite 3 file format.
+// It understands exactly what the /export/sqlite artifact needs: table b-trees,
+// records and overflow pages. Indexes, WAL files and UTF-16 databases are not supported.
+// See https://www.sqlite.org/fileformat2.html for the format.
+type sqliteReader struct {
+	data     []byte
+	pageSize int
+	usable   int
+}
+
+// sqliteRow is one table row keyed by column name. Values are nil, int64,
+// float64, string or []byte.
+type sqliteRow map[string]interface{}
+
+const sqliteHeader = "SQLite format 3\x00"
+
+var errNotSQLite = errors.New("not a SQLite 3 database")
+
+// openSQLite validates the database header of data
+func openSQLite(data []byte) (*sqliteReader, error) {
+	if len(data) < 100 || !bytes.HasPrefix(data, []byte(sqliteHeader)) {
+		return nil, errNotSQLite
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size %d", pageSize)
+	}
+	if data[18] == 2 || data[19] == 2 {
+		return nil, errors.New("SQLite databases in WAL mode are not supported, checkpoint the database first")
+	}
+	if encoding := binary.BigEndian.Uint32(data[56:60]); encoding > 1 {
+		return nil, errors.New("only UTF-8 SQLite databases are supported")
+	}
+	usable := pageSize - int(data[20])
+	if usable < 480 {
+		return nil, fmt.Errorf("invalid SQLite reserved space %d", data[20])
+	}
+	return &sqliteReader{
+		data:     data,
+		pageSize: pageSize,
+		usable:   usable,
+	}, nil
+}
+
+// page returns the content of 1-based page number n
+func (r *sqliteReader) page(n uint32) ([]byte, error) {
+	start := int64(n-1) * int64(r.pageSize)
+	if n == 0 || start+int64(r.pageSize) > int64(len(r.data)) {
+		return nil, fmt.Errorf("SQLite page %d out of range", n)
+	}
+	return r.data[start : start+int64(r.pageSize)], nil
+}
+
+// table returns all rows of the named table, or nil if it does not exist
+func (r *sqliteReader) table(name string) ([]sqliteRow, error) {
+	var rootPage int64
+	var createSQL string
+	err := r.walk(1, 0, func(rowid int64, values []interface{}) error {
+		if len(values) < 5 || values[0] != "table" {
+			return nil
+		}
+		if tableName, _ := values[1].(string); strings.EqualFold(tableName, name) {
+			rootPage, _ = values[3].(int64)
+			createSQL, _ = values[4].(string)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rootPage == 0 {
+		return nil, nil
+	}
+
+	columns, rowidColumn := parseColumns(createSQL)
+	var rows []sqliteRow
+	err = r.walk(uint32(rootPage), 0, func(rowid int64, values []interface{}) error {
+		row := make(sqliteRow, len(columns))
+		for i, column := range columns {
+			if i < len(values) {
+				row[column] = values[i]
+			} else {
+				row[column] = nil // column added after the row was written
+			}
+		}
+		if rowidColumn != "" {
+			row[rowidColumn] = rowid
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table %s: %w", name, err)
+	}
+	return rows, nil
+}
+
+// walk visits every row of the table b-tree rooted at page n in rowid order
+func (r *sqliteReader) walk(n uint32, depth int, visit func(rowid int64, values []interface{}) error) error {
+	if depth > 64 {
+		return errors.New("SQLite b-tree too deep")
+	}
+	page, err := r.page(n)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if n == 1 {
+		offset = 100 // page 1 starts with the database header
+	}
+
+	kind := page[offset]
+	cellCount := int(binary.BigEndian.Uint16(page[offset+3:]))
+	headerSize := 8
+	if kind == 0x05 {
+		headerSize = 12
+	} else if kind != 0x0D {
+		return fmt.Errorf("unexpected SQLite page type 0x%02x on page %d", kind, n)
+	}
+
+	pointers := page[offset+headerSize:]
+	if len(pointers) < cellCount*2 {
+		return fmt.Errorf("corrupt SQLite page %d", n)
+	}
+	for i := 0; i < cellCount; i++ {
+		cell := int(binary.BigEndian.Uint16(pointers[i*2:]))
+		if cell >= len(page) {
+			return fmt.Errorf("corrupt SQLite page %d", n)
+		}
+		if kind == 0x05 {
+			if cell+4 > len(page) {
+				return fmt.Errorf("corrupt SQLite page %d", n)
+			}
+			if err := r.walk(binary.BigEndian.Uint32(page[cell:]), depth+1, visit); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Sizes come from the file, so they are checked as uint64 before
+		// conversion: a payload can never be larger than the database itself
+		payloadSize, k := readVarint(page[cell:])
+		rowid, l := readVarint(page[cell+k:])
+		if k == 0 || l == 0 || payloadSize > uint64(len(r.data)) {
+			return fmt.Errorf("corrupt SQLite cell on page %d", n)
+		}
+		payload, err := r.payload(page, cell+k+l, int(payloadSize))
+		if err != nil {
+			return err
+		}
+		values, err := decodeRecord(payload)
+		if err != nil {
+			return fmt.Errorf("corrupt SQLite record on page %d: %w", n, err)
+		}
+		if err := visit(int64(rowid), values); err != nil {
+			return err
+		}
+	}
+
+	if kind == 0x05 {
+		return r.walk(binary.BigEndian.Uint32(page[offset+8:]), depth+1, visit)
+	}
+	return nil
+}
+
+// payload assembles a leaf cell payload, following overflow pages when it spills
+func (r *sqliteReader) payload(page []byte, start, size int) ([]byte, error) {
+	maxLocal := r.usable - 35
+	local := size
+	if size > maxLocal {
+		minLocal := (r.usable-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(r.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if start+local > len(page) {
+		return nil, errors.New("corrupt SQLite cell")
+	}
+	if local == size {
+		return page[start : start+size], nil
+	}
+
+	out := make([]byte, 0, size)
+	out = append(out, page[start:start+local]...)
+	if start+local+4 > len(page) {
+		return nil, errors.New("corrupt SQLite cell")
+	}
+	next := binary.BigEndian.Uint32(page[start+local:])
+	for len(out) < size {
+		overflow, err := r.page(next)
+		if err != nil {
+			return nil, err
+		}
+		chunk := overflow[4:r.usable]
+		if remaining := size - len(out); len(chunk) > remaining {
+			chunk = chunk[:remaining]
+		}
+		out = append(out, chunk...)
+		next = binary.BigEndian.Uint32(overflow)
+	}
+	return out, nil
+}
+
+// decodeRecord decodes a record in SQLite record format
+func decodeRecord(payload []byte) ([]interface{}, error) {
+	size, n := readVarint(payload)
+	if n == 0 || size < uint64(n) || size > uint64(len(payload)) {
+		return nil, errors.New("invalid record header")
+	}
+	headerSize := int(size)
+	var types []uint64
+	for pos := n; pos < headerSize; {
+		serialType, k := readVarint(payload[pos:headerSize])
+		if k == 0 {
+			return nil, errors.New("invalid record header")
+		}
+		types = append(types, serialType)
+		pos += k
+	}
+
+	body := payload[headerSize:]
+	values := make([]interface{}, len(types))
+	for i, serialType := range types {
+		size := serialSize(serialType)
+		if size > uint64(len(body)) {
+			return nil, errors.New("record body too short")
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case serialType == 0:
+			values[i] = nil
+		case serialType <= 6:
+			values[i] = readInt(field)
+		case serialType == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(field))
+		case serialType == 8:
+			values[i] = int64(0)
+		case serialType == 9:
+			values[i] = int64(1)
+		case serialType >= 12 && serialType%2 == 0:
+			values[i] = append([]byte(nil), field...)
+		case serialType >= 13:
+			values[i] = string(field)
+		default:
+			return nil, fmt.Errorf("reserved serial type %d", serialType)
+		}
+	}
+	return values, nil
+}
+
+// serialSize returns the number of body bytes used by a serial type
+func serialSize(serialType uint64) uint64 {
+	switch serialType {
+	case 0, 8, 9, 10, 11:
+		return 0
+	case 1, 2, 3, 4:
+		return serialType
+	case 5:
+		return 6
+	case 6, 7:
+		return 8
+	}
+	return (serialType - 12) / 2
+}
+
+// readInt decodes a big-endian two's-complement integer of 1 to 8 bytes
+func readInt(b []byte) int64 {
+	var v int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+// readVarint decodes a SQLite varint and returns it with its length (0 if truncated)
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// parseColumns extracts column names from a CREATE TABLE statement, along with
+// the INTEGER PRIMARY KEY column that aliases the rowid (its value is not stored in the record)
+func parseColumns(createSQL string) ([]string, string) {
+	open := strings.Index(createSQL, "(")
+	end := strings.LastIndex(createSQL, ")")
+	if open < 0 || end <= open {
+		return nil, ""
+	}
+
+	var columns []string
+	rowidColumn := ""
+	for _, def := range splitTopLevel(createSQL[open+1 : end]) {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK":
+			continue
+		}
+		name := unquoteIdentifier(fields[0])
+		columns = append(columns, name)
+		upper := strings.ToUpper(strings.Join(fields[1:], " "))
+		if strings.HasPrefix(upper, "INTEGER") && strings.Contains(upper, "PRIMARY KEY") {
+			rowidColumn = name
+		}
+	}
+	return columns, rowidColumn
+}
+
+// splitTopLevel splits a column list on commas outside parentheses and quotes
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// unquoteIdentifier strips SQL identifier quoting
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 {
+		switch name[0] {
+		case '"', '`', '\'':
+			if name[len(name)-1] == name[0] {
+				return name[1 : len(name)-1]
+			}
+		case '[':
+			if name[len(name)-1] == ']' {
+				return name[1 : len(name)-1]
+			}
+		}
+	}
+	return name
+}
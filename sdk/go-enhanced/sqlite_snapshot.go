@@ -0,0 +1,179 @@
+package flagentenhanced
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ParseSQLiteSnapshot builds a snapshot from the bytes of a /export/sqlite artifact.
+// Soft-deleted rows are skipped. FetchedAt is set to now.
+func ParseSQLiteSnapshot(data []byte, ttlMs int64) (*FlagSnapshot, error) {
+	db, err := openSQLite(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string][]sqliteRow)
+	for _, name := range []string{"flags", "segments", "variants", "constraints", "distributions"} {
+		rows, err := db.table(name)
+		if err != nil {
+			return nil, err
+		}
+		if rows == nil && name == "flags" {
+			return nil, fmt.Errorf("SQLite export has no flags table")
+		}
+		tables[name] = rows
+	}
+
+	snapshot := &FlagSnapshot{
+		Flags:     make(map[int64]*LocalFlag),
+		FetchedAt: time.Now().UnixMilli(),
+		TTLMs:     ttlMs,
+	}
+
+	for _, row := range tables["flags"] {
+		if row.deleted() {
+			continue
+		}
+		flag := &LocalFlag{
+			ID:          row.int("id"),
+			Key:         row.string("key"),
+			Enabled:     row.int("enabled") != 0,
+			Description: row.string("description"),
+			EntityType:  row.string("entity_type"),
+			Segments:    make([]*LocalSegment, 0),
+			Variants:    make([]*LocalVariant, 0),
+		}
+		snapshot.Flags[flag.ID] = flag
+	}
+
+	for _, row := range tables["variants"] {
+		flag := snapshot.Flags[row.int("flag_id")]
+		if flag == nil || row.deleted() {
+			continue
+		}
+		attachment := make(map[string]interface{})
+		if raw := row.string("attachment"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &attachment); err != nil {
+				return nil, fmt.Errorf("invalid attachment for variant %d: %w", row.int("id"), err)
+			}
+		}
+		flag.Variants = append(flag.Variants, &LocalVariant{
+			ID:         row.int("id"),
+			FlagID:     flag.ID,
+			Key:        row.string("key"),
+			Attachment: attachment,
+		})
+	}
+
+	segments := make(map[int64]*LocalSegment)
+	for _, row := range tables["segments"] {
+		flag := snapshot.Flags[row.int("flag_id")]
+		if flag == nil || row.deleted() {
+			continue
+		}
+		segment := &LocalSegment{
+			ID:             row.int("id"),
+			FlagID:         flag.ID,
+			Rank:           int(row.int("rank")),
+			RolloutPercent: int(row.int("rollout_percent")),
+			Description:    row.string("description"),
+			Constraints:    make([]*LocalConstraint, 0),
+			Distributions:  make([]*LocalDistribution, 0),
+		}
+		segments[segment.ID] = segment
+		flag.Segments = append(flag.Segments, segment)
+	}
+
+	for _, row := range tables["constraints"] {
+		segment := segments[row.int("segment_id")]
+		if segment == nil || row.deleted() {
+			continue
+		}
+		segment.Constraints = append(segment.Constraints, &LocalConstraint{
+			ID:       row.int("id"),
+			Property: row.string("property"),
+			Operator: row.string("operator"),
+			Value:    row.string("value"),
+		})
+	}
+
+	for _, row := range tables["distributions"] {
+		segment := segments[row.int("segment_id")]
+		if segment == nil || row.deleted() {
+			continue
+		}
+		segment.Distributions = append(segment.Distributions, &LocalDistribution{
+			ID:         row.int("id"),
+			VariantID:  row.int("variant_id"),
+			VariantKey: row.string("variant_key"),
+			Percent:    int(row.int("percent")),
+		})
+	}
+
+	// Rows come back in rowid order; keep segments in evaluation order
+	for _, flag := range snapshot.Flags {
+		sort.SliceStable(flag.Segments, func(i, j int) bool {
+			return flag.Segments[i].Rank < flag.Segments[j].Rank
+		})
+	}
+
+	return snapshot, nil
+}
+
+// LoadSQLiteSnapshot reads a SQLite export from disk, e.g. one shipped into an
+// air-gapped deployment. FetchedAt is the file's modification time.
+func LoadSQLiteSnapshot(path string, ttlMs int64) (*FlagSnapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SQLite export: %w", err)
+	}
+	snapshot, err := ParseSQLiteSnapshot(data, ttlMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SQLite export %s: %w", path, err)
+	}
+	snapshot.FetchedAt = info.ModTime().UnixMilli()
+	return snapshot, nil
+}
+
+// deleted reports whether a row has been soft-deleted
+func (r sqliteRow) deleted() bool {
+	return r["deleted_at"] != nil
+}
+
+// int returns an integer column, converting numeric text and reals
+func (r sqliteRow) int(column string) int64 {
+	switch v := r[column].(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// string returns a text column, or "" for NULL
+func (r sqliteRow) string(column string) string {
+	switch v := r[column].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
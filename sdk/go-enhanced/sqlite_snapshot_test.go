@@ -0,0 +1,192 @@
+package flagentenhanced
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testdata/export.sqlite is generated by testdata/gen_export_sqlite.py
+func readExportFixture(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "export.sqlite"))
+	require.NoError(t, err)
+	return data
+}
+
+func TestParseSQLiteSnapshot(t *testing.T) {
+	snapshot, err := ParseSQLiteSnapshot(readExportFixture(t), 60000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(60000), snapshot.TTLMs)
+
+	t.Run("skips soft-deleted flags", func(t *testing.T) {
+		assert.Len(t, snapshot.Flags, 119)
+		assert.Nil(t, snapshot.GetFlagByKey("old_banner"))
+		assert.NotNil(t, snapshot.GetFlagByKey("filler_120"))
+	})
+
+	t.Run("builds flag tree", func(t *testing.T) {
+		flag := snapshot.GetFlagByKey("new_checkout")
+		require.NotNil(t, flag)
+		assert.Equal(t, int64(1), flag.ID)
+		assert.True(t, flag.Enabled)
+		assert.Equal(t, "user", flag.EntityType)
+		assert.Equal(t, "New checkout flow", flag.Description)
+
+		require.Len(t, flag.Segments, 2)
+		assert.Equal(t, "US users", flag.Segments[0].Description, "segments sorted by rank")
+		assert.Equal(t, 100, flag.Segments[0].RolloutPercent)
+		require.Len(t, flag.Segments[0].Constraints, 1)
+		assert.Equal(t, LocalConstraint{ID: 1, Property: "country", Operator: "EQ", Value: "US"}, *flag.Segments[0].Constraints[0])
+		require.Len(t, flag.Segments[0].Distributions, 1)
+		assert.Equal(t, LocalDistribution{ID: 2, VariantID: 2, VariantKey: "treatment", Percent: 100}, *flag.Segments[0].Distributions[0])
+
+		require.Len(t, flag.Variants, 2)
+		assert.Empty(t, flag.Variants[0].Attachment)
+		assert.Equal(t, "green", flag.Variants[1].Attachment["color"])
+		assert.Len(t, flag.Variants[1].Attachment["copy"], 3000, "attachment read from overflow pages")
+	})
+
+	t.Run("evaluates locally", func(t *testing.T) {
+		flagKey := "new_checkout"
+		result := NewLocalEvaluator().Evaluate(&OfflineEvaluationRequest{
+			FlagKey:       &flagKey,
+			EntityID:      "user-1",
+			EntityContext: map[string]interface{}{"country": "US"},
+		}, snapshot)
+		require.NotNil(t, result.VariantKey)
+		assert.Equal(t, "treatment", *result.VariantKey)
+	})
+
+	t.Run("rejects other files", func(t *testing.T) {
+		_, err := ParseSQLiteSnapshot([]byte(`{"flags":[]}`), 0)
+		assert.ErrorIs(t, err, errNotSQLite)
+	})
+}
+
+func TestSQLiteSnapshotStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "flagent.sqlite")
+	storage := NewSQLiteSnapshotStorage(path, 0)
+
+	loaded, err := storage.Load()
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	assert.Error(t, storage.SaveSQLite([]byte("not a database")))
+	require.NoError(t, storage.SaveSQLite(readExportFixture(t)))
+
+	loaded, err = storage.Load()
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.NotNil(t, loaded.GetFlagByKey("new_checkout"))
+	assert.False(t, loaded.IsExpired())
+
+	require.NoError(t, storage.Clear())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOfflineManager_SQLiteSource(t *testing.T) {
+	t.Run("downloads and persists the export", func(t *testing.T) {
+		fixture := readExportFixture(t)
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			assert.Equal(t, "/export/sqlite", r.URL.Path)
+			assert.Equal(t, "true", r.URL.Query().Get("exclude_snapshots"))
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(fixture)
+		}))
+		defer server.Close()
+
+		client, err := flagent.NewClient(server.URL)
+		require.NoError(t, err)
+		dir := t.TempDir()
+		cfg := DefaultOfflineConfig().WithStorageDir(dir).WithAutoRefresh(false).
+			WithSnapshotSource(SnapshotSourceSQLite)
+		m := NewOfflineManager(client, cfg)
+		defer m.Close()
+
+		require.NoError(t, m.Bootstrap(context.Background(), false))
+		variant, err := m.GetVariant(context.Background(), "new_checkout", "user-1", map[string]interface{}{"country": "US"})
+		require.NoError(t, err)
+		assert.Equal(t, "treatment", variant)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		stored, err := os.ReadFile(filepath.Join(dir, "flagent.sqlite"))
+		require.NoError(t, err)
+		assert.Equal(t, fixture, stored)
+	})
+
+	t.Run("air-gapped file without a server", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "export.sqlite")
+		require.NoError(t, os.WriteFile(path, readExportFixture(t), 0644))
+
+		cfg := DefaultOfflineConfig().WithAutoRefresh(false).WithSnapshotTTL(0).WithSQLiteFile(path)
+		m := NewOfflineManager(nil, cfg)
+		defer m.Close()
+
+		require.NoError(t, m.Bootstrap(context.Background(), false))
+		enabled, err := m.IsEnabled(context.Background(), "new_checkout", "user-1", nil)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+
+		err = m.Refresh(context.Background())
+		require.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(), "no Flagent client"))
+	})
+}
+
+func TestParseSQLiteSnapshot_CorruptFile(t *testing.T) {
+	fixture := readExportFixture(t)
+	// page 1 is a leaf page; its first cell pointer follows the 100-byte database
+	// header and the 8-byte page header
+	cell := int(binary.BigEndian.Uint16(fixture[108:]))
+	_, k := readVarint(fixture[cell:])
+	_, l := readVarint(fixture[cell+k:])
+	maxVarint := bytes.Repeat([]byte{0xff}, 9)
+
+	corrupt := func(offset int, b []byte) []byte {
+		data := append([]byte(nil), fixture...)
+		copy(data[offset:], b)
+		return data
+	}
+	for name, data := range map[string][]byte{
+		"payload size":       corrupt(cell, maxVarint),
+		"record header size": corrupt(cell+k+l, maxVarint),
+		"truncated varint":   fixture[:cell+1],
+		"truncated page":     fixture[:1024],
+		"reserved space":     corrupt(20, []byte{0xff}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseSQLiteSnapshot(data, 0)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("serial type", func(t *testing.T) {
+		record := append([]byte{10}, maxVarint...)
+		_, err := decodeRecord(record)
+		assert.EqualError(t, err, "record body too short")
+	})
+}
+
+func FuzzParseSQLiteSnapshot(f *testing.F) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "export.sqlite"))
+	require.NoError(f, err)
+	f.Add(fixture)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ParseSQLiteSnapshot(data, 0)
+	})
+}
@@ -4,12 +4,15 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
 )
 
 // SSEEvent represents a Server-Sent Event
@@ -75,6 +78,9 @@ type SSEConfig struct {
 
 	// EnableDebugLogging enables debug logging
 	EnableDebugLogging bool
+
+	// Telemetry counts reconnection attempts (nil disables instrumentation)
+	Telemetry Telemetry
 }
 
 // DefaultSSEConfig returns the default SSE configuration
@@ -198,6 +204,10 @@ func (c *SSEClient) connectionLoop(flagKeys []string, flagIDs []int64) {
 			}
 			c.sendError(err)
 			c.sendStatus(Error)
+			if errors.Is(err, flagent.ErrUnsupported) {
+				// Reconnecting cannot help, the server has no SSE endpoint
+				return
+			}
 		} else {
 			c.sendStatus(Disconnected)
 		}
@@ -215,6 +225,9 @@ func (c *SSEClient) connectionLoop(flagKeys []string, flagIDs []int64) {
 		}
 
 		c.reconnectAttempt++
+		if c.config.Telemetry != nil {
+			c.config.Telemetry.RecordSSEReconnect(c.ctx)
+		}
 		delay := c.calculateBackoff()
 
 		if c.config.EnableDebugLogging {
@@ -261,6 +274,9 @@ func (c *SSEClient) connectAndListen(flagKeys []string, flagIDs []int64) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return flagent.NewUnsupportedError("server does not support real-time updates", nil)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 // SnapshotStorage is an interface for storing and loading snapshots
@@ -147,3 +148,88 @@ func (s *FileSnapshotStorage) Clear() error {
 
 	return nil
 }
+
+// SQLiteSnapshotStorage keeps the SQLite export file itself on disk and rebuilds
+// snapshots from it. The file can also be provisioned out of band, e.g. shipped
+// into an air-gapped deployment, in which case no server is needed.
+type SQLiteSnapshotStorage struct {
+	mu    sync.RWMutex
+	path  string
+	ttlMs int64
+}
+
+// NewSQLiteSnapshotStorage creates a storage for the SQLite export at path.
+// Loaded snapshots expire ttl after the file was last written (0 never expires).
+func NewSQLiteSnapshotStorage(path string, ttl time.Duration) *SQLiteSnapshotStorage {
+	return &SQLiteSnapshotStorage{
+		path:  path,
+		ttlMs: ttl.Milliseconds(),
+	}
+}
+
+// Path returns the location of the SQLite file
+func (s *SQLiteSnapshotStorage) Path() string {
+	return s.path
+}
+
+// SaveSQLite replaces the stored file with a freshly downloaded export
+func (s *SQLiteSnapshotStorage) SaveSQLite(data []byte) error {
+	if _, err := openSQLite(data); err != nil {
+		return fmt.Errorf("refusing to store invalid SQLite export: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a partial database
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write SQLite export: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write SQLite export: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write SQLite export: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write SQLite export: %w", err)
+	}
+
+	return nil
+}
+
+// Save is a no-op: snapshots are derived from the file written by SaveSQLite
+func (s *SQLiteSnapshotStorage) Save(snapshot *FlagSnapshot) error {
+	return nil
+}
+
+// Load builds a snapshot from the stored SQLite file
+func (s *SQLiteSnapshotStorage) Load() (*FlagSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, err := LoadSQLiteSnapshot(s.path, s.ttlMs)
+	if os.IsNotExist(err) {
+		return nil, nil // No stored export
+	}
+	return snapshot, err
+}
+
+// Clear removes the SQLite file
+func (s *SQLiteSnapshotStorage) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove SQLite export: %w", err)
+	}
+
+	return nil
+}
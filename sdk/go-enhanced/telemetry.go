@@ -0,0 +1,125 @@
+package flagentenhanced
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	flagent "github.com/MaxLuxs/Flagent/sdk/go"
+)
+
+// EvaluationReason explains an evaluation result, as defined by the OpenTelemetry
+// feature flag semantic conventions
+type EvaluationReason string
+
+const (
+	ReasonTargetingMatch EvaluationReason = "targeting_match"
+	ReasonDefault        EvaluationReason = "default"
+	ReasonDisabled       EvaluationReason = "disabled"
+	ReasonCached         EvaluationReason = "cached"
+	ReasonError          EvaluationReason = "error"
+)
+
+// Telemetry receives instrumentation events from Manager, OfflineManager,
+// LocalEvaluator and SSEClient. A nil Telemetry disables instrumentation.
+//
+// The OpenTelemetry implementation lives in its own module, so only programs that
+// use it depend on OpenTelemetry:
+//
+//	import flagentotel "github.com/MaxLuxs/Flagent/sdk/go-enhanced/otel"
+//
+//	telemetry, err := flagentotel.NewTelemetry(tracerProvider, meterProvider)
+type Telemetry interface {
+	// StartEvaluation is called before a server-side evaluation. The request uses the
+	// returned context, and end is called with its outcome.
+	StartEvaluation(ctx context.Context, flagKey string) (_ context.Context, end func(variant string, err error))
+
+	// StartBatchEvaluation is called before a server-side batch evaluation of size
+	// flag and entity pairs
+	StartBatchEvaluation(ctx context.Context, size int) (_ context.Context, end func(results []*flagent.EvaluationResult, err error))
+
+	// RecordEvaluation records an evaluation that made no network call (local or cached)
+	RecordEvaluation(ctx context.Context, flagKey, variant string, reason EvaluationReason)
+
+	// RecordCacheLookup records an evaluation cache hit or miss
+	RecordCacheLookup(ctx context.Context, flagKey string, hit bool)
+
+	// StartSnapshotFetch is called before a snapshot download
+	StartSnapshotFetch(ctx context.Context, source SnapshotSource) (_ context.Context, end func(snapshot *FlagSnapshot, err error))
+
+	// ObserveSnapshotAge reports the age returned by age, while age reports a snapshot,
+	// until unregister is called
+	ObserveSnapshotAge(source SnapshotSource, age func() (time.Duration, bool)) (unregister func(), err error)
+
+	// RecordSSEReconnect records an SSE reconnection attempt
+	RecordSSEReconnect(ctx context.Context)
+}
+
+// noopTelemetry is used when no Telemetry is configured
+type noopTelemetry struct{}
+
+func (noopTelemetry) StartEvaluation(ctx context.Context, _ string) (context.Context, func(string, error)) {
+	return ctx, func(string, error) {}
+}
+
+func (noopTelemetry) StartBatchEvaluation(ctx context.Context, _ int) (context.Context, func([]*flagent.EvaluationResult, error)) {
+	return ctx, func([]*flagent.EvaluationResult, error) {}
+}
+
+func (noopTelemetry) RecordEvaluation(context.Context, string, string, EvaluationReason) {}
+
+func (noopTelemetry) RecordCacheLookup(context.Context, string, bool) {}
+
+func (noopTelemetry) StartSnapshotFetch(ctx context.Context, _ SnapshotSource) (context.Context, func(*FlagSnapshot, error)) {
+	return ctx, func(*FlagSnapshot, error) {}
+}
+
+func (noopTelemetry) ObserveSnapshotAge(SnapshotSource, func() (time.Duration, bool)) (func(), error) {
+	return func() {}, nil
+}
+
+func (noopTelemetry) RecordSSEReconnect(context.Context) {}
+
+// telemetryOrNoop returns t, or a no-op Telemetry if t is nil
+func telemetryOrNoop(t Telemetry) Telemetry {
+	if t == nil {
+		return noopTelemetry{}
+	}
+	return t
+}
+
+// localReason maps a LocalEvaluator reason to an evaluation reason
+func localReason(result *LocalEvaluationResult) EvaluationReason {
+	switch result.Reason {
+	case "MATCH":
+		return ReasonTargetingMatch
+	case "FLAG_DISABLED":
+		return ReasonDisabled
+	case "FLAG_NOT_FOUND":
+		return ReasonError
+	default:
+		return ReasonDefault
+	}
+}
+
+// localFlagKey returns the key of the flag a local evaluation was asked for
+func localFlagKey(req *OfflineEvaluationRequest, result *LocalEvaluationResult) string {
+	switch {
+	case result.FlagKey != nil:
+		return *result.FlagKey
+	case req.FlagKey != nil:
+		return *req.FlagKey
+	case req.FlagID != nil:
+		return strconv.FormatInt(*req.FlagID, 10)
+	default:
+		return ""
+	}
+}
+
+// localVariantKey returns the variant of a local result, or ""
+func localVariantKey(result *LocalEvaluationResult) string {
+	if result.VariantKey == nil {
+		return ""
+	}
+	return *result.VariantKey
+}
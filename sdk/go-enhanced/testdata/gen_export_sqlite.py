@@ -0,0 +1,51 @@
+"""Generates export.sqlite, a small /export/sqlite artifact used by sqlite_snapshot_test.go.
+
+The schema mirrors what the backend's Exposed tables create. A 512-byte page size
+forces interior b-tree pages and overflow pages with only a handful of rows.
+
+    python3 testdata/gen_export_sqlite.py
+"""
+import json
+import os
+import sqlite3
+
+path = os.path.join(os.path.dirname(__file__), "export.sqlite")
+if os.path.exists(path):
+    os.remove(path)
+
+db = sqlite3.connect(path)
+db.execute("PRAGMA page_size = 512")
+db.executescript("""
+CREATE TABLE IF NOT EXISTS flags (id INTEGER PRIMARY KEY AUTOINCREMENT, "key" VARCHAR(64) NOT NULL, description TEXT NOT NULL, created_by VARCHAR(255) NULL, updated_by VARCHAR(255) NULL, enabled BOOLEAN DEFAULT 0 NOT NULL, snapshot_id INT DEFAULT 0 NOT NULL, notes TEXT NULL, data_records_enabled BOOLEAN DEFAULT 0 NOT NULL, entity_type VARCHAR(255) NULL, environment_id BIGINT NULL, project_id BIGINT NULL, created_at TEXT NULL, updated_at TEXT NULL, deleted_at TEXT NULL);
+CREATE UNIQUE INDEX idx_flag_key ON flags ("key");
+CREATE TABLE IF NOT EXISTS segments (id INTEGER PRIMARY KEY AUTOINCREMENT, flag_id INT NOT NULL, description TEXT NULL, "rank" INT DEFAULT 999 NOT NULL, rollout_percent INT DEFAULT 0 NOT NULL, created_at TEXT NULL, updated_at TEXT NULL, deleted_at TEXT NULL, CONSTRAINT fk_segments_flag_id__id FOREIGN KEY (flag_id) REFERENCES flags(id) ON DELETE CASCADE ON UPDATE RESTRICT);
+CREATE TABLE IF NOT EXISTS variants (id INTEGER PRIMARY KEY AUTOINCREMENT, flag_id INT NOT NULL, "key" VARCHAR(255) NULL, attachment TEXT NULL, created_at TEXT NULL, updated_at TEXT NULL, deleted_at TEXT NULL, CONSTRAINT fk_variants_flag_id__id FOREIGN KEY (flag_id) REFERENCES flags(id) ON DELETE CASCADE ON UPDATE RESTRICT);
+CREATE TABLE IF NOT EXISTS constraints (id INTEGER PRIMARY KEY AUTOINCREMENT, segment_id INT NOT NULL, property VARCHAR(255) NOT NULL, operator VARCHAR(50) NOT NULL, "value" TEXT NOT NULL, created_at TEXT NULL, updated_at TEXT NULL, deleted_at TEXT NULL, CONSTRAINT fk_constraints_segment_id__id FOREIGN KEY (segment_id) REFERENCES segments(id) ON DELETE CASCADE ON UPDATE RESTRICT);
+CREATE TABLE IF NOT EXISTS distributions (id INTEGER PRIMARY KEY AUTOINCREMENT, segment_id INT NOT NULL, variant_id INT NOT NULL, variant_key VARCHAR(255) NULL, percent INT DEFAULT 0 NOT NULL, bitmap TEXT NULL, created_at TEXT NULL, updated_at TEXT NULL, deleted_at TEXT NULL, CONSTRAINT fk_distributions_segment_id__id FOREIGN KEY (segment_id) REFERENCES segments(id) ON DELETE CASCADE ON UPDATE RESTRICT);
+""")
+
+now = "2026-01-01 00:00:00"
+
+# Flag 1: new_checkout, two ranked segments, control/treatment split, large attachment (overflow pages)
+db.execute("INSERT INTO flags (id, \"key\", description, enabled, entity_type, created_at) VALUES (1, 'new_checkout', 'New checkout flow', 1, 'user', ?)", (now,))
+db.execute("INSERT INTO variants (id, flag_id, \"key\", attachment) VALUES (1, 1, 'control', NULL)")
+db.execute("INSERT INTO variants (id, flag_id, \"key\", attachment) VALUES (2, 1, 'treatment', ?)",
+           (json.dumps({"color": "green", "copy": "x" * 3000}),))
+db.execute("INSERT INTO segments (id, flag_id, description, \"rank\", rollout_percent) VALUES (1, 1, 'everyone', 2, 100)")
+db.execute("INSERT INTO segments (id, flag_id, description, \"rank\", rollout_percent) VALUES (2, 1, 'US users', 1, 100)")
+db.execute("INSERT INTO segments (id, flag_id, description, \"rank\", rollout_percent, deleted_at) VALUES (3, 1, 'removed', 0, 100, ?)", (now,))
+db.execute("INSERT INTO constraints (id, segment_id, property, operator, \"value\") VALUES (1, 2, 'country', 'EQ', 'US')")
+db.execute("INSERT INTO distributions (id, segment_id, variant_id, variant_key, percent) VALUES (1, 1, 1, 'control', 100)")
+db.execute("INSERT INTO distributions (id, segment_id, variant_id, variant_key, percent) VALUES (2, 2, 2, 'treatment', 100)")
+
+# Flag 2: soft-deleted, must not appear in snapshots
+db.execute("INSERT INTO flags (id, \"key\", description, enabled, deleted_at) VALUES (2, 'old_banner', 'Removed banner', 1, ?)", (now,))
+
+# Flags 3..120: filler so the flags table spans several b-tree levels
+for i in range(3, 121):
+    db.execute("INSERT INTO flags (id, \"key\", description, enabled, notes) VALUES (?, ?, ?, 0, ?)",
+               (i, "filler_%03d" % i, "Filler flag %d" % i, "n" * 40))
+
+db.commit()
+db.execute("VACUUM")
+db.close()
@@ -0,0 +1,444 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const headerAdminKey = "X-Admin-Key"
+
+// Secret is a credential the server returns only once, such as a new API key. It
+// prints as "REDACTED" with every fmt verb, in JSON and in slog records, so it does
+// not leak into logs by accident; call Reveal to get the value.
+type Secret struct {
+	value string
+}
+
+// Reveal returns the secret value
+func (s Secret) Reveal() string {
+	return s.value
+}
+
+// IsZero reports whether the secret is empty
+func (s Secret) IsZero() bool {
+	return s.value == ""
+}
+
+// String implements fmt.Stringer without revealing the secret
+func (s Secret) String() string {
+	return redacted
+}
+
+// Format implements fmt.Formatter so that no verb, including %#v, reveals the secret
+func (s Secret) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, redacted)
+}
+
+// MarshalText implements encoding.TextMarshaler without revealing the secret
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// LogValue implements slog.LogValuer without revealing the secret
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// AdminClient manages tenants, their API keys and users through the /admin API.
+// Tenant endpoints are authenticated with the admin API key (FLAGENT_ADMIN_API_KEY,
+// sent as X-Admin-Key); user endpoints need an admin session, e.g. WithLogin.
+type AdminClient struct {
+	client *Client
+}
+
+// NewAdminClient creates an admin client. baseURL is the server URL; a trailing
+// "/api/v1" is removed because the admin API is served next to it. opts are the
+// usual client options.
+func NewAdminClient(baseURL, adminAPIKey string, opts ...ClientOption) (*AdminClient, error) {
+	if baseURL == "" {
+		return nil, NewInvalidConfigError("baseURL is required", nil)
+	}
+	serverURL := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v1")
+	withKey := func(c *Client) {
+		if adminAPIKey != "" {
+			c.apiClient.GetConfig().DefaultHeader[headerAdminKey] = adminAPIKey
+		}
+	}
+	client, err := NewClient(serverURL, append([]ClientOption{withKey}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminClient{client: client}, nil
+}
+
+// Tenant is a tenant of a multi-tenant (enterprise) server
+type Tenant struct {
+	ID         int64
+	Key        string
+	Name       string
+	Plan       string
+	Status     string
+	SchemaName string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	// DeletedAt is set for tenants scheduled for deletion
+	DeletedAt *time.Time
+}
+
+// TenantInput is the input for creating a tenant
+type TenantInput struct {
+	// Key is the tenant's unique slug
+	Key  string
+	Name string
+	// Plan is STARTER, GROWTH, SCALE or ENTERPRISE
+	Plan string
+	// OwnerEmail is the email of the tenant's first user, who becomes its owner
+	OwnerEmail string
+}
+
+// TenantUser is a user of a tenant
+type TenantUser struct {
+	ID        int64
+	TenantID  int64
+	Email     string
+	Role      string
+	CreatedAt time.Time
+}
+
+// CreatedTenant is a new tenant with its owner and first API key
+type CreatedTenant struct {
+	Tenant Tenant
+	Owner  TenantUser
+	// APIKey is shown only once; store it securely
+	APIKey Secret
+}
+
+// APIKeyInput is the input for creating a tenant API key
+type APIKeyInput struct {
+	Name   string
+	Scopes []string
+	// EnvironmentID restricts the key to one environment
+	EnvironmentID int64
+	// ExpiresAt is optional
+	ExpiresAt time.Time
+}
+
+// APIKeyInfo describes an API key without its value
+type APIKeyInfo struct {
+	ID            int64
+	TenantID      int64
+	EnvironmentID int64
+	Name          string
+	Scopes        []string
+	ExpiresAt     *time.Time
+	CreatedAt     time.Time
+	LastUsedAt    *time.Time
+}
+
+// CreatedAPIKey is a new API key
+type CreatedAPIKey struct {
+	// Key is shown only once; store it securely
+	Key  Secret
+	Info APIKeyInfo
+}
+
+// AdminUser is a user of the admin UI and API
+type AdminUser struct {
+	ID        int64
+	Email     string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// BlockedAt is set for blocked users, who cannot log in
+	BlockedAt *time.Time
+}
+
+// Blocked reports whether the user is blocked
+func (u *AdminUser) Blocked() bool {
+	return u.BlockedAt != nil
+}
+
+// ListUsersOptions represents options for listing admin users
+type ListUsersOptions struct {
+	// Limit defaults to 50
+	Limit  int
+	Offset int
+}
+
+// Wire formats; timestamps are ISO-8601 strings
+
+type tenantJSON struct {
+	ID         int64   `json:"id"`
+	Key        string  `json:"key"`
+	Name       string  `json:"name"`
+	Plan       string  `json:"plan"`
+	Status     string  `json:"status"`
+	SchemaName string  `json:"schemaName"`
+	CreatedAt  string  `json:"createdAt"`
+	UpdatedAt  string  `json:"updatedAt"`
+	DeletedAt  *string `json:"deletedAt"`
+}
+
+func (t tenantJSON) tenant() Tenant {
+	return Tenant{
+		ID:         t.ID,
+		Key:        t.Key,
+		Name:       t.Name,
+		Plan:       t.Plan,
+		Status:     t.Status,
+		SchemaName: t.SchemaName,
+		CreatedAt:  parseServerTime(t.CreatedAt),
+		UpdatedAt:  parseServerTime(t.UpdatedAt),
+		DeletedAt:  parseOptionalServerTime(t.DeletedAt),
+	}
+}
+
+type createTenantResponseJSON struct {
+	Tenant    tenantJSON `json:"tenant"`
+	OwnerUser struct {
+		ID        int64  `json:"id"`
+		TenantID  int64  `json:"tenantId"`
+		Email     string `json:"email"`
+		Role      string `json:"role"`
+		CreatedAt string `json:"createdAt"`
+	} `json:"ownerUser"`
+	APIKey string `json:"apiKey"`
+}
+
+type apiKeyRequestJSON struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	EnvironmentID *int64   `json:"environmentId,omitempty"`
+	ExpiresAt     string   `json:"expiresAt,omitempty"`
+}
+
+type createAPIKeyResponseJSON struct {
+	APIKey     string `json:"apiKey"`
+	APIKeyInfo struct {
+		ID            int64    `json:"id"`
+		TenantID      int64    `json:"tenantId"`
+		EnvironmentID *int64   `json:"environmentId"`
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresAt     *string  `json:"expiresAt"`
+		CreatedAt     string   `json:"createdAt"`
+		LastUsedAt    *string  `json:"lastUsedAt"`
+	} `json:"apiKeyInfo"`
+}
+
+type adminUserJSON struct {
+	ID        int64   `json:"id"`
+	Email     *string `json:"email"`
+	Name      *string `json:"name"`
+	CreatedAt *string `json:"createdAt"`
+	UpdatedAt *string `json:"updatedAt"`
+	BlockedAt *string `json:"blockedAt"`
+}
+
+func (u adminUserJSON) user() AdminUser {
+	user := AdminUser{ID: u.ID, BlockedAt: parseOptionalServerTime(u.BlockedAt)}
+	if u.Email != nil {
+		user.Email = *u.Email
+	}
+	if u.Name != nil {
+		user.Name = *u.Name
+	}
+	if u.CreatedAt != nil {
+		user.CreatedAt = parseServerTime(*u.CreatedAt)
+	}
+	if u.UpdatedAt != nil {
+		user.UpdatedAt = parseServerTime(*u.UpdatedAt)
+	}
+	return user
+}
+
+// parseServerTime parses an ISO-8601 timestamp with or without zone (UTC is
+// assumed); unparseable values give the zero time
+func parseServerTime(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+	t, _ := time.ParseInLocation("2006-01-02T15:04:05.999999999", s, time.UTC)
+	return t
+}
+
+func parseOptionalServerTime(s *string) *time.Time {
+	if s == nil || *s == "" {
+		return nil
+	}
+	t := parseServerTime(*s)
+	return &t
+}
+
+// ListTenants returns all tenants; includeDeleted adds tenants scheduled for deletion
+func (a *AdminClient) ListTenants(ctx context.Context, includeDeleted bool) ([]Tenant, error) {
+	var query url.Values
+	if includeDeleted {
+		query = url.Values{"includeDeleted": {"true"}}
+	}
+	var out []tenantJSON
+	resp, err := a.client.do(ctx, http.MethodGet, "/admin/tenants", query, nil, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, "failed to list tenants", false)
+	}
+	tenants := make([]Tenant, len(out))
+	for i, t := range out {
+		tenants[i] = t.tenant()
+	}
+	return tenants, nil
+}
+
+// GetTenant returns a tenant by key
+func (a *AdminClient) GetTenant(ctx context.Context, key string) (*Tenant, error) {
+	var out tenantJSON
+	resp, err := a.client.do(ctx, http.MethodGet, "/admin/tenants/"+url.PathEscape(key), nil, nil, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to get tenant %s", key), false)
+	}
+	tenant := out.tenant()
+	return &tenant, nil
+}
+
+// CreateTenant creates a tenant with its owner and returns the tenant's first API key.
+// The request is not retried, so a timeout cannot create the tenant twice.
+func (a *AdminClient) CreateTenant(ctx context.Context, input *TenantInput) (*CreatedTenant, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	if input.Key == "" || input.Name == "" || input.Plan == "" || input.OwnerEmail == "" {
+		return nil, NewInvalidConfigError("tenant key, name, plan and owner email are required", nil)
+	}
+	body := map[string]string{
+		"key":        input.Key,
+		"name":       input.Name,
+		"plan":       input.Plan,
+		"ownerEmail": input.OwnerEmail,
+	}
+	var out createTenantResponseJSON
+	resp, err := a.client.do(ctx, http.MethodPost, "/admin/tenants", nil, body, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to create tenant %s", input.Key), false)
+	}
+	return &CreatedTenant{
+		Tenant: out.Tenant.tenant(),
+		Owner: TenantUser{
+			ID:        out.OwnerUser.ID,
+			TenantID:  out.OwnerUser.TenantID,
+			Email:     out.OwnerUser.Email,
+			Role:      out.OwnerUser.Role,
+			CreatedAt: parseServerTime(out.OwnerUser.CreatedAt),
+		},
+		APIKey: Secret{value: out.APIKey},
+	}, nil
+}
+
+// DeleteTenant schedules a tenant for deletion, or deletes it right away if immediate
+func (a *AdminClient) DeleteTenant(ctx context.Context, tenantID int64, immediate bool) error {
+	var query url.Values
+	if immediate {
+		query = url.Values{"immediate": {"true"}}
+	}
+	resp, err := a.client.do(ctx, http.MethodDelete, fmt.Sprintf("/admin/tenants/%d", tenantID), query, nil, nil)
+	return convertRequestError(resp, err, fmt.Sprintf("failed to delete tenant %d", tenantID), false)
+}
+
+// CreateTenantAPIKey issues a new API key for a tenant, e.g. when its key was lost.
+// Existing keys stay valid.
+func (a *AdminClient) CreateTenantAPIKey(ctx context.Context, tenantID int64, input *APIKeyInput) (*CreatedAPIKey, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	if input.Name == "" {
+		return nil, NewInvalidConfigError("API key name is required", nil)
+	}
+	body := apiKeyRequestJSON{Name: input.Name, Scopes: input.Scopes}
+	if body.Scopes == nil {
+		body.Scopes = []string{}
+	}
+	if input.EnvironmentID != 0 {
+		body.EnvironmentID = &input.EnvironmentID
+	}
+	if !input.ExpiresAt.IsZero() {
+		body.ExpiresAt = input.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	var out createAPIKeyResponseJSON
+	resp, err := a.client.do(ctx, http.MethodPost, fmt.Sprintf("/admin/tenants/%d/api-keys", tenantID), nil, body, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to create API key for tenant %d", tenantID), false)
+	}
+	info := out.APIKeyInfo
+	key := &CreatedAPIKey{
+		Key: Secret{value: out.APIKey},
+		Info: APIKeyInfo{
+			ID:         info.ID,
+			TenantID:   info.TenantID,
+			Name:       info.Name,
+			Scopes:     info.Scopes,
+			ExpiresAt:  parseOptionalServerTime(info.ExpiresAt),
+			CreatedAt:  parseServerTime(info.CreatedAt),
+			LastUsedAt: parseOptionalServerTime(info.LastUsedAt),
+		},
+	}
+	if info.EnvironmentID != nil {
+		key.Info.EnvironmentID = *info.EnvironmentID
+	}
+	return key, nil
+}
+
+// ListUsers returns a page of admin users and the total number of users
+func (a *AdminClient) ListUsers(ctx context.Context, opts *ListUsersOptions) ([]AdminUser, int, error) {
+	query := url.Values{}
+	if opts != nil {
+		if opts.Limit > 0 {
+			query.Set("limit", strconv.Itoa(opts.Limit))
+		}
+		if opts.Offset > 0 {
+			query.Set("offset", strconv.Itoa(opts.Offset))
+		}
+	}
+	var out []adminUserJSON
+	resp, err := a.client.do(ctx, http.MethodGet, "/admin/users", query, nil, &out)
+	if err != nil {
+		return nil, 0, convertRequestError(resp, err, "failed to list users", false)
+	}
+	users := make([]AdminUser, len(out))
+	for i, u := range out {
+		users[i] = u.user()
+	}
+	total, err := strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	if err != nil {
+		total = len(users)
+	}
+	return users, total, nil
+}
+
+// GetUser returns an admin user by ID
+func (a *AdminClient) GetUser(ctx context.Context, userID int64) (*AdminUser, error) {
+	return a.userCall(ctx, http.MethodGet, fmt.Sprintf("/admin/users/%d", userID), fmt.Sprintf("failed to get user %d", userID))
+}
+
+// BlockUser blocks a user from logging in and returns the updated user
+func (a *AdminClient) BlockUser(ctx context.Context, userID int64) (*AdminUser, error) {
+	return a.userCall(ctx, http.MethodPost, fmt.Sprintf("/admin/users/%d/block", userID), fmt.Sprintf("failed to block user %d", userID))
+}
+
+// UnblockUser lets a blocked user log in again and returns the updated user
+func (a *AdminClient) UnblockUser(ctx context.Context, userID int64) (*AdminUser, error) {
+	return a.userCall(ctx, http.MethodPost, fmt.Sprintf("/admin/users/%d/unblock", userID), fmt.Sprintf("failed to unblock user %d", userID))
+}
+
+func (a *AdminClient) userCall(ctx context.Context, method, path, msg string) (*AdminUser, error) {
+	var out adminUserJSON
+	resp, err := a.client.do(ctx, method, path, nil, nil, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, msg, false)
+	}
+	user := out.user()
+	return &user, nil
+}
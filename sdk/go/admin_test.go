@@ -0,0 +1,183 @@
+package flagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNewAPIKey = "sk_live_8f3a9c"
+
+func newAdminServer(t *testing.T, handler managementHandler) *AdminClient {
+	t.Helper()
+	url := startManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		assert.Equal(t, "admin-key", r.Header.Get("X-Admin-Key"))
+		handler(w, r, body)
+	})
+	admin, err := NewAdminClient(url+"/api/v1", "admin-key", WithMaxRetries(0))
+	require.NoError(t, err)
+	return admin
+}
+
+const tenantResponse = `{"id": 4, "key": "acme", "name": "Acme", "plan": "GROWTH", "status": "ACTIVE",
+	"schemaName": "tenant_acme", "createdAt": "2026-03-01T10:00:00Z", "updatedAt": "2026-03-01T10:00:00Z"}`
+
+func TestAdminTenants(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateTenant", func(t *testing.T) {
+		admin := newAdminServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/admin/tenants", r.URL.Path)
+			assert.Equal(t, map[string]interface{}{"key": "acme", "name": "Acme", "plan": "GROWTH", "ownerEmail": "ops@acme.com"}, body)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"tenant": %s, "ownerUser": {"id": 9, "tenantId": 4, "email": "ops@acme.com", "role": "OWNER",
+				"createdAt": "2026-03-01T10:00:00Z"}, "apiKey": %q}`, tenantResponse, testNewAPIKey)
+		})
+		created, err := admin.CreateTenant(ctx, &TenantInput{Key: "acme", Name: "Acme", Plan: "GROWTH", OwnerEmail: "ops@acme.com"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), created.Tenant.ID)
+		assert.True(t, created.Tenant.CreatedAt.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)))
+		assert.Nil(t, created.Tenant.DeletedAt)
+		assert.Equal(t, "OWNER", created.Owner.Role)
+		assert.Equal(t, testNewAPIKey, created.APIKey.Reveal())
+	})
+
+	t.Run("CreateTenantAPIKey", func(t *testing.T) {
+		expires := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+		admin := newAdminServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/admin/tenants/4/api-keys", r.URL.Path)
+			assert.Equal(t, "Recovery", body["name"])
+			assert.Equal(t, []interface{}{}, body["scopes"])
+			assert.Equal(t, "2027-01-01T00:00:00Z", body["expiresAt"])
+			assert.NotContains(t, body, "environmentId")
+			fmt.Fprintf(w, `{"apiKey": %q, "apiKeyInfo": {"id": 2, "tenantId": 4, "name": "Recovery", "keyHash": "ab12",
+				"scopes": [], "expiresAt": "2027-01-01T00:00:00Z", "createdAt": "2026-03-01T10:00:00Z"}}`, testNewAPIKey)
+		})
+		key, err := admin.CreateTenantAPIKey(ctx, 4, &APIKeyInput{Name: "Recovery", ExpiresAt: expires})
+		require.NoError(t, err)
+		assert.Equal(t, testNewAPIKey, key.Key.Reveal())
+		assert.Equal(t, int64(2), key.Info.ID)
+		require.NotNil(t, key.Info.ExpiresAt)
+		assert.True(t, key.Info.ExpiresAt.Equal(expires))
+		assert.Nil(t, key.Info.LastUsedAt)
+	})
+
+	t.Run("ListTenants and GetTenant", func(t *testing.T) {
+		admin := newAdminServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			switch r.URL.Path {
+			case "/admin/tenants":
+				assert.Equal(t, "true", r.URL.Query().Get("includeDeleted"))
+				w.Write([]byte(`[` + tenantResponse + `]`))
+			case "/admin/tenants/acme":
+				w.Write([]byte(tenantResponse))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error": "Tenant not found"}`))
+			}
+		})
+		tenants, err := admin.ListTenants(ctx, true)
+		require.NoError(t, err)
+		require.Len(t, tenants, 1)
+		assert.Equal(t, "tenant_acme", tenants[0].SchemaName)
+
+		tenant, err := admin.GetTenant(ctx, "acme")
+		require.NoError(t, err)
+		assert.Equal(t, "Acme", tenant.Name)
+
+		_, err = admin.GetTenant(ctx, "missing")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("DeleteTenant", func(t *testing.T) {
+		admin := newAdminServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodDelete, r.Method)
+			assert.Equal(t, "/admin/tenants/4", r.URL.Path)
+			assert.Equal(t, "true", r.URL.Query().Get("immediate"))
+			w.WriteHeader(http.StatusNoContent)
+		})
+		require.NoError(t, admin.DeleteTenant(ctx, 4, true))
+	})
+
+	t.Run("validates input", func(t *testing.T) {
+		admin := newAdminServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			t.Fatal("invalid input must not reach the server")
+		})
+		var configErr *InvalidConfigError
+		_, err := admin.CreateTenant(ctx, &TenantInput{Key: "acme"})
+		assert.True(t, errors.As(err, &configErr))
+		_, err = admin.CreateTenantAPIKey(ctx, 4, &APIKeyInput{})
+		assert.True(t, errors.As(err, &configErr))
+	})
+}
+
+func TestSecretIsNeverPrinted(t *testing.T) {
+	created := &CreatedAPIKey{Key: Secret{value: testNewAPIKey}, Info: APIKeyInfo{Name: "Recovery"}}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d"} {
+		assert.NotContains(t, fmt.Sprintf(format, created), testNewAPIKey, format)
+		assert.NotContains(t, fmt.Sprintf(format, created.Key), testNewAPIKey, format)
+	}
+	assert.Equal(t, "REDACTED", created.Key.String())
+
+	data, err := json.Marshal(created)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), testNewAPIKey)
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("key created", "key", created.Key, "created", created)
+	assert.NotContains(t, buf.String(), testNewAPIKey)
+	assert.Contains(t, buf.String(), `"key":"REDACTED"`)
+}
+
+func TestAdminUsers(t *testing.T) {
+	ctx := context.Background()
+	admin := newAdminServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		switch r.URL.Path {
+		case "/admin/users":
+			assert.Equal(t, "10", r.URL.Query().Get("limit"))
+			assert.Equal(t, "20", r.URL.Query().Get("offset"))
+			w.Header().Set("X-Total-Count", "21")
+			w.Write([]byte(`[{"id": 3, "email": "dev@acme.com", "name": null, "createdAt": "2026-03-01T10:00:00.123",
+				"updatedAt": null, "blockedAt": null}]`))
+		case "/admin/users/3/block":
+			assert.Equal(t, http.MethodPost, r.Method)
+			w.Write([]byte(`{"id": 3, "email": "dev@acme.com", "blockedAt": "2026-03-02T09:30:00"}`))
+		case "/admin/users/3/unblock":
+			assert.Equal(t, http.MethodPost, r.Method)
+			w.Write([]byte(`{"id": 3, "email": "dev@acme.com", "blockedAt": null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "User not found"}`))
+		}
+	})
+
+	users, total, err := admin.ListUsers(ctx, &ListUsersOptions{Limit: 10, Offset: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 21, total)
+	require.Len(t, users, 1)
+	assert.Equal(t, "dev@acme.com", users[0].Email)
+	assert.True(t, users[0].CreatedAt.Equal(time.Date(2026, 3, 1, 10, 0, 0, 123e6, time.UTC)))
+	assert.False(t, users[0].Blocked())
+
+	user, err := admin.BlockUser(ctx, 3)
+	require.NoError(t, err)
+	assert.True(t, user.Blocked())
+	assert.True(t, user.BlockedAt.Equal(time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)))
+
+	user, err = admin.UnblockUser(ctx, 3)
+	require.NoError(t, err)
+	assert.False(t, user.Blocked())
+
+	_, err = admin.BlockUser(ctx, 99)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
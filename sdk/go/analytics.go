@@ -0,0 +1,359 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAnalyticsTopLimit = 20
+	// MaxFunnelRange is the longest time range the server computes funnels for
+	MaxFunnelRange = 90 * 24 * time.Hour
+)
+
+// AnalyticsOverviewQuery selects the events of an analytics overview
+type AnalyticsOverviewQuery struct {
+	Window MetricsWindow
+	// TopLimit is the number of top events returned (default: 20)
+	TopLimit int
+	// Platform, AppVersion and EventName filter events when set
+	Platform   string
+	AppVersion string
+	EventName  string
+}
+
+// AnalyticsOverview summarizes tracked events
+type AnalyticsOverview struct {
+	TotalEvents int64
+	UniqueUsers int
+	// TopEvents are the most frequent events, highest first
+	TopEvents  []EventCount
+	TimeSeries []TimeSeriesPoint
+	// DailyActiveUsers has a point for every day with events, oldest first
+	DailyActiveUsers []TimeSeriesPoint
+}
+
+// EventCount is an event name and how often it was tracked
+type EventCount struct {
+	EventName string
+	Count     int64
+}
+
+// FunnelDimension is what a funnel follows through its steps
+type FunnelDimension string
+
+const (
+	FunnelByUser    FunnelDimension = "USER_ID"
+	FunnelBySession FunnelDimension = "SESSION_ID"
+)
+
+// FunnelStep is an event a funnel entity must reach, in order
+type FunnelStep struct {
+	EventName string
+	// ParamFilter only matches events whose params have these values
+	ParamFilter map[string]string
+}
+
+// FunnelQuery defines a funnel over tracked events. A user (or session) reaches a
+// step when it tracked the step's event after reaching the previous step.
+type FunnelQuery struct {
+	Steps []FunnelStep
+	// Start and End bound the events; the range must not exceed MaxFunnelRange
+	Start time.Time
+	End   time.Time
+	// Dimension defaults to FunnelByUser
+	Dimension FunnelDimension
+	// Platform, AppVersion, FlagID and VariantID filter events when set
+	Platform   string
+	AppVersion string
+	FlagID     int64
+	VariantID  int64
+}
+
+// FunnelResult is how many entities reached each step of a funnel
+type FunnelResult struct {
+	Steps []FunnelStepResult
+}
+
+// FunnelStepResult is the outcome of one funnel step
+type FunnelStepResult struct {
+	Index     int
+	EventName string
+	Reached   int
+	// ConversionFromPrevious is Reached divided by the previous step's Reached
+	// (0 to 1; 1 for the first step with entities)
+	ConversionFromPrevious float64
+}
+
+// Conversion returns the share of entities that reached the first step and also
+// reached the last one (0 to 1)
+func (f *FunnelResult) Conversion() float64 {
+	if len(f.Steps) == 0 || f.Steps[0].Reached == 0 {
+		return 0
+	}
+	return float64(f.Steps[len(f.Steps)-1].Reached) / float64(f.Steps[0].Reached)
+}
+
+// VariantFunnel is a funnel restricted to the events tagged with one variant
+type VariantFunnel struct {
+	VariantID  int64
+	VariantKey string
+	Funnel     *FunnelResult
+}
+
+// CrashOverview summarizes crash reports
+type CrashOverview struct {
+	TotalCrashes int64
+	TimeSeries   []TimeSeriesPoint
+	// ByPlatform and ByAppVersion count crashes per platform and app version
+	ByPlatform   []CrashCount
+	ByAppVersion []CrashCount
+}
+
+// CrashCount is a platform or app version and its number of crashes
+type CrashCount struct {
+	Key   string
+	Count int64
+}
+
+// Wire formats; timestamps are Unix milliseconds
+
+type analyticsOverviewJSON struct {
+	TotalEvents int64 `json:"totalEvents"`
+	UniqueUsers int   `json:"uniqueUsers"`
+	TopEvents   []struct {
+		EventName string `json:"eventName"`
+		Count     int64  `json:"count"`
+	} `json:"topEvents"`
+	TimeSeries timeSeriesJSON `json:"timeSeries"`
+	DauByDay   []struct {
+		Timestamp int64 `json:"timestamp"`
+		Dau       int64 `json:"dau"`
+	} `json:"dauByDay"`
+}
+
+type funnelStepJSON struct {
+	EventName        string            `json:"eventName"`
+	EventParamFilter map[string]string `json:"eventParamFilter,omitempty"`
+}
+
+type funnelRequestJSON struct {
+	Steps           []funnelStepJSON `json:"steps"`
+	StartMs         int64            `json:"startMs"`
+	EndMs           int64            `json:"endMs"`
+	EntityDimension FunnelDimension  `json:"entityDimension"`
+	Platform        string           `json:"platform,omitempty"`
+	AppVersion      string           `json:"appVersion,omitempty"`
+	FlagID          int64            `json:"flagId,omitempty"`
+	VariantID       int64            `json:"variantId,omitempty"`
+}
+
+type funnelResultJSON struct {
+	Steps []struct {
+		StepIndex              int     `json:"stepIndex"`
+		EventName              string  `json:"eventName"`
+		ReachedCount           int     `json:"reachedCount"`
+		ConversionFromPrevious float64 `json:"conversionFromPrevious"`
+	} `json:"steps"`
+}
+
+type crashCountsJSON []struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+func (cc crashCountsJSON) counts() []CrashCount {
+	counts := make([]CrashCount, len(cc))
+	for i, c := range cc {
+		counts[i] = CrashCount{Key: c.Key, Count: c.Count}
+	}
+	return counts
+}
+
+type crashOverviewJSON struct {
+	TotalCrashes int64           `json:"totalCrashes"`
+	TimeSeries   timeSeriesJSON  `json:"timeSeries"`
+	ByPlatform   crashCountsJSON `json:"byPlatform"`
+	ByAppVersion crashCountsJSON `json:"byAppVersion"`
+}
+
+// GetAnalyticsOverview returns totals, top events, a time series and daily active
+// users of the events tracked in the query's window
+func (c *Client) GetAnalyticsOverview(ctx context.Context, query *AnalyticsOverviewQuery) (*AnalyticsOverview, error) {
+	if err := c.requireFeature(FeatureAnalytics); err != nil {
+		return nil, err
+	}
+	if query == nil {
+		return nil, NewInvalidConfigError("query is required", nil)
+	}
+	params, err := query.Window.query(true)
+	if err != nil {
+		return nil, err
+	}
+	topLimit := query.TopLimit
+	if topLimit <= 0 {
+		topLimit = defaultAnalyticsTopLimit
+	}
+	params.Set("topLimit", strconv.Itoa(topLimit))
+	for name, value := range map[string]string{
+		"platform":   query.Platform,
+		"appVersion": query.AppVersion,
+		"eventName":  query.EventName,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+
+	var out analyticsOverviewJSON
+	resp, err := c.do(ctx, http.MethodGet, "/analytics/overview", params, nil, &out)
+	if err != nil {
+		return nil, c.featureError(FeatureAnalytics, resp, err, "failed to get analytics overview")
+	}
+	overview := &AnalyticsOverview{
+		TotalEvents:      out.TotalEvents,
+		UniqueUsers:      out.UniqueUsers,
+		TopEvents:        make([]EventCount, len(out.TopEvents)),
+		TimeSeries:       out.TimeSeries.points(),
+		DailyActiveUsers: make([]TimeSeriesPoint, len(out.DauByDay)),
+	}
+	for i, e := range out.TopEvents {
+		overview.TopEvents[i] = EventCount{EventName: e.EventName, Count: e.Count}
+	}
+	for i, d := range out.DauByDay {
+		overview.DailyActiveUsers[i] = TimeSeriesPoint{Time: time.UnixMilli(d.Timestamp), Count: d.Dau}
+	}
+	return overview, nil
+}
+
+// Validate checks the constraints the server enforces: at least one step, each with
+// an event name, and a range of at most MaxFunnelRange with Start before End
+func (q *FunnelQuery) Validate() error {
+	if len(q.Steps) == 0 {
+		return NewInvalidConfigError("funnel needs at least one step", nil)
+	}
+	for i, step := range q.Steps {
+		if step.EventName == "" {
+			return NewInvalidConfigError(fmt.Sprintf("funnel step %d: event name is required", i), nil)
+		}
+	}
+	if q.Start.IsZero() || q.End.IsZero() {
+		return NewInvalidConfigError("funnel start and end are required", nil)
+	}
+	if !q.Start.Before(q.End) {
+		return NewInvalidConfigError("funnel start must be before end", nil)
+	}
+	if q.End.Sub(q.Start) > MaxFunnelRange {
+		return NewInvalidConfigError("funnel range must not exceed 90 days", nil)
+	}
+	switch q.Dimension {
+	case "", FunnelByUser, FunnelBySession:
+	default:
+		return NewInvalidConfigError(fmt.Sprintf("unknown funnel dimension %q", q.Dimension), nil)
+	}
+	if q.VariantID != 0 && q.FlagID == 0 {
+		return NewInvalidConfigError("funnel variant filter requires a flag", nil)
+	}
+	return nil
+}
+
+// GetFunnel computes a funnel over tracked events
+func (c *Client) GetFunnel(ctx context.Context, query *FunnelQuery) (*FunnelResult, error) {
+	if err := c.requireFeature(FeatureAnalytics); err != nil {
+		return nil, err
+	}
+	if query == nil {
+		return nil, NewInvalidConfigError("query is required", nil)
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	body := funnelRequestJSON{
+		Steps:           make([]funnelStepJSON, len(query.Steps)),
+		StartMs:         query.Start.UnixMilli(),
+		EndMs:           query.End.UnixMilli(),
+		EntityDimension: query.Dimension,
+		Platform:        query.Platform,
+		AppVersion:      query.AppVersion,
+		FlagID:          query.FlagID,
+		VariantID:       query.VariantID,
+	}
+	if body.EntityDimension == "" {
+		body.EntityDimension = FunnelByUser
+	}
+	for i, step := range query.Steps {
+		body.Steps[i] = funnelStepJSON{EventName: step.EventName, EventParamFilter: step.ParamFilter}
+	}
+
+	var out funnelResultJSON
+	resp, err := c.do(ctx, http.MethodPost, "/analytics/funnel", nil, body, &out)
+	if err != nil {
+		return nil, c.featureError(FeatureAnalytics, resp, err, "failed to compute funnel")
+	}
+	result := &FunnelResult{Steps: make([]FunnelStepResult, len(out.Steps))}
+	for i, s := range out.Steps {
+		result.Steps[i] = FunnelStepResult{
+			Index:                  s.StepIndex,
+			EventName:              s.EventName,
+			Reached:                s.ReachedCount,
+			ConversionFromPrevious: s.ConversionFromPrevious,
+		}
+	}
+	return result, nil
+}
+
+// GetFunnelByVariant computes the funnel once for each variant of a flag, in the
+// flag's variant order. Events are attributed to variants by the flag and variant
+// they were tracked with (see Tracker). query.FlagID and query.VariantID are ignored.
+func (c *Client) GetFunnelByVariant(ctx context.Context, query *FunnelQuery, flagID int64) ([]VariantFunnel, error) {
+	if err := c.requireFeature(FeatureAnalytics); err != nil {
+		return nil, err
+	}
+	if query == nil {
+		return nil, NewInvalidConfigError("query is required", nil)
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	variants, err := c.ListVariants(ctx, flagID)
+	if err != nil {
+		return nil, err
+	}
+	funnels := make([]VariantFunnel, 0, len(variants))
+	for _, variant := range variants {
+		q := *query
+		q.FlagID = flagID
+		q.VariantID = variant.Id
+		funnel, err := c.GetFunnel(ctx, &q)
+		if err != nil {
+			return nil, err
+		}
+		funnels = append(funnels, VariantFunnel{VariantID: variant.Id, VariantKey: variant.Key, Funnel: funnel})
+	}
+	return funnels, nil
+}
+
+// GetCrashOverview returns crash counts over time and per platform and app version
+func (c *Client) GetCrashOverview(ctx context.Context, window MetricsWindow) (*CrashOverview, error) {
+	if err := c.requireFeature(FeatureCrashReports); err != nil {
+		return nil, err
+	}
+	params, err := window.query(true)
+	if err != nil {
+		return nil, err
+	}
+	var out crashOverviewJSON
+	resp, err := c.do(ctx, http.MethodGet, "/crashes/overview", params, nil, &out)
+	if err != nil {
+		return nil, c.featureError(FeatureCrashReports, resp, err, "failed to get crash overview")
+	}
+	return &CrashOverview{
+		TotalCrashes: out.TotalCrashes,
+		TimeSeries:   out.TimeSeries.points(),
+		ByPlatform:   out.ByPlatform.counts(),
+		ByAppVersion: out.ByAppVersion.counts(),
+	}, nil
+}
@@ -0,0 +1,146 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyticsOverview(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		assert.Equal(t, "/analytics/overview", r.URL.Path)
+		query := r.URL.Query()
+		assert.Equal(t, "20", query.Get("topLimit"))
+		assert.Equal(t, "ios", query.Get("platform"))
+		assert.NotContains(t, query, "eventName")
+		w.Write([]byte(`{"totalEvents": 30, "uniqueUsers": 4,
+			"topEvents": [{"eventName": "screen_view", "count": 25}, {"eventName": "purchase", "count": 5}],
+			"timeSeries": [{"timestamp": 1772323200000, "count": 30}],
+			"dauByDay": [{"timestamp": 1772323200000, "dau": 4}]}`))
+	})
+	overview, err := client.GetAnalyticsOverview(context.Background(), &AnalyticsOverviewQuery{
+		Window:   MetricsWindow{Start: start, End: start.Add(24 * time.Hour)},
+		Platform: "ios",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), overview.TotalEvents)
+	assert.Equal(t, 4, overview.UniqueUsers)
+	assert.Equal(t, EventCount{EventName: "screen_view", Count: 25}, overview.TopEvents[0])
+	require.Len(t, overview.DailyActiveUsers, 1)
+	assert.True(t, overview.DailyActiveUsers[0].Time.Equal(start))
+	assert.Equal(t, int64(4), overview.DailyActiveUsers[0].Count)
+}
+
+func TestFunnel(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	query := &FunnelQuery{
+		Steps: []FunnelStep{
+			{EventName: "view_cart"},
+			{EventName: "purchase", ParamFilter: map[string]string{"method": "card"}},
+		},
+		Start: start,
+		End:   start.Add(7 * 24 * time.Hour),
+	}
+
+	t.Run("GetFunnel", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/analytics/funnel", r.URL.Path)
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"eventName": "view_cart"},
+				map[string]interface{}{"eventName": "purchase", "eventParamFilter": map[string]interface{}{"method": "card"}},
+			}, body["steps"])
+			assert.Equal(t, 1772323200000.0, body["startMs"])
+			assert.Equal(t, "USER_ID", body["entityDimension"])
+			assert.NotContains(t, body, "flagId")
+			w.Write([]byte(`{"steps": [
+				{"stepIndex": 0, "eventName": "view_cart", "reachedCount": 40, "conversionFromPrevious": 1.0},
+				{"stepIndex": 1, "eventName": "purchase", "reachedCount": 10, "conversionFromPrevious": 0.25}]}`))
+		})
+		funnel, err := client.GetFunnel(ctx, query)
+		require.NoError(t, err)
+		require.Len(t, funnel.Steps, 2)
+		assert.Equal(t, FunnelStepResult{Index: 1, EventName: "purchase", Reached: 10, ConversionFromPrevious: 0.25}, funnel.Steps[1])
+		assert.Equal(t, 0.25, funnel.Conversion())
+	})
+
+	t.Run("GetFunnelByVariant", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			switch r.URL.Path {
+			case "/flags/7/variants":
+				w.Write([]byte(`[{"id": 11, "flagID": 7, "key": "control"}, {"id": 12, "flagID": 7, "key": "treatment"}]`))
+			case "/analytics/funnel":
+				assert.Equal(t, 7.0, body["flagId"])
+				reached := 10
+				if body["variantId"] == 12.0 {
+					reached = 15
+				}
+				fmt.Fprintf(w, `{"steps": [{"stepIndex": 0, "eventName": "view_cart", "reachedCount": 50, "conversionFromPrevious": 1.0},
+					{"stepIndex": 1, "eventName": "purchase", "reachedCount": %d, "conversionFromPrevious": 0.2}]}`, reached)
+			default:
+				t.Errorf("unexpected request %s", r.URL.Path)
+			}
+		})
+		funnels, err := client.GetFunnelByVariant(ctx, query, 7)
+		require.NoError(t, err)
+		require.Len(t, funnels, 2)
+		assert.Equal(t, "control", funnels[0].VariantKey)
+		assert.Equal(t, 0.2, funnels[0].Funnel.Conversion())
+		assert.Equal(t, int64(12), funnels[1].VariantID)
+		assert.Equal(t, 0.3, funnels[1].Funnel.Conversion())
+	})
+
+	t.Run("validates the query", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			t.Fatal("invalid query must not reach the server")
+		})
+		for name, q := range map[string]FunnelQuery{
+			"no steps":     {Start: start, End: start.Add(time.Hour)},
+			"no name":      {Steps: []FunnelStep{{}}, Start: start, End: start.Add(time.Hour)},
+			"no range":     {Steps: query.Steps},
+			"reversed":     {Steps: query.Steps, Start: start, End: start},
+			"over 90 days": {Steps: query.Steps, Start: start, End: start.Add(91 * 24 * time.Hour)},
+			"dimension":    {Steps: query.Steps, Start: start, End: start.Add(time.Hour), Dimension: "DEVICE_ID"},
+			"variant only": {Steps: query.Steps, Start: start, End: start.Add(time.Hour), VariantID: 3},
+		} {
+			_, err := client.GetFunnel(ctx, &q)
+			var configErr *InvalidConfigError
+			assert.True(t, errors.As(err, &configErr), name)
+		}
+		assert.NoError(t, (&FunnelQuery{Steps: query.Steps, Start: start, End: start.Add(MaxFunnelRange)}).Validate())
+	})
+
+	t.Run("server rejects the query", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "Time range must not exceed 90 days"}`))
+		})
+		_, err := client.GetFunnel(ctx, query)
+		assert.True(t, errors.Is(err, ErrBadRequest))
+	})
+}
+
+func TestCrashOverview(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		assert.Equal(t, "/crashes/overview", r.URL.Path)
+		assert.Equal(t, "86400000", r.URL.Query().Get("timeBucketMs"))
+		w.Write([]byte(`{"totalCrashes": 3, "timeSeries": [{"timestamp": 1772323200000, "count": 3}],
+			"byPlatform": [{"key": "go", "count": 2}, {"key": "ios", "count": 1}],
+			"byAppVersion": [{"key": "2.1.0", "count": 3}]}`))
+	})
+	overview, err := client.GetCrashOverview(context.Background(), MetricsWindow{Start: start, End: start.Add(7 * 24 * time.Hour), Bucket: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), overview.TotalCrashes)
+	assert.Equal(t, []CrashCount{{"go", 2}, {"ios", 1}}, overview.ByPlatform)
+	assert.Equal(t, []CrashCount{{"2.1.0", 3}}, overview.ByAppVersion)
+	assert.Len(t, overview.TimeSeries, 1)
+}
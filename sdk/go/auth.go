@@ -0,0 +1,174 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	headerAPIKey        = "X-API-Key"
+	headerAuthorization = "Authorization"
+)
+
+// Credentials are the auth headers attached to a request. Empty fields are not sent.
+type Credentials struct {
+	// APIKey is a tenant API key, sent as X-API-Key
+	APIKey string
+
+	// BearerToken is sent as Authorization: <TokenType> <token>
+	BearerToken string
+	// TokenType is the Authorization scheme of BearerToken (default: "Bearer")
+	TokenType string
+}
+
+// CredentialsProvider supplies credentials for every request (including retries),
+// so keys and tokens can rotate without rebuilding the client.
+// Implementations must be safe for concurrent use.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc adapts an ordinary function to CredentialsProvider
+type CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials calls f(ctx)
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// WithTenantAPIKey authenticates as a tenant by sending the key in the X-API-Key header
+func WithTenantAPIKey(apiKey string) ClientOption {
+	return func(c *Client) {
+		if apiKey != "" {
+			c.apiClient.GetConfig().DefaultHeader[headerAPIKey] = apiKey
+		}
+	}
+}
+
+// WithCredentialsProvider consults provider on every request. Its credentials take
+// precedence over WithAPIKey and WithTenantAPIKey.
+func WithCredentialsProvider(provider CredentialsProvider) ClientOption {
+	return func(c *Client) {
+		c.credentials = provider
+	}
+}
+
+type tenantAPIKeyContextKey struct{}
+
+// ContextWithTenantAPIKey returns a context whose requests are sent with the given tenant
+// API key, overriding the client's credentials. This lets one shared Client act on behalf
+// of many tenants.
+func ContextWithTenantAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, tenantAPIKeyContextKey{}, apiKey)
+}
+
+// TenantAPIKeyFromContext returns the tenant API key set by ContextWithTenantAPIKey
+func TenantAPIKeyFromContext(ctx context.Context) (string, bool) {
+	apiKey, ok := ctx.Value(tenantAPIKeyContextKey{}).(string)
+	return apiKey, ok && apiKey != ""
+}
+
+// credentialsRefresher is implemented by providers that can replace credentials
+// the server rejected with 401
+type credentialsRefresher interface {
+	invalidate(rejected Credentials)
+}
+
+// httpClientUser is implemented by providers that make HTTP calls of their own
+// (e.g. logging in); NewClient hands them the client's HTTP client with middleware
+// but without auth and retries
+type httpClientUser interface {
+	setHTTPClient(httpClient *http.Client)
+}
+
+// authTransport sets credentials on each outgoing request. It sits below the retry
+// layer, so every attempt sees the current credentials.
+type authTransport struct {
+	next     http.RoundTripper
+	provider CredentialsProvider
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	creds, err := t.credentials(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(withCredentials(req, creds))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The server rejected the credentials: renew them once and replay the request
+	refresher, ok := t.provider.(credentialsRefresher)
+	if !ok || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return resp, nil
+	}
+	refresher.invalidate(creds)
+	renewed, err := t.credentials(req)
+	if err != nil || renewed == creds {
+		return resp, nil
+	}
+	replay := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		replay.Body = body
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return t.next.RoundTrip(withCredentials(replay, renewed))
+}
+
+// credentials resolves the credentials for req: the provider's, with the tenant key
+// from the context taking precedence
+func (t *authTransport) credentials(req *http.Request) (Credentials, error) {
+	ctx := req.Context()
+	var creds Credentials
+	if t.provider != nil {
+		var err error
+		creds, err = t.provider.Credentials(ctx)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("flagent: failed to get credentials: %w", err)
+		}
+	}
+	if apiKey, ok := TenantAPIKeyFromContext(ctx); ok {
+		creds.APIKey = apiKey
+	}
+	return creds, nil
+}
+
+// withCredentials returns req with the credential headers set
+func withCredentials(req *http.Request, creds Credentials) *http.Request {
+	if creds == (Credentials{}) {
+		return req
+	}
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	if creds.APIKey != "" {
+		req.Header.Set(headerAPIKey, creds.APIKey)
+	}
+	if creds.BearerToken != "" {
+		scheme := creds.TokenType
+		if scheme == "" {
+			scheme = "Bearer"
+		}
+		req.Header.Set(headerAuthorization, scheme+" "+creds.BearerToken)
+	}
+	return req
+}
+
+// withAuth returns a copy of httpClient whose transport applies credentials
+func withAuth(httpClient *http.Client, provider CredentialsProvider) *http.Client {
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped := *httpClient
+	wrapped.Transport = &authTransport{next: next, provider: provider}
+	return &wrapped
+}
@@ -0,0 +1,138 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHeaderServer(t *testing.T, headers chan<- http.Header) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestAuth(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("WithTenantAPIKey sends X-API-Key", func(t *testing.T) {
+		headers := make(chan http.Header, 1)
+		client, err := NewClient(newHeaderServer(t, headers), WithTenantAPIKey("fla_tenant"))
+		require.NoError(t, err)
+		_, err = client.ListFlags(ctx, nil)
+		require.NoError(t, err)
+		h := <-headers
+		assert.Equal(t, "fla_tenant", h.Get("X-API-Key"))
+		assert.Empty(t, h.Get("Authorization"))
+	})
+
+	t.Run("CredentialsProvider is consulted on every request", func(t *testing.T) {
+		headers := make(chan http.Header, 2)
+		var n int32
+		provider := CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+			if atomic.AddInt32(&n, 1) == 1 {
+				return Credentials{APIKey: "key-1"}, nil
+			}
+			return Credentials{APIKey: "key-2", BearerToken: "jwt"}, nil
+		})
+		client, err := NewClient(newHeaderServer(t, headers), WithAPIKey("static"), WithCredentialsProvider(provider))
+		require.NoError(t, err)
+
+		_, err = client.ListFlags(ctx, nil)
+		require.NoError(t, err)
+		h := <-headers
+		assert.Equal(t, "key-1", h.Get("X-API-Key"))
+		assert.Equal(t, "Bearer static", h.Get("Authorization"))
+
+		_, err = client.ListFlags(ctx, nil)
+		require.NoError(t, err)
+		h = <-headers
+		assert.Equal(t, "key-2", h.Get("X-API-Key"))
+		assert.Equal(t, "Bearer jwt", h.Get("Authorization"))
+	})
+
+	t.Run("context tenant key overrides client credentials", func(t *testing.T) {
+		headers := make(chan http.Header, 2)
+		client, err := NewClient(newHeaderServer(t, headers), WithTenantAPIKey("platform"))
+		require.NoError(t, err)
+
+		_, err = client.ListFlags(ContextWithTenantAPIKey(ctx, "tenant-a"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-a", (<-headers).Get("X-API-Key"))
+
+		_, err = client.ListFlags(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "platform", (<-headers).Get("X-API-Key"))
+	})
+
+	t.Run("context tenant key applies to raw requests", func(t *testing.T) {
+		headers := make(chan http.Header, 1)
+		client, err := NewClient(newHeaderServer(t, headers))
+		require.NoError(t, err)
+		_, err = client.do(ContextWithTenantAPIKey(ctx, "tenant-b"), http.MethodGet, "/flags", nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-b", (<-headers).Get("X-API-Key"))
+	})
+
+	t.Run("retries re-resolve credentials", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				assert.Equal(t, "old", r.Header.Get("X-API-Key"))
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			assert.Equal(t, "rotated", r.Header.Get("X-API-Key"))
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		var n int32
+		provider := CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+			if atomic.AddInt32(&n, 1) == 1 {
+				return Credentials{APIKey: "old"}, nil
+			}
+			return Credentials{APIKey: "rotated"}, nil
+		})
+		client, err := NewClient(server.URL, WithCredentialsProvider(provider), WithRetryDelay(time.Millisecond))
+		require.NoError(t, err)
+		_, err = client.ListFlags(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("provider error fails the request", func(t *testing.T) {
+		errVault := errors.New("vault sealed")
+		client, err := NewClient("http://127.0.0.1:1", WithMaxRetries(0),
+			WithCredentialsProvider(CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+				return Credentials{}, errVault
+			})))
+		require.NoError(t, err)
+		_, err = client.ListFlags(ctx, nil)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errVault))
+	})
+}
+
+func TestTenantAPIKeyFromContext(t *testing.T) {
+	_, ok := TenantAPIKeyFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = TenantAPIKeyFromContext(ContextWithTenantAPIKey(context.Background(), ""))
+	assert.False(t, ok)
+	key, ok := TenantAPIKeyFromContext(ContextWithTenantAPIKey(context.Background(), "k"))
+	assert.True(t, ok)
+	assert.Equal(t, "k", key)
+}
@@ -0,0 +1,70 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxBatchRetryDelay caps the backoff between retries of a background batch
+const maxBatchRetryDelay = 30 * time.Second
+
+// batchDropError is a batch the server rejected or cannot accept; retrying it cannot succeed
+type batchDropError struct {
+	what  string
+	count int
+	err   error
+}
+
+func (e *batchDropError) Error() string {
+	return fmt.Sprintf("dropped %d %s rejected by the server: %v", e.count, e.what, e.err)
+}
+
+func (e *batchDropError) Unwrap() error { return e.err }
+
+// postBatch sends a batch of count items collected in the background (analytics
+// events, crash reports), retrying transient failures with backoff. POSTs are not
+// retried by the client's retry layer, since a batch the server stored but did not
+// acknowledge would be sent twice; losing a batch is the worse outcome here.
+// A batch the server rejects, or whose feature the server lacks, is returned as *batchDropError.
+func postBatch(ctx context.Context, c *Client, feature Feature, path, what string, body interface{}, count, maxRetries int, retryDelay time.Duration) error {
+	if err := c.requireFeature(feature); err != nil {
+		return &batchDropError{what: what, count: count, err: err}
+	}
+	backoff := BackoffPolicy{BaseDelay: retryDelay, MaxDelay: maxBatchRetryDelay}
+	for retries := 0; ; retries++ {
+		resp, err := c.do(ctx, http.MethodPost, path, nil, body, nil)
+		if err == nil {
+			return nil
+		}
+		msg := "failed to send " + what
+		if resp != nil && !isTransientBatchStatus(resp.StatusCode) {
+			return &batchDropError{what: what, count: count, err: c.featureError(feature, resp, err, msg)}
+		}
+		if retries >= maxRetries || ctx.Err() != nil {
+			return convertRequestError(resp, err, msg, false)
+		}
+		delay, ok := retryAfter(resp)
+		if !ok || delay > maxBatchRetryDelay {
+			delay = backoff.backoff(retries)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return convertRequestError(resp, err, msg, false)
+		case <-timer.C:
+		}
+	}
+}
+
+// isTransientBatchStatus reports whether a batch failed with this status may succeed
+// later. Authentication errors are kept too, since fixing the credentials fixes them.
+func isTransientBatchStatus(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
@@ -0,0 +1,115 @@
+package flagent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchServer records the items of the batches POSTed to one path and answers
+// evaluations with variant "blue" of flag "checkout" for user1
+type batchServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	items    []map[string]interface{}
+	errs     []error
+	requests int32
+	// status decides the answer to each batch request; set it before the first request
+	status func(request int32) int
+}
+
+// newBatchServer starts a server accepting batches at path with status accepted.
+// field names the JSON field holding the items, or "" if the body is the item array.
+// Handler errors are reported when the test ends, since require must not be called
+// outside the test goroutine.
+func newBatchServer(t *testing.T, path, field string, accepted int) *batchServer {
+	t.Helper()
+	s := &batchServer{status: func(int32) int { return accepted }}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/evaluation" {
+			w.Write([]byte(`{"flagID": 5, "flagKey": "checkout", "variantID": 9, "variantKey": "blue", "evalContext": {"entityID": "user1"}}`))
+			return
+		}
+		if r.URL.Path != path {
+			s.fail(fmt.Errorf("unexpected path %s", r.URL.Path))
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		status := s.status(atomic.AddInt32(&s.requests, 1))
+		if status != accepted {
+			w.WriteHeader(status)
+			return
+		}
+		var items []map[string]interface{}
+		var err error
+		if field == "" {
+			err = json.NewDecoder(r.Body).Decode(&items)
+		} else {
+			var body map[string][]map[string]interface{}
+			err = json.NewDecoder(r.Body).Decode(&body)
+			items = body[field]
+		}
+		if err != nil {
+			s.fail(fmt.Errorf("cannot decode batch: %w", err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.items = append(s.items, items...)
+		s.mu.Unlock()
+		w.WriteHeader(accepted)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(func() {
+		s.Close()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, err := range s.errs {
+			t.Error(err)
+		}
+	})
+	return s
+}
+
+func (s *batchServer) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+// received returns the items of all batches received so far
+func (s *batchServer) received() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.items...)
+}
+
+// newClient returns a client of the server without retries
+func (s *batchServer) newClient(t *testing.T) *Client {
+	t.Helper()
+	client, err := NewClient(s.URL, WithMaxRetries(0))
+	require.NoError(t, err)
+	return client
+}
+
+func TestIsTransientBatchStatus(t *testing.T) {
+	for status, transient := range map[int]bool{
+		http.StatusBadRequest:          false,
+		http.StatusNotFound:            false,
+		http.StatusUnauthorized:        true,
+		http.StatusForbidden:           true,
+		http.StatusTooManyRequests:     true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusInternalServerError: true,
+	} {
+		assert.Equal(t, transient, isTransientBatchStatus(status), "status %d", status)
+	}
+}
@@ -0,0 +1,285 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets calls through and tracks their outcome
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects calls with ErrCircuitOpen until OpenTimeout has passed
+	CircuitOpen
+	// CircuitHalfOpen lets a few probe calls through to test whether the server recovered
+	CircuitHalfOpen
+)
+
+// String returns the state name
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker. Zero fields take the defaults
+// of DefaultCircuitBreakerConfig.
+type CircuitBreakerConfig struct {
+	// FailureRateThreshold opens the circuit when this share of the calls in the
+	// window failed (default: 0.5)
+	FailureRateThreshold float64
+
+	// SlowCallThreshold counts calls that take longer as failures, even if they
+	// succeed (default: 0, latency is not tracked)
+	SlowCallThreshold time.Duration
+
+	// WindowSize is the number of most recent calls the failure rate is computed over (default: 20)
+	WindowSize int
+
+	// MinimumCalls is the number of calls in the window before the circuit can open (default: 10)
+	MinimumCalls int
+
+	// OpenTimeout is how long the circuit stays open before probing the server (default: 30s)
+	OpenTimeout time.Duration
+
+	// HalfOpenCalls is the number of probe calls that must succeed to close the circuit (default: 1)
+	HalfOpenCalls int
+
+	// IsFailure decides which errors count as failures. The default counts network
+	// errors, timeouts, 5xx and 429 responses; client errors such as a missing flag
+	// do not indicate an unhealthy server.
+	IsFailure func(err error) bool
+
+	// OnStateChange is called after every state transition, e.g. to alert when the circuit opens
+	OnStateChange func(from, to CircuitState)
+}
+
+// DefaultCircuitBreakerConfig returns the default circuit breaker configuration
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureRateThreshold: 0.5,
+		WindowSize:           20,
+		MinimumCalls:         10,
+		OpenTimeout:          30 * time.Second,
+		HalfOpenCalls:        1,
+		IsFailure:            isCircuitFailure,
+	}
+}
+
+// CircuitBreaker stops calling a degraded server. While closed it tracks the failure
+// rate of recent calls; past the threshold it opens and rejects calls immediately with
+// a CircuitOpenError. After OpenTimeout it lets probe calls through (half-open) and
+// closes again once they succeed. A CircuitBreaker is safe for concurrent use and can
+// be shared between clients.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	openedAt time.Time
+	// window is a ring buffer of recent outcomes (true = failure)
+	window   []bool
+	next     int
+	calls    int
+	failures int
+	// probes is the number of half-open calls admitted, successes how many of them succeeded
+	probes    int
+	successes int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	defaults := DefaultCircuitBreakerConfig()
+	if config.FailureRateThreshold <= 0 || config.FailureRateThreshold > 1 {
+		config.FailureRateThreshold = defaults.FailureRateThreshold
+	}
+	if config.WindowSize <= 0 {
+		config.WindowSize = defaults.WindowSize
+	}
+	if config.MinimumCalls <= 0 {
+		config.MinimumCalls = defaults.MinimumCalls
+	}
+	if config.MinimumCalls > config.WindowSize {
+		config.MinimumCalls = config.WindowSize
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaults.OpenTimeout
+	}
+	if config.HalfOpenCalls <= 0 {
+		config.HalfOpenCalls = defaults.HalfOpenCalls
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = defaults.IsFailure
+	}
+	return &CircuitBreaker{
+		config: config,
+		now:    time.Now,
+		window: make([]bool, config.WindowSize),
+	}
+}
+
+// WithCircuitBreaker guards remote evaluation (Evaluate, EvaluateBatch) and export
+// calls (GetSnapshot, ExportSQLite) with breaker. While it is open those calls fail
+// fast with a CircuitOpenError (errors.Is(err, ErrCircuitOpen)) instead of waiting
+// for a degraded server.
+func WithCircuitBreaker(breaker *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	transition := b.expireOpen()
+	state := b.state
+	b.mu.Unlock()
+	b.notify(transition)
+	return state
+}
+
+// Execute runs fn if the circuit allows it and records the outcome. When the circuit
+// is open, fn is not called and a CircuitOpenError is returned.
+func (b *CircuitBreaker) Execute(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	start := b.now()
+	err := fn()
+	b.record(err, b.now().Sub(start))
+	return err
+}
+
+// stateTransition is a state change to report to OnStateChange once the lock is released
+type stateTransition struct {
+	from, to CircuitState
+}
+
+// allow admits a call or rejects it with a CircuitOpenError
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	transition := b.expireOpen()
+	var err error
+	switch b.state {
+	case CircuitOpen:
+		err = NewCircuitOpenError("circuit breaker is open", nil)
+	case CircuitHalfOpen:
+		if b.probes >= b.config.HalfOpenCalls {
+			err = NewCircuitOpenError("circuit breaker is half-open, waiting for probe calls", nil)
+		} else {
+			b.probes++
+		}
+	}
+	b.mu.Unlock()
+	b.notify(transition)
+	return err
+}
+
+// record tracks the outcome of an admitted call
+func (b *CircuitBreaker) record(err error, elapsed time.Duration) {
+	// A call the caller cancelled says nothing about the server
+	ignored := errors.Is(err, context.Canceled)
+	failed := !ignored && (b.config.IsFailure(err) ||
+		(b.config.SlowCallThreshold > 0 && elapsed > b.config.SlowCallThreshold))
+
+	b.mu.Lock()
+	var transition *stateTransition
+	switch b.state {
+	case CircuitClosed:
+		if !ignored {
+			b.push(failed)
+			if b.calls >= b.config.MinimumCalls &&
+				float64(b.failures)/float64(b.calls) >= b.config.FailureRateThreshold {
+				transition = b.setState(CircuitOpen)
+			}
+		}
+	case CircuitHalfOpen:
+		switch {
+		case failed:
+			transition = b.setState(CircuitOpen)
+		case ignored:
+			// Free the probe slot for another call
+			b.probes--
+		default:
+			b.successes++
+			if b.successes >= b.config.HalfOpenCalls {
+				transition = b.setState(CircuitClosed)
+			}
+		}
+	}
+	b.mu.Unlock()
+	b.notify(transition)
+}
+
+// push adds an outcome to the window, evicting the oldest one
+func (b *CircuitBreaker) push(failed bool) {
+	if b.calls == len(b.window) {
+		if b.window[b.next] {
+			b.failures--
+		}
+	} else {
+		b.calls++
+	}
+	b.window[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.window)
+}
+
+// expireOpen moves an open circuit to half-open once OpenTimeout has passed
+func (b *CircuitBreaker) expireOpen() *stateTransition {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.config.OpenTimeout {
+		return b.setState(CircuitHalfOpen)
+	}
+	return nil
+}
+
+// setState switches state and resets the bookkeeping of the new state
+func (b *CircuitBreaker) setState(to CircuitState) *stateTransition {
+	from := b.state
+	b.state = to
+	switch to {
+	case CircuitOpen:
+		b.openedAt = b.now()
+	case CircuitHalfOpen:
+		b.probes, b.successes = 0, 0
+	case CircuitClosed:
+		b.next, b.calls, b.failures = 0, 0, 0
+	}
+	return &stateTransition{from: from, to: to}
+}
+
+// notify calls OnStateChange outside the lock, so callbacks may use the breaker
+func (b *CircuitBreaker) notify(transition *stateTransition) {
+	if transition != nil && b.config.OnStateChange != nil {
+		b.config.OnStateChange(transition.from, transition.to)
+	}
+}
+
+// isCircuitFailure is the default CircuitBreakerConfig.IsFailure
+func isCircuitFailure(err error) bool {
+	return errors.Is(err, ErrServerUnavailable) ||
+		errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// guard runs call through the circuit breaker, if one is configured
+func (c *Client) guard(call func() error) error {
+	if c.breaker == nil {
+		return call()
+	}
+	return c.breaker.Execute(call)
+}
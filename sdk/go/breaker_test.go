@@ -0,0 +1,148 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for CircuitBreaker.now
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(config CircuitBreakerConfig) (*CircuitBreaker, *fakeClock, *[]string) {
+	var transitions []string
+	config.OnStateChange = func(from, to CircuitState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	}
+	breaker := NewCircuitBreaker(config)
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	breaker.now = clock.now
+	return breaker, clock, &transitions
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	unavailable := NewNetworkError("evaluation failed", errors.New("connection refused"))
+	succeed := func() error { return nil }
+	fail := func() error { return unavailable }
+
+	t.Run("opens at the failure rate and fails fast", func(t *testing.T) {
+		breaker, _, transitions := newTestBreaker(CircuitBreakerConfig{WindowSize: 4, MinimumCalls: 4, FailureRateThreshold: 0.5})
+		require.NoError(t, breaker.Execute(succeed))
+		require.NoError(t, breaker.Execute(succeed))
+		require.Error(t, breaker.Execute(fail))
+		assert.Equal(t, CircuitClosed, breaker.State())
+		require.Error(t, breaker.Execute(fail))
+		assert.Equal(t, CircuitOpen, breaker.State())
+
+		called := false
+		err := breaker.Execute(func() error { called = true; return nil })
+		assert.False(t, called)
+		assert.True(t, errors.Is(err, ErrCircuitOpen))
+		var openErr *CircuitOpenError
+		assert.True(t, errors.As(err, &openErr))
+		assert.Equal(t, []string{"closed->open"}, *transitions)
+	})
+
+	t.Run("half-open probe closes the circuit", func(t *testing.T) {
+		breaker, clock, transitions := newTestBreaker(CircuitBreakerConfig{
+			WindowSize: 2, MinimumCalls: 2, OpenTimeout: time.Minute, HalfOpenCalls: 2,
+		})
+		breaker.Execute(fail)
+		breaker.Execute(fail)
+		require.Equal(t, CircuitOpen, breaker.State())
+
+		clock.advance(time.Minute)
+		assert.Equal(t, CircuitHalfOpen, breaker.State())
+		require.NoError(t, breaker.Execute(succeed))
+		require.NoError(t, breaker.Execute(succeed))
+		assert.Equal(t, CircuitClosed, breaker.State())
+		assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, *transitions)
+	})
+
+	t.Run("failed probe reopens the circuit", func(t *testing.T) {
+		breaker, clock, _ := newTestBreaker(CircuitBreakerConfig{WindowSize: 1, MinimumCalls: 1, OpenTimeout: time.Second})
+		breaker.Execute(fail)
+		clock.advance(time.Second)
+		require.Error(t, breaker.Execute(fail))
+		assert.Equal(t, CircuitOpen, breaker.State())
+		clock.advance(500 * time.Millisecond)
+		assert.Equal(t, CircuitOpen, breaker.State(), "open timeout restarts")
+	})
+
+	t.Run("half-open admits a limited number of probes", func(t *testing.T) {
+		breaker, clock, _ := newTestBreaker(CircuitBreakerConfig{WindowSize: 1, MinimumCalls: 1, OpenTimeout: time.Second})
+		breaker.Execute(fail)
+		clock.advance(time.Second)
+		require.NoError(t, breaker.allow())
+		assert.True(t, errors.Is(breaker.allow(), ErrCircuitOpen))
+	})
+
+	t.Run("slow calls count as failures", func(t *testing.T) {
+		breaker, clock, _ := newTestBreaker(CircuitBreakerConfig{WindowSize: 2, MinimumCalls: 2, SlowCallThreshold: time.Second})
+		slow := func() error { clock.advance(2 * time.Second); return nil }
+		require.NoError(t, breaker.Execute(slow))
+		require.NoError(t, breaker.Execute(slow))
+		assert.Equal(t, CircuitOpen, breaker.State())
+	})
+
+	t.Run("client errors and cancellations are not failures", func(t *testing.T) {
+		breaker, _, _ := newTestBreaker(CircuitBreakerConfig{WindowSize: 2, MinimumCalls: 2})
+		notFound := NewFlagNotFoundError("evaluation failed", &APIError{StatusCode: http.StatusNotFound})
+		breaker.Execute(func() error { return notFound })
+		breaker.Execute(func() error { return NewNetworkError("evaluation failed", context.Canceled) })
+		breaker.Execute(func() error { return notFound })
+		assert.Equal(t, CircuitClosed, breaker.State())
+	})
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var opened int32
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		WindowSize:   3,
+		MinimumCalls: 3,
+		OnStateChange: func(from, to CircuitState) {
+			if to == CircuitOpen {
+				atomic.AddInt32(&opened, 1)
+			}
+		},
+	})
+	client, err := NewClient(server.URL, WithMaxRetries(0), WithCircuitBreaker(breaker))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := client.Evaluate(ctx, &EvaluationContext{FlagKey: stringPtr("f1")})
+		require.True(t, errors.Is(err, ErrServerUnavailable))
+	}
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&opened))
+
+	_, err = client.Evaluate(ctx, &EvaluationContext{FlagKey: stringPtr("f1")})
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	_, err = client.GetSnapshot(ctx)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	_, err = client.ExportSQLite(ctx, true)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls), "open circuit does not reach the server")
+
+	// Management calls are not guarded
+	_, err = client.ListFlags(ctx, nil)
+	assert.True(t, errors.Is(err, ErrServerUnavailable))
+}
@@ -0,0 +1,317 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultCompatibilityTimeout = 5 * time.Second
+
+// Feature is a server feature that not every supported server version provides
+type Feature string
+
+const (
+	FeatureMetrics      Feature = "metrics"
+	FeatureAnalytics    Feature = "analytics"
+	FeatureCrashReports Feature = "crashes"
+	FeatureWebhooks     Feature = "webhooks"
+	FeatureRealtimeSSE  Feature = "realtime.sse"
+	FeatureGitOps       Feature = "gitops"
+)
+
+// RealtimeTransport is a way for clients to learn about flag changes
+type RealtimeTransport string
+
+const (
+	// RealtimePolling re-fetches flags or snapshots periodically; every server supports it
+	RealtimePolling RealtimeTransport = "polling"
+	// RealtimeSSE streams flag changes from /realtime/sse
+	RealtimeSSE RealtimeTransport = "sse"
+)
+
+// coreEndpoints are served by every supported server version, relative to /api/v1
+var coreEndpoints = []string{
+	"/evaluation", "/evaluation/batch",
+	"/flags", "/flags/{flagID}", "/flags/{flagID}/segments", "/flags/{flagID}/segments/{segmentID}",
+	"/flags/{flagID}/segments/{segmentID}/constraints", "/flags/{flagID}/segments/{segmentID}/distributions",
+	"/flags/{flagID}/variants", "/flags/{flagID}/tags", "/tags",
+	"/health", "/info",
+}
+
+// constraintOperators are the constraint operators of every supported server version
+var constraintOperators = []string{
+	"EQ", "NEQ", "LT", "LTE", "GT", "GTE", "IN", "NOTIN", "EREG", "NEREG", "CONTAINS", "NOTCONTAINS",
+}
+
+// features lists the optional features in a stable order with their endpoints. Keep in
+// sync with docs/guides/compatibility.md.
+//
+// Server releases do not record which version added each feature, so features are not
+// gated by version: a feature is assumed present until a request to one of its
+// collection endpoints (no IDs in the path) answers 404, see Client.featureError.
+var features = []struct {
+	feature   Feature
+	endpoints []string
+}{
+	{FeatureMetrics, []string{"/metrics/overview", "/flags/{flagID}/evaluation-stats", "/flags/{flagID}/usage"}},
+	{FeatureAnalytics, []string{"/analytics/events", "/analytics/overview", "/analytics/funnel"}},
+	{FeatureCrashReports, []string{"/crashes/batch", "/crashes/overview"}},
+	{FeatureWebhooks, []string{"/webhooks", "/webhooks/{webhookID}"}},
+	{FeatureRealtimeSSE, []string{"/realtime/sse"}},
+	{FeatureGitOps, []string{"/export/gitops", "/import"}},
+}
+
+// Capabilities describes the connected server, as reported by /info
+type Capabilities struct {
+	ServerVersion string
+	BuildTime     string
+	GitCommit     string
+	// Enterprise is true when the server runs with the enterprise module
+	Enterprise   bool
+	LicenseValid bool
+
+	version semver
+	known   bool
+	// client reports the features its server answered 404 for; nil if none are known
+	client *Client
+}
+
+type infoJSON struct {
+	Version           string `json:"version"`
+	BuildTime         string `json:"buildTime"`
+	GitCommit         string `json:"gitCommit"`
+	EnterpriseEnabled bool   `json:"enterpriseEnabled"`
+	LicenseValid      bool   `json:"licenseValid"`
+}
+
+func newCapabilities(info infoJSON) *Capabilities {
+	caps := &Capabilities{
+		ServerVersion: info.Version,
+		BuildTime:     info.BuildTime,
+		GitCommit:     info.GitCommit,
+		Enterprise:    info.EnterpriseEnabled,
+		LicenseValid:  info.LicenseValid,
+	}
+	caps.version, caps.known = parseSemver(info.Version)
+	return caps
+}
+
+// Supports reports whether the server provides the feature. Every feature is assumed
+// supported until the server answered a request for it with 404.
+func (c *Capabilities) Supports(feature Feature) bool {
+	for _, f := range features {
+		if f.feature == feature {
+			return c.client == nil || !c.client.lacksFeature(feature)
+		}
+	}
+	return false
+}
+
+// Features returns the optional features the server provides
+func (c *Capabilities) Features() []Feature {
+	var supported []Feature
+	for _, f := range features {
+		if c.Supports(f.feature) {
+			supported = append(supported, f.feature)
+		}
+	}
+	return supported
+}
+
+// Endpoints returns the paths the server serves, relative to /api/v1, with path
+// parameters in braces. Admin and enterprise endpoints are not included.
+func (c *Capabilities) Endpoints() []string {
+	endpoints := append([]string(nil), coreEndpoints...)
+	for _, f := range features {
+		if c.Supports(f.feature) {
+			endpoints = append(endpoints, f.endpoints...)
+		}
+	}
+	return endpoints
+}
+
+// ConstraintOperators returns the constraint operators the server evaluates
+func (c *Capabilities) ConstraintOperators() []string {
+	return append([]string(nil), constraintOperators...)
+}
+
+// RealtimeTransports returns the ways the server can push flag changes, preferred first
+func (c *Capabilities) RealtimeTransports() []RealtimeTransport {
+	if c.Supports(FeatureRealtimeSSE) {
+		return []RealtimeTransport{RealtimeSSE, RealtimePolling}
+	}
+	return []RealtimeTransport{RealtimePolling}
+}
+
+// CheckCompatibility returns an IncompatibleServerError if this SDK version does not
+// support the server's version (see docs/guides/compatibility.md). Before 1.0 the
+// major and minor versions must match, from 1.0 on the major version; the patch version
+// never matters. Servers whose version cannot be parsed are not checked.
+func (c *Capabilities) CheckCompatibility() error {
+	if !c.known {
+		return nil
+	}
+	sdk, _ := parseSemver(Version)
+	if c.version.major != sdk.major || (sdk.major == 0 && c.version.minor != sdk.minor) {
+		return NewIncompatibleServerError(
+			fmt.Sprintf("server version %s is not compatible with SDK version %s", c.ServerVersion, Version), nil)
+	}
+	return nil
+}
+
+// CompatibilityPolicy decides what WithCompatibilityCheck does with an incompatible server
+type CompatibilityPolicy int
+
+const (
+	// CompatibilityWarn logs a warning and creates the client anyway
+	CompatibilityWarn CompatibilityPolicy = iota
+	// CompatibilityStrict makes NewClient fail, also when /info cannot be fetched
+	CompatibilityStrict
+)
+
+// CompatibilityOptions configures WithCompatibilityCheck
+type CompatibilityOptions struct {
+	Policy CompatibilityPolicy
+	// Logger receives warnings (default: slog.Default())
+	Logger *slog.Logger
+	// Timeout bounds the /info request (default: 5s)
+	Timeout time.Duration
+}
+
+// WithCompatibilityCheck makes NewClient fetch /info and check the server version
+// against the SDK's. The capabilities are cached. opts may be nil.
+func WithCompatibilityCheck(opts *CompatibilityOptions) ClientOption {
+	return func(c *Client) {
+		o := CompatibilityOptions{}
+		if opts != nil {
+			o = *opts
+		}
+		if o.Logger == nil {
+			o.Logger = slog.Default()
+		}
+		if o.Timeout <= 0 {
+			o.Timeout = defaultCompatibilityTimeout
+		}
+		c.compatibility = &o
+	}
+}
+
+// checkCompatibility runs the startup check configured by WithCompatibilityCheck
+func (c *Client) checkCompatibility() error {
+	opts := c.compatibility
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	caps, err := c.Capabilities(ctx)
+	if err == nil {
+		err = caps.CheckCompatibility()
+	}
+	if err == nil {
+		return nil
+	}
+	if opts.Policy == CompatibilityStrict {
+		return err
+	}
+	opts.Logger.Warn("flagent: server compatibility check failed", "sdkVersion", Version, "error", err)
+	return nil
+}
+
+// Capabilities returns what the server supports. The first successful call fetches
+// /info; later calls return the cached result.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	if caps, ok := c.KnownCapabilities(); ok {
+		return caps, nil
+	}
+	var out infoJSON
+	resp, err := c.do(ctx, http.MethodGet, "/info", nil, nil, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, "failed to get server info", false)
+	}
+	caps := newCapabilities(out)
+	caps.client = c
+
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+	if c.capabilities == nil {
+		c.capabilities = caps
+	}
+	return c.capabilities, nil
+}
+
+// KnownCapabilities returns the cached capabilities without contacting the server.
+// ok is false until Capabilities or WithCompatibilityCheck fetched them.
+func (c *Client) KnownCapabilities() (caps *Capabilities, ok bool) {
+	c.capabilitiesMu.RLock()
+	defer c.capabilitiesMu.RUnlock()
+	return c.capabilities, c.capabilities != nil
+}
+
+// requireFeature fails with an UnsupportedError when the server already answered a
+// request for feature with 404; otherwise the request is sent
+func (c *Client) requireFeature(feature Feature) error {
+	if c.lacksFeature(feature) {
+		return newFeatureUnsupportedError(feature, nil)
+	}
+	return nil
+}
+
+// featureError converts the error of a request to one of feature's collection
+// endpoints. These exist on every server with the feature, so a 404 means the server
+// lacks it: the feature is remembered as unsupported and an UnsupportedError is
+// returned. Endpoints with IDs in the path use convertRequestError, since their 404
+// means the resource is missing.
+func (c *Client) featureError(feature Feature, resp *http.Response, err error, context string) error {
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		c.capabilitiesMu.Lock()
+		if c.unsupported == nil {
+			c.unsupported = make(map[Feature]bool)
+		}
+		c.unsupported[feature] = true
+		c.capabilitiesMu.Unlock()
+		return newFeatureUnsupportedError(feature, newAPIError(resp, err))
+	}
+	return convertRequestError(resp, err, context, false)
+}
+
+// lacksFeature reports whether the server answered a request for feature with 404
+func (c *Client) lacksFeature(feature Feature) bool {
+	c.capabilitiesMu.RLock()
+	defer c.capabilitiesMu.RUnlock()
+	return c.unsupported[feature]
+}
+
+func newFeatureUnsupportedError(feature Feature, err error) *UnsupportedError {
+	return NewUnsupportedError(fmt.Sprintf("server does not support %s", feature), err)
+}
+
+// semver is a parsed major.minor.patch version
+type semver struct {
+	major, minor, patch int
+}
+
+// parseSemver parses versions like "0.1.7", "v1.2" or "0.2.0-SNAPSHOT"; pre-release
+// and build suffixes are ignored
+func parseSemver(s string) (v semver, ok bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return semver{}, false
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		nums[i] = n
+	}
+	return semver{major: nums[0], minor: nums[1], patch: nums[2]}, true
+}
@@ -0,0 +1,175 @@
+package flagent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newInfoServer serves /api/v1/info with version and fails every other request
+func newInfoServer(t *testing.T, version string, infoCalls *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/info" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(infoCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"version": %q, "buildTime": "2026-03-05T10:00:00Z", "gitCommit": "abc123",
+			"enterpriseEnabled": false, "licenseValid": false}`, version)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCapabilities(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fetched once and cached", func(t *testing.T) {
+		var calls int32
+		server := newInfoServer(t, "0.1.7", &calls)
+		client, err := NewClient(server.URL + "/api/v1")
+		require.NoError(t, err)
+		_, ok := client.KnownCapabilities()
+		assert.False(t, ok)
+
+		caps, err := client.Capabilities(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "0.1.7", caps.ServerVersion)
+		assert.Equal(t, "abc123", caps.GitCommit)
+		assert.NoError(t, caps.CheckCompatibility())
+		assert.Equal(t, []Feature{FeatureMetrics, FeatureAnalytics, FeatureCrashReports, FeatureWebhooks, FeatureRealtimeSSE, FeatureGitOps}, caps.Features())
+		assert.Equal(t, []RealtimeTransport{RealtimeSSE, RealtimePolling}, caps.RealtimeTransports())
+		assert.Contains(t, caps.ConstraintOperators(), "NOTCONTAINS")
+		assert.Contains(t, caps.Endpoints(), "/realtime/sse")
+
+		_, err = client.Capabilities(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("features are not gated by version", func(t *testing.T) {
+		caps := newCapabilities(infoJSON{Version: "0.1.0"})
+		assert.NoError(t, caps.CheckCompatibility())
+		assert.True(t, caps.Supports(FeatureWebhooks))
+		assert.Equal(t, []RealtimeTransport{RealtimeSSE, RealtimePolling}, caps.RealtimeTransports())
+		assert.False(t, caps.Supports(Feature("unknown")))
+	})
+
+	t.Run("version rules", func(t *testing.T) {
+		for version, compatible := range map[string]bool{
+			"0.1.0":          true,
+			"0.1.9-SNAPSHOT": true,
+			"v0.1.7":         true,
+			"0.2.0":          false,
+			"0.0.9":          false,
+			"1.1.7":          false,
+			"dev":            true,
+		} {
+			err := newCapabilities(infoJSON{Version: version}).CheckCompatibility()
+			assert.Equal(t, compatible, err == nil, version)
+			if !compatible {
+				assert.True(t, errors.Is(err, ErrIncompatibleServer), version)
+			}
+		}
+		assert.True(t, newCapabilities(infoJSON{Version: "dev"}).Supports(FeatureRealtimeSSE))
+	})
+}
+
+func TestWithCompatibilityCheck(t *testing.T) {
+	t.Run("strict rejects incompatible server", func(t *testing.T) {
+		var calls int32
+		server := newInfoServer(t, "0.2.0", &calls)
+		_, err := NewClient(server.URL+"/api/v1", WithCompatibilityCheck(&CompatibilityOptions{Policy: CompatibilityStrict}))
+		assert.True(t, errors.Is(err, ErrIncompatibleServer))
+		var incompatible *IncompatibleServerError
+		require.True(t, errors.As(err, &incompatible))
+		assert.Contains(t, incompatible.Error(), "server version 0.2.0")
+	})
+
+	t.Run("strict fails when info is unavailable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		_, err := NewClient(server.URL, WithMaxRetries(0), WithCompatibilityCheck(&CompatibilityOptions{Policy: CompatibilityStrict}))
+		assert.True(t, errors.Is(err, ErrServerUnavailable))
+	})
+
+	t.Run("warn logs and continues", func(t *testing.T) {
+		var calls int32
+		server := newInfoServer(t, "0.2.0", &calls)
+		var buf bytes.Buffer
+		client, err := NewClient(server.URL+"/api/v1", WithCompatibilityCheck(&CompatibilityOptions{
+			Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+		}))
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "level=WARN")
+		assert.Contains(t, buf.String(), "server version 0.2.0 is not compatible with SDK version "+Version)
+		_, ok := client.KnownCapabilities()
+		assert.True(t, ok)
+	})
+
+}
+
+func TestUnsupportedFeatures(t *testing.T) {
+	ctx := context.Background()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/info" {
+			w.Write([]byte(`{"version": "0.1.4"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer server.Close()
+	client, err := NewClient(server.URL+"/api/v1", WithMaxRetries(0), WithCompatibilityCheck(nil))
+	require.NoError(t, err)
+	caps, ok := client.KnownCapabilities()
+	require.True(t, ok)
+
+	t.Run("404 from a feature endpoint", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		_, err := client.Webhooks().List(ctx)
+		assert.True(t, errors.Is(err, ErrUnsupported))
+		var unsupported *UnsupportedError
+		require.True(t, errors.As(err, &unsupported))
+		assert.Contains(t, unsupported.Error(), "server does not support webhooks")
+
+		_, err = client.Webhooks().Create(ctx, &WebhookInput{URL: "https://example.com/hook", Events: []WebhookEvent{WebhookFlagUpdated}})
+		assert.True(t, errors.Is(err, ErrUnsupported))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "later calls fail fast")
+		assert.False(t, caps.Supports(FeatureWebhooks))
+		assert.NotContains(t, caps.Features(), FeatureWebhooks)
+		assert.NotContains(t, caps.Endpoints(), "/webhooks")
+	})
+
+	t.Run("batches are dropped", func(t *testing.T) {
+		err := postBatch(ctx, client, FeatureAnalytics, "/analytics/events", "analytics events", nil, 2, 3, 0)
+		var dropErr *batchDropError
+		assert.True(t, errors.As(err, &dropErr))
+		assert.True(t, errors.Is(err, ErrUnsupported))
+		assert.False(t, caps.Supports(FeatureAnalytics))
+	})
+
+	t.Run("404 of a resource is not a missing feature", func(t *testing.T) {
+		_, err := client.GetFlagEvaluationStats(ctx, 42, LastMetricsWindow(time.Hour))
+		assert.True(t, errors.Is(err, ErrFlagNotFound))
+		assert.True(t, caps.Supports(FeatureMetrics))
+	})
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/MaxLuxs/Flagent/sdk/go/api"
 )
 
+// Version is the SDK version, checked against the server's by WithCompatibilityCheck
+const Version = "0.1.7"
+
 const (
 	defaultTimeout   = 30 * time.Second
 	defaultUserAgent = "flagent-go-client/0.1.7"
@@ -21,7 +27,20 @@ type ClientOption func(*Client)
 
 // Client is the Flagent API client (wrapper over generated api.APIClient)
 type Client struct {
-	apiClient *api.APIClient
+	apiClient   *api.APIClient
+	retryPolicy RetryPolicy
+	credentials CredentialsProvider
+	middleware  []Middleware
+	breaker     *CircuitBreaker
+	failover    *FailoverConfig
+
+	compatibility  *CompatibilityOptions
+	capabilitiesMu sync.RWMutex
+	capabilities   *Capabilities
+	unsupported    map[Feature]bool
+
+	observerMu sync.RWMutex
+	observers  []*evaluationObserver
 }
 
 // NewClient creates a new Flagent client
@@ -38,16 +57,42 @@ func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
 	cfg.HTTPClient = &http.Client{Timeout: defaultTimeout}
 	cfg.UserAgent = defaultUserAgent
 
-	client := &Client{apiClient: api.NewAPIClient(cfg)}
+	client := &Client{
+		apiClient:   api.NewAPIClient(cfg),
+		retryPolicy: NewBackoffPolicy(defaultMaxRetries),
+	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	// Install middleware, auth, failover and retry layers last so they wrap whichever
+	// HTTP client the options chose. Retries sit on top, so every attempt picks the
+	// best endpoint and re-resolves credentials, and middleware sees each attempt as sent.
+	cfg.HTTPClient = withMiddleware(cfg.HTTPClient, client.middleware)
+	if user, ok := client.credentials.(httpClientUser); ok {
+		user.setHTTPClient(cfg.HTTPClient)
+	}
+	cfg.HTTPClient = withAuth(cfg.HTTPClient, client.credentials)
+	if client.failover != nil {
+		pool, err := newEndpointPool(baseURL, *client.failover)
+		if err != nil {
+			return nil, err
+		}
+		cfg.HTTPClient = withFailover(cfg.HTTPClient, pool)
+	}
+	cfg.HTTPClient = withRetries(cfg.HTTPClient, client.retryPolicy)
+
+	if client.compatibility != nil {
+		if err := client.checkCompatibility(); err != nil {
+			return nil, err
+		}
+	}
 	return client, nil
 }
 
-// WithAPIKey sets the API key for authentication
+// WithAPIKey sets the API key for authentication, sent as Authorization: Bearer.
+// Use WithTenantAPIKey for tenant API keys (X-API-Key).
 func WithAPIKey(apiKey string) ClientOption {
 	return func(c *Client) {
 		if apiKey != "" {
@@ -70,14 +115,69 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 	}
 }
 
-// WithMaxRetries sets the maximum number of retries (no-op, kept for API compatibility)
+// WithMaxRetries sets the maximum number of retries for transient failures (default: 3, 0 disables).
+// It tunes the default BackoffPolicy and has no effect after WithRetryPolicy.
 func WithMaxRetries(maxRetries int) ClientOption {
-	return func(c *Client) {}
+	return func(c *Client) {
+		if p, ok := c.retryPolicy.(*BackoffPolicy); ok {
+			p.MaxRetries = maxRetries
+		}
+	}
 }
 
-// WithRetryDelay sets the delay between retries (no-op, kept for API compatibility)
+// WithRetryDelay sets the initial backoff delay, doubled on each retry (default: 200ms).
+// It tunes the default BackoffPolicy and has no effect after WithRetryPolicy.
 func WithRetryDelay(delay time.Duration) ClientOption {
-	return func(c *Client) {}
+	return func(c *Client) {
+		if p, ok := c.retryPolicy.(*BackoffPolicy); ok {
+			p.BaseDelay = delay
+		}
+	}
+}
+
+// WithRetryPolicy replaces the default BackoffPolicy with a custom policy (nil disables retries)
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// evaluationObserver is told about every successful evaluation, with the caller's context
+type evaluationObserver func(ctx context.Context, entityID string, result *EvaluationResult)
+
+// observeEvaluations registers fn to be called with every successful evaluation
+// until the returned unregister func is called
+func (c *Client) observeEvaluations(fn evaluationObserver) (unregister func()) {
+	observer := &fn
+	c.observerMu.Lock()
+	defer c.observerMu.Unlock()
+	c.observers = append(c.observers, observer)
+	return func() {
+		c.observerMu.Lock()
+		defer c.observerMu.Unlock()
+		for i, o := range c.observers {
+			if o == observer {
+				c.observers = append(c.observers[:i:i], c.observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notifyEvaluation calls the evaluation observers. entityID falls back to the one
+// the server echoes in the result's evaluation context.
+func (c *Client) notifyEvaluation(ctx context.Context, entityID string, result *EvaluationResult) {
+	c.observerMu.RLock()
+	defer c.observerMu.RUnlock()
+	if len(c.observers) == 0 || result == nil || result.EvalResult == nil {
+		return
+	}
+	if entityID == "" && result.EvalContext != nil && result.EvalContext.EntityID != nil {
+		entityID = *result.EvalContext.EntityID
+	}
+	for _, fn := range c.observers {
+		(*fn)(ctx, entityID, result)
+	}
 }
 
 // toEvaluationResult converts api.EvalResult to EvaluationResult
@@ -117,32 +217,48 @@ func evalContextToAPI(ec *EvaluationContext) api.EvalContext {
 	return *ctx
 }
 
-// convertError converts api errors to our error types
-func convertError(err error, context string) error {
+// convertError converts api errors to our error types. resp may be nil.
+func convertError(resp *http.Response, err error, context string) error {
 	if err == nil {
 		return nil
 	}
-	openAPIErr, ok := err.(*api.GenericOpenAPIError)
-	if !ok {
-		return NewNetworkError(context+": "+err.Error(), err)
+	if resp == nil {
+		return NewNetworkError(context, err)
 	}
-	if strings.Contains(openAPIErr.Error(), "404") {
-		return NewFlagNotFoundError(context+": "+openAPIErr.Error(), err)
+	if resp.StatusCode < http.StatusMultipleChoices {
+		// Successful status but the body could not be decoded
+		return NewEvaluationError(context, err)
 	}
-	return NewEvaluationError(context+": "+openAPIErr.Error(), err)
+	apiErr := newAPIError(resp, err)
+	if resp.StatusCode == http.StatusNotFound {
+		return NewFlagNotFoundError(context, apiErr)
+	}
+	return NewEvaluationError(context, apiErr)
 }
 
 // Evaluate evaluates a single flag
 func (c *Client) Evaluate(ctx context.Context, evalCtx *EvaluationContext) (*EvaluationResult, error) {
 	apiCtx := evalContextToAPI(evalCtx)
-	result, resp, err := c.apiClient.EvaluationAPI.PostEvaluation(ctx).EvalContext(apiCtx).Execute()
-	if err != nil {
-		if resp != nil && resp.StatusCode == 404 {
-			return nil, NewFlagNotFoundError("flag not found", err)
+	var result *api.EvalResult
+	err := c.guard(func() error {
+		var resp *http.Response
+		var err error
+		result, resp, err = c.apiClient.EvaluationAPI.PostEvaluation(ctx).EvalContext(apiCtx).Execute()
+		if err != nil {
+			return convertError(resp, err, "evaluation failed")
 		}
-		return nil, convertError(err, "evaluation failed")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	evalResult := toEvaluationResult(result)
+	var entityID string
+	if evalCtx != nil && evalCtx.EntityID != nil {
+		entityID = *evalCtx.EntityID
 	}
-	return toEvaluationResult(result), nil
+	c.notifyEvaluation(ctx, entityID, evalResult)
+	return evalResult, nil
 }
 
 // EvaluateBatch evaluates multiple flags for multiple entities
@@ -170,13 +286,23 @@ func (c *Client) EvaluateBatch(ctx context.Context, req *BatchEvaluationRequest)
 		EnableDebug: api.PtrBool(req.EnableDebug),
 	}
 	apiReqPtr := &apiReq
-	result, _, err := c.apiClient.EvaluationAPI.PostEvaluationBatch(ctx).EvaluationBatchRequest(*apiReqPtr).Execute()
+	var result *api.EvaluationBatchResponse
+	err := c.guard(func() error {
+		var resp *http.Response
+		var err error
+		result, resp, err = c.apiClient.EvaluationAPI.PostEvaluationBatch(ctx).EvaluationBatchRequest(*apiReqPtr).Execute()
+		if err != nil {
+			return convertError(resp, err, "batch evaluation failed")
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, convertError(err, "batch evaluation failed")
+		return nil, err
 	}
 	results := make([]*EvaluationResult, len(result.EvaluationResults))
 	for i := range result.EvaluationResults {
 		results[i] = toEvaluationResult(&result.EvaluationResults[i])
+		c.notifyEvaluation(ctx, "", results[i])
 	}
 	return results, nil
 }
@@ -185,10 +311,7 @@ func (c *Client) EvaluateBatch(ctx context.Context, req *BatchEvaluationRequest)
 func (c *Client) GetFlag(ctx context.Context, flagID int64) (*Flag, error) {
 	flag, resp, err := c.apiClient.FlagAPI.GetFlag(ctx, flagID).Execute()
 	if err != nil {
-		if resp != nil && resp.StatusCode == 404 {
-			return nil, NewFlagNotFoundError(fmt.Sprintf("flag %d not found", flagID), err)
-		}
-		return nil, convertError(err, "failed to get flag")
+		return nil, convertError(resp, err, fmt.Sprintf("failed to get flag %d", flagID))
 	}
 	return flag, nil
 }
@@ -199,6 +322,17 @@ type ListFlagsOptions struct {
 	Offset  int
 	Enabled *bool
 	Preload bool
+
+	// Key matches the flag key exactly
+	Key string
+	// Description matches the description exactly
+	Description string
+	// DescriptionLike matches descriptions containing the given text
+	DescriptionLike string
+	// Tags matches flags with any of the given tag values
+	Tags []string
+	// Deleted lists archived flags instead of active ones
+	Deleted bool
 }
 
 // ListFlags retrieves a list of flags
@@ -213,18 +347,42 @@ func (c *Client) ListFlags(ctx context.Context, opts *ListFlagsOptions) ([]Flag,
 	if opts.Enabled != nil {
 		req = req.Enabled(*opts.Enabled)
 	}
-	flags, _, err := req.Execute()
+	if opts.Key != "" {
+		req = req.Key(opts.Key)
+	}
+	if opts.Description != "" {
+		req = req.Description(opts.Description)
+	}
+	if opts.DescriptionLike != "" {
+		req = req.DescriptionLike(opts.DescriptionLike)
+	}
+	if len(opts.Tags) > 0 {
+		req = req.Tags(strings.Join(opts.Tags, ","))
+	}
+	if opts.Deleted {
+		req = req.Deleted(true)
+	}
+	flags, resp, err := req.Execute()
 	if err != nil {
-		return nil, convertError(err, "failed to list flags")
+		return nil, convertError(resp, err, "failed to list flags")
 	}
 	return flags, nil
 }
 
 // GetSnapshot retrieves a snapshot for client-side evaluation
 func (c *Client) GetSnapshot(ctx context.Context) (*FlagSnapshot, error) {
-	result, _, err := c.apiClient.ExportAPI.GetExportEvalCacheJSON(ctx).Execute()
+	var result map[string]interface{}
+	err := c.guard(func() error {
+		var resp *http.Response
+		var err error
+		result, resp, err = c.apiClient.ExportAPI.GetExportEvalCacheJSON(ctx).Execute()
+		if err != nil {
+			return convertError(resp, err, "failed to get snapshot")
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, convertError(err, "failed to get snapshot")
+		return nil, err
 	}
 	// API returns map[string]interface{}, unmarshal to FlagSnapshot
 	jsonBytes, err := json.Marshal(result)
@@ -238,11 +396,40 @@ func (c *Client) GetSnapshot(ctx context.Context) (*FlagSnapshot, error) {
 	return &snapshot, nil
 }
 
+// ExportSQLite downloads the database as a SQLite file (GET /export/sqlite).
+// excludeSnapshots leaves out flag history, which is not needed for evaluation.
+func (c *Client) ExportSQLite(ctx context.Context, excludeSnapshots bool) ([]byte, error) {
+	var file *os.File
+	err := c.guard(func() error {
+		var resp *http.Response
+		var err error
+		file, resp, err = c.apiClient.ExportAPI.GetExportSQLite(ctx).ExcludeSnapshots(excludeSnapshots).Execute()
+		if err != nil {
+			return convertError(resp, err, "failed to export SQLite")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, NewNetworkError("empty SQLite export", nil)
+	}
+	// The generated client buffers the body into a temp file; read it and clean up
+	defer os.Remove(file.Name())
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, NewNetworkError("failed to read SQLite export: "+err.Error(), err)
+	}
+	return data, nil
+}
+
 // HealthCheck checks server health
 func (c *Client) HealthCheck(ctx context.Context) (*Health, error) {
-	health, _, err := c.apiClient.HealthAPI.GetHealth(ctx).Execute()
+	health, resp, err := c.apiClient.HealthAPI.GetHealth(ctx).Execute()
 	if err != nil {
-		return nil, convertError(err, "health check failed")
+		return nil, convertError(resp, err, "health check failed")
 	}
 	return health, nil
 }
@@ -3,6 +3,7 @@ package flagent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -292,6 +293,58 @@ func TestListFlags(t *testing.T) {
 }
 
 func TestConvertErrorAndEvaluatePaths(t *testing.T) {
+	t.Run("API error carries status, message and request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"invalid api key"}`))
+		}))
+		defer server.Close()
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+		_, err = client.ListFlags(context.Background(), nil)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+		assert.False(t, errors.Is(err, ErrServerUnavailable))
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+		assert.Equal(t, "invalid api key", apiErr.Message)
+		assert.Equal(t, http.MethodGet, apiErr.Method)
+		assert.Equal(t, "/flags", apiErr.Path)
+		assert.False(t, apiErr.Retryable)
+	})
+
+	t.Run("404 matches ErrFlagNotFound", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"flag 7 not found"}`))
+		}))
+		defer server.Close()
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+		_, err = client.GetFlag(context.Background(), 7)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrFlagNotFound))
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "flag 7 not found", apiErr.Message)
+	})
+
+	t.Run("server outage matches ErrServerUnavailable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		client, err := NewClient(server.URL, WithMaxRetries(0))
+		require.NoError(t, err)
+		_, err = client.HealthCheck(context.Background())
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrServerUnavailable))
+		assert.True(t, IsRetryable(err))
+	})
+
 	t.Run("Evaluate network error returns NetworkError", func(t *testing.T) {
 		// Use unreachable host to trigger non-GenericOpenAPIError (connection refused etc)
 		client, err := NewClient("http://127.0.0.1:19999", WithTimeout(time.Millisecond))
@@ -299,6 +352,7 @@ func TestConvertErrorAndEvaluatePaths(t *testing.T) {
 		_, err = client.Evaluate(context.Background(), &EvaluationContext{FlagKey: stringPtr("f1")})
 		require.Error(t, err)
 		assert.IsType(t, &NetworkError{}, err)
+		assert.True(t, errors.Is(err, ErrServerUnavailable))
 	})
 }
 
@@ -363,6 +417,35 @@ func TestGetSnapshot(t *testing.T) {
 	})
 }
 
+func TestExportSQLite(t *testing.T) {
+	t.Run("returns file contents", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/export/sqlite", r.URL.Path)
+			assert.Equal(t, "true", r.URL.Query().Get("exclude_snapshots"))
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("SQLite format 3\x00..."))
+		}))
+		defer server.Close()
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+		data, err := client.ExportSQLite(context.Background(), true)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("SQLite format 3\x00..."), data)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+		_, err = client.ExportSQLite(context.Background(), false)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrServerUnavailable))
+	})
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
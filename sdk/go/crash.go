@@ -0,0 +1,407 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCrashPlatform      = "go"
+	defaultCrashBatchSize     = 20
+	defaultCrashFlushInterval = 5 * time.Second
+	defaultCrashMaxQueueSize  = 1000
+	defaultCrashFlushTimeout  = 5 * time.Second
+	crashMaxRetries           = 3
+	crashRetryDelay           = time.Second
+)
+
+// ErrCrashReporterClosed is reported to OnError for panics recovered after Close
+var ErrCrashReporterClosed = errors.New("crash reporter is closed")
+
+// CrashReporterConfig configures a CrashReporter. Zero fields take the defaults.
+type CrashReporterConfig struct {
+	// Platform is reported with every crash (default: "go")
+	Platform string
+
+	// AppVersion defaults to the main module version from the build info
+	AppVersion string
+
+	// BatchSize is the number of crashes sent per request (default: 20)
+	BatchSize int
+
+	// FlushInterval is how often queued crashes are sent (default: 5s)
+	FlushInterval time.Duration
+
+	// MaxQueueSize bounds the queue; when it is full the oldest crashes are dropped (default: 1000)
+	MaxQueueSize int
+
+	// Repanic re-raises recovered panics after reporting them, so the process still
+	// crashes. The report is sent first, waiting up to FlushTimeout.
+	Repanic bool
+
+	// FlushTimeout bounds the synchronous send before a re-panic (default: 5s)
+	FlushTimeout time.Duration
+
+	// OnError is called when crashes cannot be sent or are dropped, including
+	// crashes recovered after Close (ErrCrashReporterClosed)
+	OnError func(err error)
+}
+
+// crashReport is the wire format of a crash
+type crashReport struct {
+	StackTrace     string   `json:"stackTrace"`
+	Message        string   `json:"message"`
+	Platform       string   `json:"platform"`
+	AppVersion     string   `json:"appVersion,omitempty"`
+	DeviceInfo     string   `json:"deviceInfo,omitempty"`
+	CustomKeys     string   `json:"customKeys,omitempty"`
+	ActiveFlagKeys []string `json:"activeFlagKeys,omitempty"`
+	Timestamp      int64    `json:"timestamp"`
+}
+
+// crashScope collects the flags evaluated while handling one request
+type crashScope struct {
+	mu       sync.Mutex
+	entityID string
+	variants map[string]string
+}
+
+type crashScopeKey struct{}
+
+// ContextWithCrashScope returns a context that collects the flags evaluated with it, so
+// a crash reported with it lists them. CrashReporter.Middleware does this for every
+// request; use it for work that does not come in over HTTP, such as queue consumers.
+func ContextWithCrashScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, crashScopeKey{}, &crashScope{variants: make(map[string]string)})
+}
+
+// RecordActiveFlag adds a flag variant to the crash scope of ctx, e.g. after a local
+// evaluation. Evaluations made with a Client that has a CrashReporter are recorded
+// automatically.
+func RecordActiveFlag(ctx context.Context, entityID, flagKey, variantKey string) {
+	scope, _ := ctx.Value(crashScopeKey{}).(*crashScope)
+	if scope == nil || flagKey == "" {
+		return
+	}
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if entityID != "" {
+		scope.entityID = entityID
+	}
+	scope.variants[flagKey] = variantKey
+}
+
+// CrashReporter recovers panics in HTTP handlers and goroutines and reports them to
+// /crashes/batch with their stack trace, build info and the flags the request was
+// evaluated for, so crash-by-flag dashboards can tie a panic to a rollout. Crashes
+// are sent in the background; call Close before exiting.
+type CrashReporter struct {
+	client     *Client
+	config     CrashReporterConfig
+	deviceInfo string
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	queue  []crashReport
+	closed bool
+
+	flushMu sync.Mutex
+	trigger chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	// unobserve detaches the reporter from the client's evaluations
+	unobserve func()
+}
+
+// NewCrashReporter creates a crash reporter that sends crashes with client and
+// records the flags client evaluates
+func NewCrashReporter(client *Client, config CrashReporterConfig) (*CrashReporter, error) {
+	if client == nil {
+		return nil, NewInvalidConfigError("client is required", nil)
+	}
+	buildInfo, hasBuildInfo := debug.ReadBuildInfo()
+	if config.Platform == "" {
+		config.Platform = defaultCrashPlatform
+	}
+	if config.AppVersion == "" && hasBuildInfo && buildInfo.Main.Version != "(devel)" {
+		config.AppVersion = buildInfo.Main.Version
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultCrashBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultCrashFlushInterval
+	}
+	if config.MaxQueueSize <= 0 {
+		config.MaxQueueSize = defaultCrashMaxQueueSize
+	}
+	if config.FlushTimeout <= 0 {
+		config.FlushTimeout = defaultCrashFlushTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &CrashReporter{
+		client:     client,
+		config:     config,
+		deviceInfo: crashDeviceInfo(buildInfo, hasBuildInfo),
+		retryDelay: crashRetryDelay,
+		trigger:    make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	r.unobserve = client.observeEvaluations(func(ctx context.Context, entityID string, result *EvaluationResult) {
+		if result.IsEnabled() {
+			RecordActiveFlag(ctx, entityID, result.GetFlagKey(), *result.VariantKey)
+		}
+	})
+	go r.run()
+	return r, nil
+}
+
+// Middleware recovers panics of next, reports them with the request's flags and
+// answers 500. http.ErrAbortHandler is passed through unreported.
+func (r *CrashReporter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := ContextWithCrashScope(req.Context())
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			r.capture(ctx, p, debug.Stack(), map[string]string{
+				"http.method": req.Method,
+				"http.path":   req.URL.Path,
+			})
+			if r.config.Repanic {
+				r.flushBeforeExit()
+				panic(p)
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// Go runs fn in a goroutine and reports a panic instead of crashing the process
+// (unless Repanic is set)
+func (r *CrashReporter) Go(fn func()) {
+	r.GoContext(context.Background(), func(context.Context) { fn() })
+}
+
+// GoContext is Go for work started by a request: a panic is reported with the flags
+// recorded in ctx's crash scope
+func (r *CrashReporter) GoContext(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer r.Recover(ctx)
+		fn(ctx)
+	}()
+}
+
+// Recover reports a panic of the calling goroutine. Use it directly with defer:
+//
+//	defer reporter.Recover(ctx)
+func (r *CrashReporter) Recover(ctx context.Context) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	r.capture(ctx, p, debug.Stack(), nil)
+	if r.config.Repanic {
+		r.flushBeforeExit()
+		panic(p)
+	}
+}
+
+// Flush sends all queued crashes and returns the first error
+func (r *CrashReporter) Flush(ctx context.Context) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	for {
+		r.mu.Lock()
+		n := len(r.queue)
+		if n > r.config.BatchSize {
+			n = r.config.BatchSize
+		}
+		batch := r.queue[:n:n]
+		r.queue = r.queue[n:]
+		r.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := postBatch(ctx, r.client, FeatureCrashReports, "/crashes/batch", "crash reports", batch, len(batch), crashMaxRetries, r.retryDelay)
+		var dropErr *batchDropError
+		if errors.As(err, &dropErr) {
+			r.report(err)
+			continue
+		}
+		if err != nil {
+			r.mu.Lock()
+			r.queue = append(batch, r.queue...)
+			dropped := r.trim()
+			r.mu.Unlock()
+			if dropped > 0 {
+				r.report(fmt.Errorf("crash queue is full, dropped %d oldest crashes", dropped))
+			}
+			return err
+		}
+	}
+}
+
+// Close stops the background flushes and sends the remaining crashes
+func (r *CrashReporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.mu.Unlock()
+
+	r.unobserve()
+	close(r.stop)
+	r.cancel()
+	<-r.done
+	return r.Flush(ctx)
+}
+
+// capture queues a crash report for a recovered panic
+func (r *CrashReporter) capture(ctx context.Context, p interface{}, stack []byte, keys map[string]string) {
+	report := crashReport{
+		StackTrace: string(stack),
+		Message:    fmt.Sprintf("panic: %v", p),
+		Platform:   r.config.Platform,
+		AppVersion: r.config.AppVersion,
+		DeviceInfo: r.deviceInfo,
+		Timestamp:  time.Now().UnixMilli(),
+	}
+	custom := map[string]interface{}{}
+	for k, v := range keys {
+		custom[k] = v
+	}
+	if scope, _ := ctx.Value(crashScopeKey{}).(*crashScope); scope != nil {
+		scope.mu.Lock()
+		if scope.entityID != "" {
+			custom["entityID"] = scope.entityID
+		}
+		if len(scope.variants) > 0 {
+			variants := make(map[string]string, len(scope.variants))
+			for flagKey, variantKey := range scope.variants {
+				report.ActiveFlagKeys = append(report.ActiveFlagKeys, flagKey)
+				variants[flagKey] = variantKey
+			}
+			custom["variants"] = variants
+			sort.Strings(report.ActiveFlagKeys)
+		}
+		scope.mu.Unlock()
+	}
+	if len(custom) > 0 {
+		data, _ := json.Marshal(custom)
+		report.CustomKeys = string(data)
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		r.report(fmt.Errorf("%w: dropped crash %q", ErrCrashReporterClosed, report.Message))
+		return
+	}
+	r.queue = append(r.queue, report)
+	dropped := r.trim()
+	full := len(r.queue) >= r.config.BatchSize
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		r.report(fmt.Errorf("crash queue is full, dropped %d oldest crashes", dropped))
+	}
+	if full {
+		select {
+		case r.trigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// trim drops the oldest crashes beyond MaxQueueSize. Called with mu held.
+func (r *CrashReporter) trim() int {
+	over := len(r.queue) - r.config.MaxQueueSize
+	if over <= 0 {
+		return 0
+	}
+	r.queue = r.queue[over:]
+	return over
+}
+
+// flushBeforeExit sends queued crashes before a re-panic takes the process down
+func (r *CrashReporter) flushBeforeExit() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.FlushTimeout)
+	defer cancel()
+	if err := r.Flush(ctx); err != nil {
+		r.report(err)
+	}
+}
+
+// run flushes when a batch is full and every FlushInterval
+func (r *CrashReporter) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+		if err := r.Flush(r.ctx); err != nil && r.ctx.Err() == nil {
+			r.report(err)
+		}
+	}
+}
+
+func (r *CrashReporter) report(err error) {
+	if r.config.OnError != nil {
+		r.config.OnError(err)
+	}
+}
+
+// crashDeviceInfo describes the binary and host as JSON
+func crashDeviceInfo(buildInfo *debug.BuildInfo, ok bool) string {
+	info := map[string]string{
+		"goVersion": runtime.Version(),
+		"os":        runtime.GOOS,
+		"arch":      runtime.GOARCH,
+	}
+	if host, err := os.Hostname(); err == nil {
+		info["hostname"] = host
+	}
+	if ok {
+		info["module"] = buildInfo.Main.Path
+		info["moduleVersion"] = buildInfo.Main.Version
+		for _, s := range buildInfo.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				info[s.Key] = s.Value
+			}
+		}
+	}
+	data, _ := json.Marshal(info)
+	return string(data)
+}
@@ -0,0 +1,166 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCrashServer starts a server accepting crash report batches
+func newCrashServer(t *testing.T) *batchServer {
+	t.Helper()
+	return newBatchServer(t, "/crashes/batch", "", http.StatusCreated)
+}
+
+func newTestCrashReporter(t *testing.T, server *batchServer, config CrashReporterConfig) *CrashReporter {
+	t.Helper()
+	client := server.newClient(t)
+	if config.FlushInterval == 0 {
+		config.FlushInterval = time.Hour
+	}
+	reporter, err := NewCrashReporter(client, config)
+	require.NoError(t, err)
+	reporter.retryDelay = time.Millisecond
+	t.Cleanup(func() { reporter.Close(context.Background()) })
+	return reporter
+}
+
+func TestCrashReporterMiddleware(t *testing.T) {
+	server := newCrashServer(t)
+	reporter := newTestCrashReporter(t, server, CrashReporterConfig{AppVersion: "2.1.0"})
+
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := reporter.client.Evaluate(r.Context(), &EvaluationContext{FlagKey: stringPtr("checkout"), EntityID: stringPtr("user1")})
+		require.NoError(t, err)
+		RecordActiveFlag(r.Context(), "", "new_search", "on")
+		panic("boom")
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/checkout", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	require.NoError(t, reporter.Flush(context.Background()))
+	crashes := server.received()
+	require.Len(t, crashes, 1)
+	crash := crashes[0]
+	assert.Equal(t, "panic: boom", crash["message"])
+	assert.Equal(t, "go", crash["platform"])
+	assert.Equal(t, "2.1.0", crash["appVersion"])
+	assert.Contains(t, crash["stackTrace"], "crash_test.go")
+	assert.Equal(t, []interface{}{"checkout", "new_search"}, crash["activeFlagKeys"])
+	assert.Contains(t, crash["deviceInfo"], `"goVersion"`)
+
+	var custom map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(crash["customKeys"].(string)), &custom))
+	assert.Equal(t, "user1", custom["entityID"])
+	assert.Equal(t, map[string]interface{}{"checkout": "blue", "new_search": "on"}, custom["variants"])
+	assert.Equal(t, "/checkout", custom["http.path"])
+	assert.Equal(t, "POST", custom["http.method"])
+}
+
+func TestCrashReporterMiddlewareAbort(t *testing.T) {
+	server := newCrashServer(t)
+	reporter := newTestCrashReporter(t, server, CrashReporterConfig{})
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	require.NoError(t, reporter.Flush(context.Background()))
+	assert.Empty(t, server.received())
+}
+
+func TestCrashReporterGo(t *testing.T) {
+	server := newCrashServer(t)
+	reporter := newTestCrashReporter(t, server, CrashReporterConfig{BatchSize: 1})
+
+	ctx := ContextWithCrashScope(context.Background())
+	RecordActiveFlag(ctx, "user7", "checkout", "blue")
+	reporter.GoContext(ctx, func(ctx context.Context) {
+		var m map[string]int
+		m["x"] = 1
+	})
+	reporter.Go(func() { panic("plain") })
+
+	// A full batch is sent in the background
+	assert.Eventually(t, func() bool { return len(server.received()) == 2 }, 2*time.Second, 5*time.Millisecond)
+	messages := map[interface{}]map[string]interface{}{}
+	for _, c := range server.received() {
+		messages[c["message"]] = c
+	}
+	require.Contains(t, messages, "panic: assignment to entry in nil map")
+	assert.Equal(t, []interface{}{"checkout"}, messages["panic: assignment to entry in nil map"]["activeFlagKeys"])
+	require.Contains(t, messages, "panic: plain")
+	assert.NotContains(t, messages["panic: plain"], "activeFlagKeys")
+}
+
+func TestCrashReporterRepanic(t *testing.T) {
+	server := newCrashServer(t)
+	reporter := newTestCrashReporter(t, server, CrashReporterConfig{Repanic: true})
+	assert.PanicsWithValue(t, "fatal", func() {
+		defer reporter.Recover(context.Background())
+		panic("fatal")
+	})
+	assert.Len(t, server.received(), 1, "the crash is sent before re-panicking")
+}
+
+func TestCrashReporterKeepsCrashesWhileServerIsDown(t *testing.T) {
+	server := newCrashServer(t)
+	var down atomic.Bool
+	down.Store(true)
+	server.status = func(int32) int {
+		if down.Load() {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusCreated
+	}
+	reporter := newTestCrashReporter(t, server, CrashReporterConfig{})
+	func() {
+		defer reporter.Recover(context.Background())
+		panic("boom")
+	}()
+
+	require.Error(t, reporter.Flush(context.Background()))
+	down.Store(false)
+	require.NoError(t, reporter.Flush(context.Background()))
+	assert.Len(t, server.received(), 1)
+}
+
+func TestCrashReporterCloseDetaches(t *testing.T) {
+	server := newCrashServer(t)
+	reporter := newTestCrashReporter(t, server, CrashReporterConfig{})
+	require.NoError(t, reporter.Close(context.Background()))
+
+	ctx := ContextWithCrashScope(context.Background())
+	_, err := reporter.client.Evaluate(ctx, &EvaluationContext{FlagKey: stringPtr("checkout"), EntityID: stringPtr("user1")})
+	require.NoError(t, err)
+	scope := ctx.Value(crashScopeKey{}).(*crashScope)
+	assert.Empty(t, scope.variants, "evaluations after Close are not recorded")
+	assert.Empty(t, reporter.client.observers)
+}
+
+func TestCrashReporterCloseRejectsCrashes(t *testing.T) {
+	server := newCrashServer(t)
+	var reported []error
+	reporter := newTestCrashReporter(t, server, CrashReporterConfig{OnError: func(err error) { reported = append(reported, err) }})
+	require.NoError(t, reporter.Close(context.Background()))
+
+	func() {
+		defer reporter.Recover(context.Background())
+		panic("after close")
+	}()
+	require.Len(t, reported, 1)
+	assert.True(t, errors.Is(reported[0], ErrCrashReporterClosed))
+	assert.Contains(t, reported[0].Error(), "panic: after close")
+	assert.Empty(t, reporter.queue)
+	assert.Empty(t, server.received())
+}
@@ -1,6 +1,31 @@
 package flagent
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+)
+
+// Sentinel errors for use with errors.Is. Errors returned by Client match the
+// sentinel for their HTTP status, e.g. errors.Is(err, ErrUnauthorized) for a 401.
+var (
+	ErrBadRequest         = errors.New("flagent: bad request")
+	ErrUnauthorized       = errors.New("flagent: unauthorized")
+	ErrForbidden          = errors.New("flagent: forbidden")
+	ErrNotFound           = errors.New("flagent: not found")
+	ErrFlagNotFound       = errors.New("flagent: flag not found")
+	ErrConflict           = errors.New("flagent: conflict")
+	ErrRateLimited        = errors.New("flagent: rate limited")
+	ErrServerUnavailable  = errors.New("flagent: server unavailable")
+	ErrCircuitOpen        = errors.New("flagent: circuit breaker is open")
+	ErrUnsupported        = errors.New("flagent: not supported by server")
+	ErrIncompatibleServer = errors.New("flagent: incompatible server version")
+)
 
 // FlagentError is the base error type for all Flagent errors
 type FlagentError struct {
@@ -24,6 +49,11 @@ type FlagNotFoundError struct {
 	FlagentError
 }
 
+// Is reports whether target is ErrFlagNotFound
+func (e *FlagNotFoundError) Is(target error) bool {
+	return target == ErrFlagNotFound
+}
+
 // EvaluationError indicates that flag evaluation failed
 type EvaluationError struct {
 	FlagentError
@@ -34,11 +64,57 @@ type NetworkError struct {
 	FlagentError
 }
 
+// Is reports whether target is ErrServerUnavailable; a cancelled context does not count
+func (e *NetworkError) Is(target error) bool {
+	return target == ErrServerUnavailable && !errors.Is(e.Err, context.Canceled)
+}
+
 // InvalidConfigError indicates invalid configuration
 type InvalidConfigError struct {
 	FlagentError
 }
 
+// NotFoundError indicates that a segment, constraint, variant or tag was not found
+type NotFoundError struct {
+	FlagentError
+}
+
+// RequestError indicates that the server rejected a management API request
+type RequestError struct {
+	FlagentError
+}
+
+// CircuitOpenError indicates that a call was rejected without contacting the server
+// because the circuit breaker is open
+type CircuitOpenError struct {
+	FlagentError
+}
+
+// Is reports whether target is ErrCircuitOpen
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// UnsupportedError indicates that the connected server lacks a feature
+type UnsupportedError struct {
+	FlagentError
+}
+
+// Is reports whether target is ErrUnsupported
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// IncompatibleServerError indicates that the SDK does not support the server's version
+type IncompatibleServerError struct {
+	FlagentError
+}
+
+// Is reports whether target is ErrIncompatibleServer
+func (e *IncompatibleServerError) Is(target error) bool {
+	return target == ErrIncompatibleServer
+}
+
 // NewFlagNotFoundError creates a new FlagNotFoundError
 func NewFlagNotFoundError(message string, err error) *FlagNotFoundError {
 	return &FlagNotFoundError{
@@ -66,3 +142,138 @@ func NewInvalidConfigError(message string, err error) *InvalidConfigError {
 		FlagentError: FlagentError{Message: message, Err: err},
 	}
 }
+
+// NewNotFoundError creates a new NotFoundError
+func NewNotFoundError(message string, err error) *NotFoundError {
+	return &NotFoundError{
+		FlagentError: FlagentError{Message: message, Err: err},
+	}
+}
+
+// NewRequestError creates a new RequestError
+func NewRequestError(message string, err error) *RequestError {
+	return &RequestError{
+		FlagentError: FlagentError{Message: message, Err: err},
+	}
+}
+
+// NewCircuitOpenError creates a new CircuitOpenError
+func NewCircuitOpenError(message string, err error) *CircuitOpenError {
+	return &CircuitOpenError{
+		FlagentError: FlagentError{Message: message, Err: err},
+	}
+}
+
+// NewUnsupportedError creates a new UnsupportedError
+func NewUnsupportedError(message string, err error) *UnsupportedError {
+	return &UnsupportedError{
+		FlagentError: FlagentError{Message: message, Err: err},
+	}
+}
+
+// NewIncompatibleServerError creates a new IncompatibleServerError
+func NewIncompatibleServerError(message string, err error) *IncompatibleServerError {
+	return &IncompatibleServerError{
+		FlagentError: FlagentError{Message: message, Err: err},
+	}
+}
+
+// APIError is an HTTP error response from the Flagent server. Client methods wrap it
+// in one of the error types above; retrieve it with errors.As.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Message is the message from the server's error body, if any
+	Message string
+
+	// Method and Path identify the request that failed
+	Method string
+	Path   string
+
+	// Retryable is true if the same request may succeed later (429, 502, 503, 504)
+	Retryable bool
+
+	// Body is the raw response body
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	if e.Method != "" {
+		fmt.Fprintf(&b, "%s %s: ", e.Method, e.Path)
+	}
+	fmt.Fprintf(&b, "%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	}
+	return b.String()
+}
+
+// Is matches the sentinel error for the status code
+func (e *APIError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return target == ErrBadRequest
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	}
+	return e.StatusCode >= http.StatusInternalServerError && target == ErrServerUnavailable
+}
+
+// newAPIError builds an APIError from a failed response and the generated client's error
+func newAPIError(resp *http.Response, err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var body []byte
+	var openAPIErr *api.GenericOpenAPIError
+	if errors.As(err, &openAPIErr) {
+		body = openAPIErr.Body()
+	}
+	return apiErrorFromBody(resp, body)
+}
+
+// apiErrorFromBody builds an APIError from a failed response and its raw body.
+// The server reports errors as {"message": "..."} or {"error": "..."}.
+func apiErrorFromBody(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Retryable:  IsRetryableResponse(resp, nil),
+		Body:       body,
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.Path = resp.Request.URL.Path
+	}
+	var decoded struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(body, &decoded) == nil {
+		apiErr.Message = decoded.Message
+		if apiErr.Message == "" {
+			apiErr.Message = decoded.Error
+		}
+	}
+	return apiErr
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying later
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable
+	}
+	var netErr *NetworkError
+	return errors.As(err, &netErr) && errors.Is(netErr, ErrServerUnavailable)
+}
@@ -1,7 +1,9 @@
 package flagent
 
 import (
+	"context"
 	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,3 +42,49 @@ func TestErrorTypes(t *testing.T) {
 		assert.True(t, errors.Is(err, inner))
 	})
 }
+
+func TestAPIError(t *testing.T) {
+	cases := []struct {
+		status   int
+		sentinel error
+	}{
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusInternalServerError, ErrServerUnavailable},
+		{http.StatusServiceUnavailable, ErrServerUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			err := NewEvaluationError("wrapper", &APIError{StatusCode: tc.status})
+			assert.True(t, errors.Is(err, tc.sentinel))
+			assert.False(t, errors.Is(err, ErrFlagNotFound))
+		})
+	}
+
+	t.Run("Error includes request and message", func(t *testing.T) {
+		err := &APIError{StatusCode: 401, Method: "GET", Path: "/api/v1/flags", Message: "invalid api key"}
+		assert.Equal(t, "GET /api/v1/flags: 401 Unauthorized: invalid api key", err.Error())
+	})
+
+	t.Run("FlagNotFoundError matches ErrFlagNotFound and ErrNotFound", func(t *testing.T) {
+		err := NewFlagNotFoundError("missing", &APIError{StatusCode: http.StatusNotFound})
+		assert.True(t, errors.Is(err, ErrFlagNotFound))
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("NetworkError matches ErrServerUnavailable unless cancelled", func(t *testing.T) {
+		assert.True(t, errors.Is(NewNetworkError("refused", errors.New("dial tcp")), ErrServerUnavailable))
+		assert.False(t, errors.Is(NewNetworkError("cancelled", context.Canceled), ErrServerUnavailable))
+	})
+
+	t.Run("IsRetryable", func(t *testing.T) {
+		assert.True(t, IsRetryable(NewEvaluationError("x", &APIError{StatusCode: 503, Retryable: true})))
+		assert.False(t, IsRetryable(NewEvaluationError("x", &APIError{StatusCode: 401})))
+		assert.True(t, IsRetryable(NewNetworkError("x", errors.New("connection reset"))))
+		assert.False(t, IsRetryable(errors.New("other")))
+	})
+}
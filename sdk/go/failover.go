@@ -0,0 +1,437 @@
+package flagent
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+)
+
+// Endpoint is a Flagent server the client can send requests to
+type Endpoint struct {
+	// URL is the API base URL, e.g. "https://eu.flagent.example.com/api/v1"
+	URL string
+
+	// Weight is the endpoint's relative share of traffic. When all weights are zero,
+	// endpoints are used in order: the first healthy one gets all requests. When some
+	// are weighted, traffic is spread over the healthy weighted endpoints and
+	// zero-weight endpoints are only used as backups.
+	Weight int
+}
+
+// FailoverConfig configures multi-endpoint failover
+type FailoverConfig struct {
+	// Endpoints lists the servers to use. The base URL passed to NewClient is the
+	// first endpoint unless it is listed here.
+	Endpoints []Endpoint
+
+	// FailureThreshold is the number of consecutive connection errors or 5xx responses
+	// after which an endpoint is marked unhealthy (default: 1)
+	FailureThreshold int
+
+	// HealthCheckInterval is how often an unhealthy endpoint is probed at /health
+	// until it recovers (default: 10s)
+	HealthCheckInterval time.Duration
+
+	// HedgeDelay enables hedged evaluations: when Evaluate or EvaluateBatch has not
+	// been answered within HedgeDelay, the same request is sent to the next endpoint
+	// and the first successful response wins (default: 0, no hedging)
+	HedgeDelay time.Duration
+
+	// OnHealthChange is called when an endpoint is marked healthy or unhealthy
+	OnHealthChange func(endpoint string, healthy bool)
+}
+
+// WithFailover sends requests to several endpoints, failing over to the next healthy
+// one on connection errors and 5xx responses. Requests that are not safe to repeat
+// (see IsRetryableRequest) are only sent once.
+func WithFailover(config FailoverConfig) ClientOption {
+	return func(c *Client) {
+		c.failover = &config
+	}
+}
+
+// endpoint is an Endpoint with its health
+type endpoint struct {
+	url    string
+	weight int
+
+	healthy  bool
+	failures int
+	// checkAt is when an unhealthy endpoint is probed next
+	checkAt time.Time
+	probing bool
+}
+
+// endpointPool tracks the health of the configured endpoints and picks one per request
+type endpointPool struct {
+	// baseURL is the URL request URLs are built from; it is replaced by the chosen endpoint's
+	baseURL   string
+	endpoints []*endpoint
+	weighted  bool
+	config    FailoverConfig
+	// probe sends /health requests
+	probe http.RoundTripper
+	now   func() time.Time
+
+	mu sync.Mutex
+}
+
+// newEndpointPool builds the pool for baseURL and config
+func newEndpointPool(baseURL string, config FailoverConfig) (*endpointPool, error) {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 1
+	}
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = defaultHealthCheckInterval
+	}
+
+	pool := &endpointPool{baseURL: baseURL, config: config, now: time.Now}
+	listed := false
+	for _, e := range config.Endpoints {
+		u := strings.TrimSuffix(strings.TrimSpace(e.URL), "/")
+		if u == "" {
+			return nil, NewInvalidConfigError("failover endpoint URL is required", nil)
+		}
+		if e.Weight < 0 {
+			return nil, NewInvalidConfigError("failover endpoint weight must not be negative", nil)
+		}
+		listed = listed || u == baseURL
+		pool.weighted = pool.weighted || e.Weight > 0
+		pool.endpoints = append(pool.endpoints, &endpoint{url: u, weight: e.Weight, healthy: true})
+	}
+	if !listed {
+		pool.endpoints = append([]*endpoint{{url: baseURL, healthy: true}}, pool.endpoints...)
+	}
+	return pool, nil
+}
+
+// candidates returns the endpoints to try for one request, preferred first. Unhealthy
+// endpoints come last, so a request is still attempted when all of them are down.
+func (p *endpointPool) candidates() []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var healthy, unhealthy []*endpoint
+	for _, e := range p.endpoints {
+		if e.healthy {
+			healthy = append(healthy, e)
+			continue
+		}
+		unhealthy = append(unhealthy, e)
+		if !e.probing && !now.Before(e.checkAt) {
+			e.probing = true
+			go p.checkHealth(e)
+		}
+	}
+
+	if !p.weighted {
+		return append(healthy, unhealthy...)
+	}
+
+	// Weighted: a random weighted endpoint first, then the other weighted ones,
+	// then the zero-weight backups, each in configured order
+	var weighted, backups []*endpoint
+	for _, e := range healthy {
+		if e.weight > 0 {
+			weighted = append(weighted, e)
+		} else {
+			backups = append(backups, e)
+		}
+	}
+	ordered := make([]*endpoint, 0, len(p.endpoints))
+	if first := pickWeighted(weighted); first >= 0 {
+		ordered = append(ordered, weighted[first])
+		weighted = append(weighted[:first:first], weighted[first+1:]...)
+	}
+	ordered = append(ordered, weighted...)
+	ordered = append(ordered, backups...)
+	return append(ordered, unhealthy...)
+}
+
+// pickWeighted returns the index of a weighted random endpoint, or -1 if none has a weight
+func pickWeighted(endpoints []*endpoint) int {
+	total := 0
+	for _, e := range endpoints {
+		total += e.weight
+	}
+	if total == 0 {
+		return -1
+	}
+	n := rand.Intn(total)
+	for i, e := range endpoints {
+		if n < e.weight {
+			return i
+		}
+		n -= e.weight
+	}
+	return -1
+}
+
+// markSuccess records a successful request
+func (p *endpointPool) markSuccess(e *endpoint) {
+	p.setHealth(e, true)
+}
+
+// markFailure records a connection error or 5xx response
+func (p *endpointPool) markFailure(e *endpoint) {
+	p.mu.Lock()
+	e.failures++
+	failed := e.failures >= p.config.FailureThreshold
+	p.mu.Unlock()
+	if failed {
+		p.setHealth(e, false)
+	}
+}
+
+// setHealth updates the health of e and reports changes
+func (p *endpointPool) setHealth(e *endpoint, healthy bool) {
+	p.mu.Lock()
+	changed := e.healthy != healthy
+	e.healthy = healthy
+	if healthy {
+		e.failures = 0
+	} else {
+		e.checkAt = p.now().Add(p.config.HealthCheckInterval)
+	}
+	p.mu.Unlock()
+
+	if changed && p.config.OnHealthChange != nil {
+		p.config.OnHealthChange(e.url, healthy)
+	}
+}
+
+// checkHealth probes an unhealthy endpoint at /health
+func (p *endpointPool) checkHealth(e *endpoint) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	healthy := false
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.url+"/health", nil)
+	if err == nil {
+		req.Header.Set("Accept", "application/json")
+		resp, err := p.probe.RoundTrip(req)
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			healthy = resp.StatusCode == http.StatusOK
+		}
+	}
+
+	p.setHealth(e, healthy)
+	p.mu.Lock()
+	e.probing = false
+	p.mu.Unlock()
+}
+
+// failoverTransport sends each request to the best endpoint and fails over to the others
+type failoverTransport struct {
+	next http.RoundTripper
+	pool *endpointPool
+}
+
+// withFailover returns a copy of httpClient whose transport fails over between endpoints
+func withFailover(httpClient *http.Client, pool *endpointPool) *http.Client {
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	pool.probe = next
+	wrapped := *httpClient
+	wrapped.Transport = &failoverTransport{next: next, pool: pool}
+	return &wrapped
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.String(), t.pool.baseURL) {
+		return t.next.RoundTrip(req)
+	}
+	candidates := t.pool.candidates()
+	if !IsRetryableRequest(req) {
+		candidates = candidates[:1]
+	}
+	if t.pool.config.HedgeDelay > 0 && len(candidates) > 1 && isEvaluationRequest(req) {
+		return t.hedge(req, candidates)
+	}
+
+	var resp *http.Response
+	var err error
+	for i, e := range candidates {
+		if i > 0 {
+			discard(resp)
+		}
+		attempt, rewriteErr := t.rewrite(req.Context(), req, e, i > 0)
+		if rewriteErr != nil {
+			return nil, rewriteErr
+		}
+		resp, err = t.next.RoundTrip(attempt)
+		if !endpointFailed(resp, err) {
+			t.pool.markSuccess(e)
+			return resp, err
+		}
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+		t.pool.markFailure(e)
+	}
+	return resp, err
+}
+
+// hedgeResult is the outcome of one hedged attempt
+type hedgeResult struct {
+	attempt  int
+	endpoint *endpoint
+	resp     *http.Response
+	err      error
+}
+
+// hedge sends req to the first candidate and, if it has not answered within HedgeDelay,
+// to the second one as well. Failed attempts fail over to the remaining candidates.
+// The first successful response wins and the other attempts are cancelled.
+func (t *failoverTransport) hedge(req *http.Request, candidates []*endpoint) (*http.Response, error) {
+	ctx := req.Context()
+	results := make(chan hedgeResult, len(candidates))
+	var cancels []context.CancelFunc
+	launch := func() error {
+		n := len(cancels)
+		e := candidates[n]
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		attempt, err := t.rewrite(attemptCtx, req, e, true)
+		if err != nil {
+			return err
+		}
+		go func() {
+			resp, err := t.next.RoundTrip(attempt)
+			results <- hedgeResult{attempt: n, endpoint: e, resp: resp, err: err}
+		}()
+		return nil
+	}
+	// cancelOthers cancels every attempt but the given one
+	cancelOthers := func(keep int) {
+		for i, cancel := range cancels {
+			if i != keep {
+				cancel()
+			}
+		}
+	}
+
+	if err := launch(); err != nil {
+		cancelOthers(-1)
+		return nil, err
+	}
+	inflight := 1
+	timer := time.NewTimer(t.pool.config.HedgeDelay)
+	defer timer.Stop()
+
+	var last *hedgeResult
+	for inflight > 0 {
+		select {
+		case <-timer.C:
+			// Hedge once; any further attempts are failovers
+			if len(cancels) == 1 && launch() == nil {
+				inflight++
+			}
+		case r := <-results:
+			inflight--
+			if !endpointFailed(r.resp, r.err) {
+				t.pool.markSuccess(r.endpoint)
+				cancelOthers(r.attempt)
+				go drainHedges(results, inflight)
+				r.resp.Body = &cancelOnClose{ReadCloser: r.resp.Body, cancel: cancels[r.attempt]}
+				return r.resp, nil
+			}
+			if ctx.Err() == nil {
+				t.pool.markFailure(r.endpoint)
+			}
+			if last != nil {
+				discard(last.resp)
+				cancels[last.attempt]()
+			}
+			last = &r
+			if ctx.Err() == nil && len(cancels) < len(candidates) && launch() == nil {
+				inflight++
+			}
+		}
+	}
+
+	// Every attempt failed: return the last outcome, keeping its body readable
+	if last.resp != nil {
+		last.resp.Body = &cancelOnClose{ReadCloser: last.resp.Body, cancel: cancels[last.attempt]}
+	} else {
+		cancels[last.attempt]()
+	}
+	return last.resp, last.err
+}
+
+// drainHedges closes the responses of hedged attempts that lost
+func drainHedges(results <-chan hedgeResult, inflight int) {
+	for ; inflight > 0; inflight-- {
+		r := <-results
+		discard(r.resp)
+	}
+}
+
+// discard drains and closes resp, if any
+func discard(resp *http.Response) {
+	if resp != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// cancelOnClose cancels an attempt's context once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the attempt's context
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// rewrite returns req addressed to endpoint e. replay gives the attempt a fresh body.
+func (t *failoverTransport) rewrite(ctx context.Context, req *http.Request, e *endpoint, replay bool) (*http.Request, error) {
+	target, err := url.Parse(e.url + strings.TrimPrefix(req.URL.String(), t.pool.baseURL))
+	if err != nil {
+		return nil, err
+	}
+	attempt := req.Clone(ctx)
+	attempt.URL = target
+	attempt.Host = ""
+	if replay && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attempt.Body = body
+	}
+	return attempt, nil
+}
+
+// endpointFailed reports whether an attempt points to an unhealthy endpoint:
+// a connection error or a 5xx response
+func endpointFailed(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// isEvaluationRequest reports whether req is an evaluation POST, the only requests that are hedged
+func isEvaluationRequest(req *http.Request) bool {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	return req.Method == http.MethodPost &&
+		(strings.HasSuffix(path, "/evaluation") || strings.HasSuffix(path, "/evaluation/batch"))
+}
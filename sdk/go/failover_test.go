@@ -0,0 +1,222 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// regionServer is a test server that answers evaluations with its own name as variant
+type regionServer struct {
+	*httptest.Server
+	calls   int32
+	status  int32
+	delay   time.Duration
+	healthy int32
+}
+
+func newRegionServer(t *testing.T, name string) *regionServer {
+	t.Helper()
+	s := &regionServer{status: http.StatusOK, healthy: 1}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			if atomic.LoadInt32(&s.healthy) == 1 {
+				w.Write([]byte(`{"status":"OK"}`))
+			} else {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		atomic.AddInt32(&s.calls, 1)
+		if s.delay > 0 {
+			select {
+			case <-time.After(s.delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if status := int(atomic.LoadInt32(&s.status)); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		result := api.EvalResult{}
+		result.SetFlagKey("f1")
+		result.VariantKey = *api.NewNullableString(&name)
+		json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func evaluateVariant(t *testing.T, client *Client) string {
+	t.Helper()
+	result, err := client.Evaluate(context.Background(), &EvaluationContext{FlagKey: stringPtr("f1")})
+	require.NoError(t, err)
+	return *result.VariantKey
+}
+
+func TestFailover(t *testing.T) {
+	t.Run("fails over on 5xx and remembers the unhealthy endpoint", func(t *testing.T) {
+		eu, us := newRegionServer(t, "eu"), newRegionServer(t, "us")
+		eu.status = http.StatusServiceUnavailable
+
+		var mu sync.Mutex
+		var changes []string
+		client, err := NewClient(eu.URL, WithMaxRetries(0), WithFailover(FailoverConfig{
+			Endpoints:           []Endpoint{{URL: us.URL}},
+			HealthCheckInterval: time.Hour,
+			OnHealthChange: func(endpoint string, healthy bool) {
+				mu.Lock()
+				defer mu.Unlock()
+				if endpoint == eu.URL && !healthy {
+					changes = append(changes, "eu down")
+				}
+			},
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "us", evaluateVariant(t, client))
+		assert.Equal(t, "us", evaluateVariant(t, client))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&eu.calls), "unhealthy endpoint is skipped")
+		assert.Equal(t, int32(2), atomic.LoadInt32(&us.calls))
+		mu.Lock()
+		assert.Equal(t, []string{"eu down"}, changes)
+		mu.Unlock()
+	})
+
+	t.Run("fails over on connection errors", func(t *testing.T) {
+		down := httptest.NewServer(http.NotFoundHandler())
+		down.Close()
+		us := newRegionServer(t, "us")
+
+		client, err := NewClient(down.URL, WithMaxRetries(0), WithFailover(FailoverConfig{
+			Endpoints: []Endpoint{{URL: us.URL}},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "us", evaluateVariant(t, client))
+	})
+
+	t.Run("recovered endpoint is used again after a health check", func(t *testing.T) {
+		eu, us := newRegionServer(t, "eu"), newRegionServer(t, "us")
+		eu.status = http.StatusBadGateway
+		atomic.StoreInt32(&eu.healthy, 0)
+
+		client, err := NewClient(eu.URL, WithMaxRetries(0), WithFailover(FailoverConfig{
+			Endpoints:           []Endpoint{{URL: us.URL}},
+			HealthCheckInterval: 10 * time.Millisecond,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "us", evaluateVariant(t, client))
+
+		atomic.StoreInt32(&eu.status, http.StatusOK)
+		atomic.StoreInt32(&eu.healthy, 1)
+		assert.Eventually(t, func() bool {
+			return evaluateVariant(t, client) == "eu"
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("weighted endpoints share traffic", func(t *testing.T) {
+		eu, us, backup := newRegionServer(t, "eu"), newRegionServer(t, "us"), newRegionServer(t, "backup")
+		client, err := NewClient(eu.URL, WithFailover(FailoverConfig{
+			Endpoints: []Endpoint{{URL: eu.URL, Weight: 3}, {URL: us.URL, Weight: 1}, {URL: backup.URL}},
+		}))
+		require.NoError(t, err)
+
+		for i := 0; i < 200; i++ {
+			evaluateVariant(t, client)
+		}
+		assert.Greater(t, atomic.LoadInt32(&eu.calls), atomic.LoadInt32(&us.calls))
+		assert.Greater(t, atomic.LoadInt32(&us.calls), int32(0))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&backup.calls), "zero-weight endpoints are backups")
+	})
+
+	t.Run("non-idempotent requests are not failed over", func(t *testing.T) {
+		eu, us := newRegionServer(t, "eu"), newRegionServer(t, "us")
+		eu.status = http.StatusServiceUnavailable
+
+		client, err := NewClient(eu.URL, WithMaxRetries(0), WithFailover(FailoverConfig{
+			Endpoints: []Endpoint{{URL: us.URL}},
+		}))
+		require.NoError(t, err)
+		_, err = client.CreateFlag(context.Background(), &CreateFlagInput{Key: "checkout"})
+		require.Error(t, err)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&us.calls))
+	})
+
+	t.Run("all endpoints down returns the last error", func(t *testing.T) {
+		eu, us := newRegionServer(t, "eu"), newRegionServer(t, "us")
+		eu.status = http.StatusServiceUnavailable
+		us.status = http.StatusBadGateway
+
+		client, err := NewClient(eu.URL, WithMaxRetries(0), WithFailover(FailoverConfig{
+			Endpoints: []Endpoint{{URL: us.URL}},
+		}))
+		require.NoError(t, err)
+		_, err = client.Evaluate(context.Background(), &EvaluationContext{FlagKey: stringPtr("f1")})
+		require.Error(t, err)
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	})
+
+	t.Run("invalid endpoint", func(t *testing.T) {
+		_, err := NewClient("http://localhost:18000/api/v1", WithFailover(FailoverConfig{
+			Endpoints: []Endpoint{{URL: " "}},
+		}))
+		var configErr *InvalidConfigError
+		assert.True(t, errors.As(err, &configErr))
+	})
+}
+
+func TestHedgedEvaluate(t *testing.T) {
+	t.Run("slow endpoint is hedged", func(t *testing.T) {
+		eu, us := newRegionServer(t, "eu"), newRegionServer(t, "us")
+		eu.delay = 2 * time.Second
+
+		client, err := NewClient(eu.URL, WithFailover(FailoverConfig{
+			Endpoints:  []Endpoint{{URL: us.URL}},
+			HedgeDelay: 20 * time.Millisecond,
+		}))
+		require.NoError(t, err)
+
+		start := time.Now()
+		assert.Equal(t, "us", evaluateVariant(t, client))
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&eu.calls))
+	})
+
+	t.Run("fast endpoint is not hedged", func(t *testing.T) {
+		eu, us := newRegionServer(t, "eu"), newRegionServer(t, "us")
+
+		client, err := NewClient(eu.URL, WithFailover(FailoverConfig{
+			Endpoints:  []Endpoint{{URL: us.URL}},
+			HedgeDelay: time.Second,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "eu", evaluateVariant(t, client))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&us.calls))
+	})
+
+	t.Run("failed attempt fails over without waiting", func(t *testing.T) {
+		eu, us := newRegionServer(t, "eu"), newRegionServer(t, "us")
+		eu.status = http.StatusServiceUnavailable
+
+		client, err := NewClient(eu.URL, WithMaxRetries(0), WithFailover(FailoverConfig{
+			Endpoints:  []Endpoint{{URL: us.URL}},
+			HedgeDelay: time.Minute,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "us", evaluateVariant(t, client))
+	})
+}
@@ -0,0 +1,85 @@
+package flagent
+
+import "context"
+
+const defaultPageSize = 100
+
+// FlagIterator walks all flags matching a ListFlagsOptions filter, fetching pages lazily.
+// Use it as:
+//
+//	it := client.IterateFlags(ctx, &flagent.ListFlagsOptions{Tags: []string{"payments"}})
+//	for it.Next() {
+//		flag := it.Flag()
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// Pagination is offset-based, so flags created or deleted during iteration may be
+// skipped or returned twice.
+type FlagIterator struct {
+	client *Client
+	ctx    context.Context
+	opts   ListFlagsOptions
+
+	page    []Flag
+	index   int
+	current Flag
+	done    bool
+	err     error
+}
+
+// IterateFlags returns an iterator over all flags matching opts. opts.Limit is the
+// page size (default: 100) and opts.Offset the starting offset.
+func (c *Client) IterateFlags(ctx context.Context, opts *ListFlagsOptions) *FlagIterator {
+	it := &FlagIterator{client: c, ctx: ctx}
+	if opts != nil {
+		it.opts = *opts
+	} else {
+		it.opts.Preload = true
+	}
+	if it.opts.Limit <= 0 {
+		it.opts.Limit = defaultPageSize
+	}
+	return it
+}
+
+// Next advances to the next flag. It returns false when all flags have been read,
+// on error, or when the context is cancelled; check Err afterwards.
+func (it *FlagIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	if it.index >= len(it.page) {
+		if it.done {
+			return false
+		}
+		page, err := it.client.ListFlags(it.ctx, &it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page
+		it.index = 0
+		it.opts.Offset += len(page)
+		it.done = len(page) < it.opts.Limit
+		if len(page) == 0 {
+			return false
+		}
+	}
+	it.current = it.page[it.index]
+	it.index++
+	return true
+}
+
+// Flag returns the current flag
+func (it *FlagIterator) Flag() Flag {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *FlagIterator) Err() error {
+	return it.err
+}
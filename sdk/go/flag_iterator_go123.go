@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package flagent
+
+import (
+	"context"
+	"iter"
+)
+
+// AllFlags returns a range-over-func iterator over all flags matching opts
+// (see IterateFlags). An error is yielded once, as the last element.
+//
+//	for flag, err := range client.AllFlags(ctx, nil) {
+//		if err != nil { ... }
+//	}
+func (c *Client) AllFlags(ctx context.Context, opts *ListFlagsOptions) iter.Seq2[Flag, error] {
+	return func(yield func(Flag, error) bool) {
+		it := c.IterateFlags(ctx, opts)
+		for it.Next() {
+			if !yield(it.Flag(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield(Flag{}, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+//go:build go1.23
+
+package flagent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllFlags(t *testing.T) {
+	t.Run("ranges over all pages", func(t *testing.T) {
+		var requests int32
+		server := newPagingServer(t, 15, &requests)
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+
+		count := 0
+		for flag, err := range client.AllFlags(context.Background(), &ListFlagsOptions{Limit: 4}) {
+			require.NoError(t, err)
+			count++
+			assert.Equal(t, int64(count), flag.Id)
+		}
+		assert.Equal(t, 15, count)
+	})
+
+	t.Run("break stops fetching", func(t *testing.T) {
+		var requests int32
+		server := newPagingServer(t, 15, &requests)
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+
+		for flag := range client.AllFlags(context.Background(), &ListFlagsOptions{Limit: 4}) {
+			if flag.Id == 2 {
+				break
+			}
+		}
+		assert.Equal(t, int32(1), requests)
+	})
+
+	t.Run("yields error last", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+
+		var errs []error
+		for _, err := range client.AllFlags(context.Background(), nil) {
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], ErrForbidden)
+	})
+}
@@ -0,0 +1,125 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPagingServer serves total flags from /flags honouring limit and offset
+func newPagingServer(t *testing.T, total int, requests *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		flags := []api.Flag{}
+		for i := offset; i < total && i < offset+limit; i++ {
+			flags = append(flags, api.Flag{Id: int64(i + 1), Key: fmt.Sprintf("flag_%d", i+1)})
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(flags)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFlagIterator(t *testing.T) {
+	t.Run("walks all pages", func(t *testing.T) {
+		var requests int32
+		server := newPagingServer(t, 25, &requests)
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+
+		it := client.IterateFlags(context.Background(), &ListFlagsOptions{Limit: 10})
+		var keys []string
+		for it.Next() {
+			keys = append(keys, it.Flag().Key)
+		}
+		require.NoError(t, it.Err())
+		assert.Len(t, keys, 25)
+		assert.Equal(t, "flag_25", keys[24])
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("exact multiple of page size needs one empty page", func(t *testing.T) {
+		var requests int32
+		server := newPagingServer(t, 20, &requests)
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+
+		it := client.IterateFlags(context.Background(), &ListFlagsOptions{Limit: 10})
+		count := 0
+		for it.Next() {
+			count++
+		}
+		require.NoError(t, it.Err())
+		assert.Equal(t, 20, count)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("sends filters", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			assert.Equal(t, "checkout", q.Get("key"))
+			assert.Equal(t, "payment", q.Get("descriptionLike"))
+			assert.Equal(t, "payments,web", q.Get("tags"))
+			assert.Equal(t, "true", q.Get("deleted"))
+			assert.Equal(t, "false", q.Get("enabled"))
+			assert.Equal(t, "100", q.Get("limit"))
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			json.NewEncoder(w).Encode([]api.Flag{})
+		}))
+		defer server.Close()
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+
+		it := client.IterateFlags(context.Background(), &ListFlagsOptions{
+			Key:             "checkout",
+			DescriptionLike: "payment",
+			Tags:            []string{"payments", "web"},
+			Deleted:         true,
+			Enabled:         BoolPtr(false),
+		})
+		assert.False(t, it.Next())
+		assert.NoError(t, it.Err())
+	})
+
+	t.Run("stops on cancelled context", func(t *testing.T) {
+		var requests int32
+		server := newPagingServer(t, 25, &requests)
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		it := client.IterateFlags(ctx, &ListFlagsOptions{Limit: 10})
+		require.True(t, it.Next())
+		cancel()
+		assert.False(t, it.Next())
+		assert.ErrorIs(t, it.Err(), context.Canceled)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("reports server errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+		client, err := NewClient(server.URL)
+		require.NoError(t, err)
+
+		it := client.IterateFlags(context.Background(), nil)
+		assert.False(t, it.Next())
+		assert.ErrorIs(t, it.Err(), ErrUnauthorized)
+	})
+}
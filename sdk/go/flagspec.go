@@ -0,0 +1,297 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rollbackTimeout bounds the cleanup after a failed ApplyFlagSpec
+const rollbackTimeout = 30 * time.Second
+
+// specMaxKeyLength is the server's limit on flag and variant keys
+const specMaxKeyLength = 63
+
+// specKeyPattern is the server's rule for flag and variant keys (^[\w\d-/\.:]+$)
+var specKeyPattern = regexp.MustCompile(`^[\w\-/.:]+$`)
+
+// FlagSpec is the complete definition of a flag, created in one call with ApplyFlagSpec
+type FlagSpec struct {
+	Key         string
+	Description string
+	EntityType  string
+	Notes       string
+	// Enabled enables the flag once everything else is in place
+	Enabled  bool
+	Variants []VariantInput
+	// Segments are evaluated in order; the first one matching an entity decides
+	Segments []SegmentSpec
+	Tags     []string
+}
+
+// SegmentSpec is a segment of a FlagSpec
+type SegmentSpec struct {
+	Description string
+	// RolloutPercent is the share of matching entities that get a variant (0 to 100)
+	RolloutPercent int
+	// Constraints must all match for an entity to be in the segment
+	Constraints []ConstraintInput
+	// Distribution maps variant keys to percents adding up to 100
+	Distribution map[string]int
+}
+
+// FlagApplyError reports the step of ApplyFlagSpec that failed. The partially
+// created flag is deleted; if that fails too, RollbackErr is set and FlagID
+// identifies the flag left on the server.
+type FlagApplyError struct {
+	Key         string
+	Step        string
+	Err         error
+	FlagID      int64
+	RollbackErr error
+}
+
+func (e *FlagApplyError) Error() string {
+	msg := fmt.Sprintf("failed to apply flag %s: %s: %v", e.Key, e.Step, e.Err)
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf(" (rollback failed, flag %d left on the server: %v)", e.FlagID, e.RollbackErr)
+	}
+	return msg
+}
+
+func (e *FlagApplyError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the spec locally with the server's rules: key formats, known
+// constraint operators, and distributions of declared variants adding up to 100
+func (s *FlagSpec) Validate() error {
+	if !validSpecKey(s.Key) {
+		return NewInvalidConfigError(fmt.Sprintf("flag key %q must be 1 to 63 letters, digits, '_', '-', '/', '.' or ':'", s.Key), nil)
+	}
+	variants := make(map[string]bool, len(s.Variants))
+	for _, v := range s.Variants {
+		if !validSpecKey(v.Key) {
+			return NewInvalidConfigError(fmt.Sprintf("flag %s: variant key %q must be 1 to 63 letters, digits, '_', '-', '/', '.' or ':'", s.Key, v.Key), nil)
+		}
+		if variants[v.Key] {
+			return NewInvalidConfigError(fmt.Sprintf("flag %s: duplicate variant %s", s.Key, v.Key), nil)
+		}
+		variants[v.Key] = true
+	}
+	for i, segment := range s.Segments {
+		if err := segment.validate(variants); err != nil {
+			return NewInvalidConfigError(fmt.Sprintf("flag %s: segment %d: %s", s.Key, i, err), nil)
+		}
+	}
+	tags := make(map[string]bool, len(s.Tags))
+	for _, tag := range s.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return NewInvalidConfigError(fmt.Sprintf("flag %s: empty tag", s.Key), nil)
+		}
+		if tags[tag] {
+			return NewInvalidConfigError(fmt.Sprintf("flag %s: duplicate tag %s", s.Key, tag), nil)
+		}
+		tags[tag] = true
+	}
+	return nil
+}
+
+// validSpecKey reports whether the server accepts key as a flag or variant key
+func validSpecKey(key string) bool {
+	return len(key) <= specMaxKeyLength && specKeyPattern.MatchString(key)
+}
+
+func (s *SegmentSpec) validate(variants map[string]bool) error {
+	if s.RolloutPercent < 0 || s.RolloutPercent > 100 {
+		return fmt.Errorf("rollout percent must be between 0 and 100")
+	}
+	for _, c := range s.Constraints {
+		if c.Property == "" {
+			return fmt.Errorf("constraint property is required")
+		}
+		if !isConstraintOperator(c.Operator) {
+			return fmt.Errorf("unknown constraint operator %q", c.Operator)
+		}
+	}
+	if len(s.Distribution) == 0 {
+		return nil
+	}
+	total := 0
+	for key, percent := range s.Distribution {
+		if !variants[key] {
+			return fmt.Errorf("distribution of undeclared variant %s", key)
+		}
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("distribution of %s must be between 0 and 100", key)
+		}
+		total += percent
+	}
+	if total != 100 {
+		return fmt.Errorf("distribution adds up to %d, not 100", total)
+	}
+	return nil
+}
+
+func isConstraintOperator(op string) bool {
+	for _, known := range constraintOperators {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyFlagSpec validates the spec and creates the flag with its variants, segments,
+// constraints, distributions and tags, then enables it if the spec says so. If a
+// step fails, the flag is permanently deleted again and a *FlagApplyError is
+// returned. The flag's key must not exist yet.
+func (c *Client) ApplyFlagSpec(ctx context.Context, s *FlagSpec) (*Flag, error) {
+	if s == nil {
+		return nil, NewInvalidConfigError("spec is required", nil)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	flag, err := c.CreateFlag(ctx, &CreateFlagInput{Key: s.Key, Description: s.Description})
+	if err != nil {
+		return nil, &FlagApplyError{Key: s.Key, Step: "create flag", Err: err}
+	}
+	if step, err := s.build(ctx, c, flag.Id); err != nil {
+		applyErr := &FlagApplyError{Key: s.Key, Step: step, Err: err, FlagID: flag.Id}
+		// Roll back even if ctx was cancelled, which may be why the step failed
+		rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+		defer cancel()
+		applyErr.RollbackErr = c.PermanentlyDeleteFlag(rollbackCtx, flag.Id)
+		return nil, applyErr
+	}
+	return c.GetFlag(ctx, flag.Id)
+}
+
+// build creates everything below the flag, returning the failed step on error
+func (s *FlagSpec) build(ctx context.Context, c *Client, flagID int64) (step string, err error) {
+	if s.EntityType != "" || s.Notes != "" {
+		input := &UpdateFlagInput{}
+		if s.EntityType != "" {
+			input.EntityType = &s.EntityType
+		}
+		if s.Notes != "" {
+			input.Notes = &s.Notes
+		}
+		if _, err := c.UpdateFlag(ctx, flagID, input); err != nil {
+			return "update flag", err
+		}
+	}
+
+	variantIDs := make(map[string]int64, len(s.Variants))
+	for i := range s.Variants {
+		variant, err := c.CreateVariant(ctx, flagID, &s.Variants[i])
+		if err != nil {
+			return "create variant " + s.Variants[i].Key, err
+		}
+		variantIDs[variant.Key] = variant.Id
+	}
+
+	segmentIDs := make([]int64, len(s.Segments))
+	for i, spec := range s.Segments {
+		segment, err := c.CreateSegment(ctx, flagID, &SegmentInput{Description: spec.Description, RolloutPercent: spec.RolloutPercent})
+		if err != nil {
+			return fmt.Sprintf("create segment %d", i), err
+		}
+		segmentIDs[i] = segment.Id
+		for j := range spec.Constraints {
+			if _, err := c.CreateConstraint(ctx, flagID, segment.Id, &spec.Constraints[j]); err != nil {
+				return fmt.Sprintf("create constraint %d of segment %d", j, i), err
+			}
+		}
+		if len(spec.Distribution) > 0 {
+			if _, err := c.ReplaceDistributions(ctx, flagID, segment.Id, spec.distributionInputs(variantIDs)); err != nil {
+				return fmt.Sprintf("set distribution of segment %d", i), err
+			}
+		}
+	}
+	// New segments share the default rank, so order them explicitly
+	if len(segmentIDs) > 1 {
+		if err := c.ReorderSegments(ctx, flagID, segmentIDs); err != nil {
+			return "reorder segments", err
+		}
+	}
+
+	for _, tag := range s.Tags {
+		if _, err := c.AddFlagTag(ctx, flagID, tag); err != nil {
+			return "add tag " + tag, err
+		}
+	}
+	if s.Enabled {
+		if _, err := c.SetFlagEnabled(ctx, flagID, true); err != nil {
+			return "enable flag", err
+		}
+	}
+	return "", nil
+}
+
+// distributionInputs resolves the distribution's variant keys to server IDs, sorted by key
+func (s *SegmentSpec) distributionInputs(variantIDs map[string]int64) []DistributionInput {
+	keys := make([]string, 0, len(s.Distribution))
+	for key := range s.Distribution {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	inputs := make([]DistributionInput, len(keys))
+	for i, key := range keys {
+		inputs[i] = DistributionInput{VariantID: variantIDs[key], VariantKey: key, Percent: s.Distribution[key]}
+	}
+	return inputs
+}
+
+// Templates for common flags. The single-variant templates serve the variant "enabled".
+
+// KillSwitchSpec returns an enabled flag serving "enabled" to everyone. Disable the
+// flag to turn the feature off.
+func KillSwitchSpec(key, description string) *FlagSpec {
+	return &FlagSpec{
+		Key:         key,
+		Description: description,
+		Enabled:     true,
+		Variants:    []VariantInput{{Key: "enabled"}},
+		Segments: []SegmentSpec{
+			{Description: "everyone", RolloutPercent: 100, Distribution: map[string]int{"enabled": 100}},
+		},
+	}
+}
+
+// PercentageRolloutSpec returns an enabled flag serving "enabled" to percent of all
+// entities; the others get no variant
+func PercentageRolloutSpec(key, description string, percent int) *FlagSpec {
+	spec := KillSwitchSpec(key, description)
+	spec.Segments[0].RolloutPercent = percent
+	return spec
+}
+
+// ABTestSpec returns an enabled flag splitting all entities 50/50 between the
+// variants "control" and "treatment"
+func ABTestSpec(key, description string) *FlagSpec {
+	return &FlagSpec{
+		Key:         key,
+		Description: description,
+		Enabled:     true,
+		Variants:    []VariantInput{{Key: "control"}, {Key: "treatment"}},
+		Segments: []SegmentSpec{
+			{Description: "everyone", RolloutPercent: 100, Distribution: map[string]int{"control": 50, "treatment": 50}},
+		},
+	}
+}
+
+// AllowlistSpec returns an enabled flag serving "enabled" only to entities whose
+// context property is one of values
+func AllowlistSpec(key, description, property string, values []string) *FlagSpec {
+	spec := KillSwitchSpec(key, description)
+	spec.Segments[0].Description = "allowlist"
+	spec.Segments[0].Constraints = []ConstraintInput{
+		{Property: property, Operator: "IN", Value: strings.Join(values, ",")},
+	}
+	return spec
+}
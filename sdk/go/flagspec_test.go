@@ -0,0 +1,211 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlagSpecServer fakes the management calls of ApplyFlagSpec for flag 10, records
+// them as "METHOD path" and fails the call matching failOn with a 400. Created
+// variants, segments, constraints and tags get IDs from 101 up.
+func newFlagSpecServer(t *testing.T, failOn string, calls *[]string) *Client {
+	t.Helper()
+	nextID := int64(100)
+	return newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		call := r.Method + " " + r.URL.Path
+		*calls = append(*calls, call)
+		if call == failOn {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"rejected"}`))
+			return
+		}
+		switch {
+		case call == "POST /flags":
+			json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: body["key"].(string)})
+		case call == "POST /flags/10/variants":
+			nextID++
+			json.NewEncoder(w).Encode(api.Variant{Id: nextID, FlagID: 10, Key: body["key"].(string)})
+		case call == "POST /flags/10/segments":
+			nextID++
+			json.NewEncoder(w).Encode(api.Segment{Id: nextID, FlagID: 10})
+		case strings.HasSuffix(call, "/constraints"):
+			nextID++
+			json.NewEncoder(w).Encode(api.Constraint{Id: nextID, Property: body["property"].(string), Operator: body["operator"].(string)})
+		case strings.HasSuffix(call, "/distributions"):
+			json.NewEncoder(w).Encode([]api.Distribution{})
+		case call == "POST /flags/10/tags":
+			nextID++
+			json.NewEncoder(w).Encode(api.Tag{Id: nextID, Value: body["value"].(string)})
+		case call == "GET /flags/10", call == "PUT /flags/10", call == "PUT /flags/10/enabled":
+			json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: "checkout_v2", Enabled: true})
+		}
+	})
+}
+
+func TestApplyFlagSpec(t *testing.T) {
+	ctx := context.Background()
+	spec := &FlagSpec{
+		Key:        "checkout_v2",
+		EntityType: "user",
+		Enabled:    true,
+		Variants:   []VariantInput{{Key: "control"}, {Key: "treatment"}},
+		Segments: []SegmentSpec{
+			{
+				Description:    "beta",
+				RolloutPercent: 100,
+				Constraints:    []ConstraintInput{{Property: "tier", Operator: "EQ", Value: "beta"}},
+				Distribution:   map[string]int{"treatment": 100},
+			},
+			{Description: "everyone", RolloutPercent: 50, Distribution: map[string]int{"control": 50, "treatment": 50}},
+		},
+		Tags: []string{"payments"},
+	}
+
+	t.Run("creates the flag in dependency order", func(t *testing.T) {
+		var calls []string
+		client := newFlagSpecServer(t, "", &calls)
+		flag, err := client.ApplyFlagSpec(ctx, spec)
+		require.NoError(t, err)
+		assert.True(t, flag.Enabled)
+		assert.Equal(t, []string{
+			"POST /flags",
+			"PUT /flags/10",
+			"POST /flags/10/variants",
+			"POST /flags/10/variants",
+			"POST /flags/10/segments",
+			"POST /flags/10/segments/103/constraints",
+			"PUT /flags/10/segments/103/distributions",
+			"POST /flags/10/segments",
+			"PUT /flags/10/segments/105/distributions",
+			"PUT /flags/10/segments/reorder",
+			"POST /flags/10/tags",
+			"PUT /flags/10/enabled",
+			"GET /flags/10",
+		}, calls)
+	})
+
+	t.Run("resolves variant keys to IDs", func(t *testing.T) {
+		var got []interface{}
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			switch r.URL.Path {
+			case "/flags":
+				json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: "checkout_ab"})
+			case "/flags/10/variants":
+				id := int64(1)
+				if body["key"] == "treatment" {
+					id = 2
+				}
+				json.NewEncoder(w).Encode(api.Variant{Id: id, FlagID: 10, Key: body["key"].(string)})
+			case "/flags/10/segments":
+				json.NewEncoder(w).Encode(api.Segment{Id: 5, FlagID: 10})
+			case "/flags/10/segments/5/distributions":
+				got = body["distributions"].([]interface{})
+				json.NewEncoder(w).Encode([]api.Distribution{})
+			default:
+				json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: "checkout_ab", Enabled: true})
+			}
+		})
+		_, err := client.ApplyFlagSpec(ctx, ABTestSpec("checkout_ab", "Checkout experiment"))
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, map[string]interface{}{"variantID": 1.0, "variantKey": "control", "percent": 50.0}, got[0])
+		assert.Equal(t, map[string]interface{}{"variantID": 2.0, "variantKey": "treatment", "percent": 50.0}, got[1])
+	})
+
+	t.Run("rolls back on failure", func(t *testing.T) {
+		var calls []string
+		client := newFlagSpecServer(t, "PUT /flags/10/segments/105/distributions", &calls)
+		_, err := client.ApplyFlagSpec(ctx, spec)
+		var applyErr *FlagApplyError
+		require.True(t, errors.As(err, &applyErr))
+		assert.Equal(t, "set distribution of segment 1", applyErr.Step)
+		assert.NoError(t, applyErr.RollbackErr)
+		assert.True(t, errors.Is(err, ErrBadRequest))
+		assert.Equal(t, "DELETE /flags/10/permanent", calls[len(calls)-1])
+		assert.NotContains(t, calls, "PUT /flags/10/enabled")
+	})
+
+	t.Run("reports a failed rollback", func(t *testing.T) {
+		var calls []string
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			calls = append(calls, r.Method+" "+r.URL.Path)
+			if r.URL.Path == "/flags" {
+				json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: "kill_checkout"})
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		_, err := client.ApplyFlagSpec(ctx, KillSwitchSpec("kill_checkout", ""))
+		var applyErr *FlagApplyError
+		require.True(t, errors.As(err, &applyErr))
+		assert.Equal(t, "create variant enabled", applyErr.Step)
+		assert.Equal(t, int64(10), applyErr.FlagID)
+		assert.True(t, errors.Is(applyErr.RollbackErr, ErrServerUnavailable))
+		assert.Contains(t, err.Error(), "flag 10 left on the server")
+	})
+
+	t.Run("validates the spec locally", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			t.Fatal("invalid spec must not reach the server")
+		})
+		valid := func() *FlagSpec { return ABTestSpec("checkout_ab", "") }
+		for name, mutate := range map[string]func(s *FlagSpec){
+			"key":              func(s *FlagSpec) { s.Key = "Checkout AB" },
+			"variant":          func(s *FlagSpec) { s.Variants[0].Key = "variant a" },
+			"long key":         func(s *FlagSpec) { s.Key = strings.Repeat("k", 64) },
+			"duplicate":        func(s *FlagSpec) { s.Variants[1].Key = "control" },
+			"rollout":          func(s *FlagSpec) { s.Segments[0].RolloutPercent = 101 },
+			"operator":         func(s *FlagSpec) { s.Segments[0].Constraints = []ConstraintInput{{Property: "a", Operator: "LIKE"}} },
+			"undeclared":       func(s *FlagSpec) { s.Segments[0].Distribution = map[string]int{"other": 100} },
+			"not 100":          func(s *FlagSpec) { s.Segments[0].Distribution["control"] = 40 },
+			"duplicate tag":    func(s *FlagSpec) { s.Tags = []string{"a", "a"} },
+			"missing property": func(s *FlagSpec) { s.Segments[0].Constraints = []ConstraintInput{{Operator: "EQ"}} },
+		} {
+			s := valid()
+			mutate(s)
+			_, err := client.ApplyFlagSpec(ctx, s)
+			var configErr *InvalidConfigError
+			assert.True(t, errors.As(err, &configErr), name)
+		}
+	})
+}
+
+func TestFlagSpecKeys(t *testing.T) {
+	t.Run("accepts the keys the server accepts", func(t *testing.T) {
+		for _, key := range []string{"on", "Checkout.V2", "release_1.2", "team/checkout:v2", strings.Repeat("k", 63)} {
+			spec := &FlagSpec{Key: key, Variants: []VariantInput{{Key: key}}}
+			assert.NoError(t, spec.Validate(), key)
+		}
+	})
+
+	t.Run("accepts the GitOps example", func(t *testing.T) {
+		file, err := ParseGitOpsFile([]byte(gitOpsYAML), GitOpsYAML)
+		require.NoError(t, err)
+		for _, spec := range file.FlagSpecs() {
+			assert.NoError(t, spec.Validate(), spec.Key)
+		}
+	})
+}
+
+func TestFlagSpecTemplates(t *testing.T) {
+	for name, spec := range map[string]*FlagSpec{
+		"kill switch": KillSwitchSpec("kill_checkout", "Checkout kill switch"),
+		"rollout":     PercentageRolloutSpec("new_search", "", 10),
+		"ab test":     ABTestSpec("checkout_ab", ""),
+		"allowlist":   AllowlistSpec("beta_reports", "", "email", []string{"a@acme.com", "b@acme.com"}),
+	} {
+		assert.NoError(t, spec.Validate(), name)
+		assert.True(t, spec.Enabled, name)
+	}
+	assert.Equal(t, 10, PercentageRolloutSpec("new_search", "", 10).Segments[0].RolloutPercent)
+	assert.Equal(t, []ConstraintInput{{Property: "email", Operator: "IN", Value: "a@acme.com,b@acme.com"}},
+		AllowlistSpec("beta_reports", "", "email", []string{"a@acme.com", "b@acme.com"}).Segments[0].Constraints)
+}
@@ -0,0 +1,319 @@
+package flagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GitOpsFormat is the encoding of a GitOps flags file
+type GitOpsFormat string
+
+const (
+	GitOpsYAML GitOpsFormat = "yaml"
+	GitOpsJSON GitOpsFormat = "json"
+)
+
+const (
+	gitOpsVersion               = "1"
+	defaultGitOpsSegmentRank    = 999
+	defaultGitOpsRolloutPercent = 100
+)
+
+// GitOpsFile is a GitOps flags file (flags.yaml), as exported by /export/gitops and
+// accepted by /import. See docs/guides/gitops.md.
+type GitOpsFile struct {
+	Version string       `json:"version" yaml:"version"`
+	Flags   []GitOpsFlag `json:"flags" yaml:"flags"`
+}
+
+// GitOpsFlag is a flag in a GitOps file, identified by its key
+type GitOpsFlag struct {
+	Key                string          `json:"key" yaml:"key"`
+	Description        string          `json:"description" yaml:"description"`
+	Enabled            bool            `json:"enabled" yaml:"enabled"`
+	Notes              string          `json:"notes,omitempty" yaml:"notes,omitempty"`
+	DataRecordsEnabled bool            `json:"dataRecordsEnabled" yaml:"dataRecordsEnabled"`
+	EntityType         string          `json:"entityType,omitempty" yaml:"entityType,omitempty"`
+	Segments           []GitOpsSegment `json:"segments,omitempty" yaml:"segments,omitempty"`
+	Variants           []GitOpsVariant `json:"variants,omitempty" yaml:"variants,omitempty"`
+	Tags               []string        `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// GitOpsSegment is a segment of a GitOps flag. When decoding, a missing Rank
+// defaults to 999 and a missing RolloutPercent to 100, as on the server.
+type GitOpsSegment struct {
+	Rank           int                  `json:"rank" yaml:"rank"`
+	Description    string               `json:"description,omitempty" yaml:"description,omitempty"`
+	RolloutPercent int                  `json:"rolloutPercent" yaml:"rolloutPercent"`
+	Constraints    []GitOpsConstraint   `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	Distributions  []GitOpsDistribution `json:"distributions,omitempty" yaml:"distributions,omitempty"`
+}
+
+// GitOpsConstraint is a segment constraint
+type GitOpsConstraint struct {
+	Property string `json:"property" yaml:"property"`
+	Operator string `json:"operator" yaml:"operator"`
+	Value    string `json:"value" yaml:"value"`
+}
+
+// GitOpsDistribution assigns a percentage of a segment to a variant
+type GitOpsDistribution struct {
+	VariantKey string `json:"variantKey" yaml:"variantKey"`
+	Percent    int    `json:"percent" yaml:"percent"`
+}
+
+// GitOpsVariant is a flag variant. Variants referenced only by distributions are
+// created without an attachment.
+type GitOpsVariant struct {
+	Key        string            `json:"key" yaml:"key"`
+	Attachment map[string]string `json:"attachment,omitempty" yaml:"attachment,omitempty"`
+}
+
+// gitOpsSegmentFields has the fields of GitOpsSegment without its decoders
+type gitOpsSegmentFields GitOpsSegment
+
+func newGitOpsSegmentFields() gitOpsSegmentFields {
+	return gitOpsSegmentFields{Rank: defaultGitOpsSegmentRank, RolloutPercent: defaultGitOpsRolloutPercent}
+}
+
+// UnmarshalJSON applies the server defaults for missing fields
+func (s *GitOpsSegment) UnmarshalJSON(data []byte) error {
+	fields := newGitOpsSegmentFields()
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*s = GitOpsSegment(fields)
+	return nil
+}
+
+// UnmarshalYAML applies the server defaults for missing fields
+func (s *GitOpsSegment) UnmarshalYAML(node *yaml.Node) error {
+	fields := newGitOpsSegmentFields()
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+	*s = GitOpsSegment(fields)
+	return nil
+}
+
+// ParseGitOpsFile decodes a GitOps file. Unknown fields are ignored, as on the server.
+func ParseGitOpsFile(data []byte, format GitOpsFormat) (*GitOpsFile, error) {
+	var file GitOpsFile
+	var err error
+	switch format {
+	case GitOpsYAML:
+		err = yaml.Unmarshal(data, &file)
+	case GitOpsJSON:
+		err = json.Unmarshal(data, &file)
+	default:
+		return nil, NewInvalidConfigError(fmt.Sprintf("unsupported GitOps format %q", format), nil)
+	}
+	if err != nil {
+		return nil, NewInvalidConfigError(fmt.Sprintf("invalid GitOps %s: %v", format, err), err)
+	}
+	if file.Version == "" {
+		file.Version = gitOpsVersion
+	}
+	return &file, nil
+}
+
+// Marshal encodes the file in format
+func (f *GitOpsFile) Marshal(format GitOpsFormat) ([]byte, error) {
+	out := *f
+	if out.Version == "" {
+		out.Version = gitOpsVersion
+	}
+	if out.Flags == nil {
+		out.Flags = []GitOpsFlag{}
+	}
+	switch format {
+	case GitOpsYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(out); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case GitOpsJSON:
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, NewInvalidConfigError(fmt.Sprintf("unsupported GitOps format %q", format), nil)
+	}
+}
+
+// Validate checks what the server would silently ignore or fail on part-way: missing
+// or duplicate keys, incomplete constraints and distributions that do not add up to 100.
+func (f *GitOpsFile) Validate() error {
+	keys := make(map[string]bool, len(f.Flags))
+	for i, flag := range f.Flags {
+		if strings.TrimSpace(flag.Key) == "" {
+			return NewInvalidConfigError(fmt.Sprintf("flag %d: key is required", i), nil)
+		}
+		if keys[flag.Key] {
+			return NewInvalidConfigError(fmt.Sprintf("flag %s: duplicate key", flag.Key), nil)
+		}
+		keys[flag.Key] = true
+
+		variants := make(map[string]bool, len(flag.Variants))
+		for _, v := range flag.Variants {
+			if v.Key == "" {
+				return NewInvalidConfigError(fmt.Sprintf("flag %s: variant key is required", flag.Key), nil)
+			}
+			if variants[v.Key] {
+				return NewInvalidConfigError(fmt.Sprintf("flag %s: duplicate variant %s", flag.Key, v.Key), nil)
+			}
+			variants[v.Key] = true
+		}
+		for j, segment := range flag.Segments {
+			if segment.RolloutPercent < 0 || segment.RolloutPercent > 100 {
+				return NewInvalidConfigError(fmt.Sprintf("flag %s: segment %d: rolloutPercent must be between 0 and 100", flag.Key, j), nil)
+			}
+			for _, c := range segment.Constraints {
+				if c.Property == "" || c.Operator == "" {
+					return NewInvalidConfigError(fmt.Sprintf("flag %s: segment %d: constraint property and operator are required", flag.Key, j), nil)
+				}
+			}
+			if len(segment.Distributions) == 0 {
+				continue
+			}
+			total := 0
+			for _, d := range segment.Distributions {
+				if d.VariantKey == "" {
+					return NewInvalidConfigError(fmt.Sprintf("flag %s: segment %d: distribution variantKey is required", flag.Key, j), nil)
+				}
+				total += d.Percent
+			}
+			if total != 100 {
+				return NewInvalidConfigError(fmt.Sprintf("flag %s: segment %d: distributions add up to %d, not 100", flag.Key, j, total), nil)
+			}
+		}
+	}
+	return nil
+}
+
+// ExportFlags exports all flags as a GitOps file in format (GET /export/gitops).
+// Decode it with ParseGitOpsFile.
+func (c *Client) ExportFlags(ctx context.Context, format GitOpsFormat) ([]byte, error) {
+	if err := c.requireFeature(FeatureGitOps); err != nil {
+		return nil, err
+	}
+	if format != GitOpsYAML && format != GitOpsJSON {
+		return nil, NewInvalidConfigError(fmt.Sprintf("unsupported GitOps format %q", format), nil)
+	}
+	query := url.Values{"format": {string(format)}}
+	resp, err := c.do(ctx, http.MethodGet, "/export/gitops", query, nil, nil)
+	if err != nil {
+		return nil, c.featureError(FeatureGitOps, resp, err, "failed to export flags")
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewNetworkError("failed to read flags export: "+err.Error(), err)
+	}
+	return data, nil
+}
+
+// ImportResult lists the flags an import created, updated and skipped
+type ImportResult struct {
+	Created []string      `json:"created"`
+	Updated []string      `json:"updated"`
+	Skipped []SkippedFlag `json:"skipped"`
+}
+
+// SkippedFlag is a flag the server failed to import
+type SkippedFlag struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// importResultJSON is the response of /import; errors are "<key>: <reason>"
+type importResultJSON struct {
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Errors  []string `json:"errors"`
+}
+
+// ImportFlags creates or updates the flags of file by key (POST /import). Flags not
+// in the file are left alone. The file is validated first; a flag that fails on the
+// server is skipped without stopping the import. An updated flag's segments are
+// replaced by the file's.
+//
+// The server only returns counts, so created and updated flags are told apart by
+// the keys that existed before the import.
+func (c *Client) ImportFlags(ctx context.Context, file *GitOpsFile) (*ImportResult, error) {
+	if err := c.requireFeature(FeatureGitOps); err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, NewInvalidConfigError("file is required", nil)
+	}
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+	content, err := file.Marshal(GitOpsJSON)
+	if err != nil {
+		return nil, NewInvalidConfigError("failed to encode GitOps file: "+err.Error(), err)
+	}
+
+	existing := make(map[string]bool)
+	it := c.IterateFlags(ctx, &ListFlagsOptions{})
+	for it.Next() {
+		existing[it.Flag().Key] = true
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	body := map[string]string{"format": string(GitOpsJSON), "content": string(content)}
+	var out importResultJSON
+	resp, err := c.do(ctx, http.MethodPost, "/import", nil, body, &out)
+	if err != nil {
+		return nil, c.featureError(FeatureGitOps, resp, err, "failed to import flags")
+	}
+	return newImportResult(file, existing, out)
+}
+
+// newImportResult assigns the server's per-flag errors to the file's flags. Errors
+// that do not name a flag mean nothing was imported.
+func newImportResult(file *GitOpsFile, existing map[string]bool, out importResultJSON) (*ImportResult, error) {
+	result := &ImportResult{}
+	skipped := make(map[string]bool)
+	for _, message := range out.Errors {
+		key := ""
+		for _, flag := range file.Flags {
+			if strings.HasPrefix(message, flag.Key+": ") && len(flag.Key) > len(key) {
+				key = flag.Key
+			}
+		}
+		if key == "" {
+			return nil, NewRequestError("failed to import flags: "+message, nil)
+		}
+		skipped[key] = true
+		result.Skipped = append(result.Skipped, SkippedFlag{Key: key, Reason: strings.TrimPrefix(message, key+": ")})
+	}
+	for _, flag := range file.Flags {
+		switch {
+		case skipped[flag.Key]:
+		case existing[flag.Key]:
+			result.Updated = append(result.Updated, flag.Key)
+		default:
+			result.Created = append(result.Created, flag.Key)
+		}
+	}
+	return result, nil
+}
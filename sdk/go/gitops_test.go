@@ -0,0 +1,183 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gitOpsYAML = `version: "1"
+flags:
+  - key: new_checkout
+    description: New checkout flow
+    enabled: true
+    tags: [payments]
+    variants:
+      - key: "on"
+        attachment:
+          color: blue
+    segments:
+      - description: Beta users
+        constraints:
+          - property: tier
+            operator: EQ
+            value: beta
+        distributions:
+          - variantKey: "on"
+            percent: 100
+  - key: dark_mode
+    description: Dark mode UI
+`
+
+func TestGitOpsFile(t *testing.T) {
+	t.Run("parses YAML with server defaults", func(t *testing.T) {
+		file, err := ParseGitOpsFile([]byte(gitOpsYAML), GitOpsYAML)
+		require.NoError(t, err)
+		require.Len(t, file.Flags, 2)
+		flag := file.Flags[0]
+		assert.Equal(t, "new_checkout", flag.Key)
+		assert.True(t, flag.Enabled)
+		assert.Equal(t, []string{"payments"}, flag.Tags)
+		assert.Equal(t, map[string]string{"color": "blue"}, flag.Variants[0].Attachment)
+		require.Len(t, flag.Segments, 1)
+		assert.Equal(t, 999, flag.Segments[0].Rank)
+		assert.Equal(t, 100, flag.Segments[0].RolloutPercent)
+		assert.Equal(t, GitOpsConstraint{Property: "tier", Operator: "EQ", Value: "beta"}, flag.Segments[0].Constraints[0])
+		assert.False(t, file.Flags[1].Enabled)
+	})
+
+	t.Run("round-trips through JSON and YAML", func(t *testing.T) {
+		file, err := ParseGitOpsFile([]byte(gitOpsYAML), GitOpsYAML)
+		require.NoError(t, err)
+		for _, format := range []GitOpsFormat{GitOpsJSON, GitOpsYAML} {
+			data, err := file.Marshal(format)
+			require.NoError(t, err)
+			decoded, err := ParseGitOpsFile(data, format)
+			require.NoError(t, err)
+			assert.Equal(t, file, decoded, format)
+		}
+	})
+
+	t.Run("keeps an explicit zero rollout", func(t *testing.T) {
+		file, err := ParseGitOpsFile([]byte(`{"flags": [{"key": "f", "segments": [{"rank": 1, "rolloutPercent": 0}]}]}`), GitOpsJSON)
+		require.NoError(t, err)
+		assert.Equal(t, "1", file.Version)
+		assert.Equal(t, GitOpsSegment{Rank: 1}, file.Flags[0].Segments[0])
+	})
+
+	t.Run("rejects malformed files", func(t *testing.T) {
+		var configErr *InvalidConfigError
+		_, err := ParseGitOpsFile([]byte("flags: [key: x"), GitOpsYAML)
+		assert.True(t, errors.As(err, &configErr))
+		_, err = ParseGitOpsFile([]byte("{}"), "toml")
+		assert.True(t, errors.As(err, &configErr))
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		for name, flags := range map[string][]GitOpsFlag{
+			"missing key":   {{Description: "x"}},
+			"duplicate key": {{Key: "a"}, {Key: "a"}},
+			"bad rollout":   {{Key: "a", Segments: []GitOpsSegment{{RolloutPercent: 120}}}},
+			"distributions": {{Key: "a", Segments: []GitOpsSegment{{RolloutPercent: 100, Distributions: []GitOpsDistribution{{VariantKey: "on", Percent: 60}}}}}},
+			"constraint":    {{Key: "a", Segments: []GitOpsSegment{{RolloutPercent: 100, Constraints: []GitOpsConstraint{{Property: "tier"}}}}}},
+		} {
+			var configErr *InvalidConfigError
+			assert.True(t, errors.As((&GitOpsFile{Flags: flags}).Validate(), &configErr), name)
+		}
+	})
+}
+
+func TestExportFlags(t *testing.T) {
+	client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/export/gitops", r.URL.Path)
+		assert.Equal(t, "yaml", r.URL.Query().Get("format"))
+		w.Header().Set("Content-Type", "text/yaml; charset=utf-8")
+		w.Write([]byte(gitOpsYAML))
+	})
+	data, err := client.ExportFlags(context.Background(), GitOpsYAML)
+	require.NoError(t, err)
+	assert.Equal(t, gitOpsYAML, string(data))
+
+	_, err = client.ExportFlags(context.Background(), "xml")
+	var configErr *InvalidConfigError
+	assert.True(t, errors.As(err, &configErr))
+}
+
+func TestImportFlags(t *testing.T) {
+	ctx := context.Background()
+	file := &GitOpsFile{Flags: []GitOpsFlag{
+		{Key: "new_checkout", Enabled: true},
+		{Key: "dark_mode"},
+		{Key: "dark_mode_v2"},
+	}}
+
+	t.Run("lists created, updated and skipped flags", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			switch r.URL.Path {
+			case "/flags":
+				if r.URL.Query().Get("offset") == "0" {
+					w.Write([]byte(`[{"id": 1, "key": "dark_mode", "description": "", "enabled": false, "dataRecordsEnabled": false}]`))
+					return
+				}
+				w.Write([]byte(`[]`))
+			case "/import":
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "json", body["format"])
+				sent, err := ParseGitOpsFile([]byte(body["content"].(string)), GitOpsJSON)
+				require.NoError(t, err)
+				assert.Equal(t, []GitOpsFlag{{Key: "new_checkout", Enabled: true}, {Key: "dark_mode"}, {Key: "dark_mode_v2"}}, sent.Flags)
+				w.Write([]byte(`{"created": 1, "updated": 1, "errors": ["dark_mode_v2: Variant key must be unique"]}`))
+			default:
+				t.Errorf("unexpected request %s", r.URL.Path)
+			}
+		})
+		result, err := client.ImportFlags(ctx, file)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"new_checkout"}, result.Created)
+		assert.Equal(t, []string{"dark_mode"}, result.Updated)
+		assert.Equal(t, []SkippedFlag{{Key: "dark_mode_v2", Reason: "Variant key must be unique"}}, result.Skipped)
+	})
+
+	t.Run("errors that name no flag fail the import", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			if r.URL.Path == "/flags" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`{"created": 0, "updated": 0, "errors": ["Parse error: unexpected token"]}`))
+		})
+		_, err := client.ImportFlags(ctx, file)
+		var requestErr *RequestError
+		require.True(t, errors.As(err, &requestErr))
+		assert.Contains(t, err.Error(), "Parse error")
+	})
+
+	t.Run("server errors", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			if r.URL.Path == "/flags" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": "Insufficient permissions"}`))
+		})
+		_, err := client.ImportFlags(ctx, file)
+		assert.True(t, errors.Is(err, ErrForbidden))
+	})
+
+	t.Run("invalid files are not sent", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			t.Fatal("invalid file must not reach the server")
+		})
+		_, err := client.ImportFlags(ctx, &GitOpsFile{Flags: []GitOpsFlag{{Key: "a"}, {Key: "a"}}})
+		var configErr *InvalidConfigError
+		assert.True(t, errors.As(err, &configErr))
+		_, err = client.ImportFlags(ctx, nil)
+		assert.True(t, errors.As(err, &configErr))
+	})
+}
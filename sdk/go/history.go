@@ -0,0 +1,418 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+)
+
+// FlagRevision is one saved version of a flag, as recorded after every change
+type FlagRevision struct {
+	// ID is the snapshot ID; it increases with every change
+	ID        int64
+	UpdatedBy string
+	UpdatedAt time.Time
+	Flag      Flag
+}
+
+// FlagHistory returns the saved revisions of a flag, oldest first
+func (c *Client) FlagHistory(ctx context.Context, flagID int64) ([]FlagRevision, error) {
+	snapshots, resp, err := c.apiClient.FlagAPI.GetFlagSnapshots(ctx, flagID).Sort("ASC").Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to get history of flag %d", flagID), true)
+	}
+	revisions := make([]FlagRevision, len(snapshots))
+	for i, s := range snapshots {
+		revisions[i] = FlagRevision{
+			ID:        s.Id,
+			UpdatedBy: nullableString(s.UpdatedBy),
+			UpdatedAt: s.UpdatedAt,
+			Flag:      s.Flag,
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		if !revisions[i].UpdatedAt.Equal(revisions[j].UpdatedAt) {
+			return revisions[i].UpdatedAt.Before(revisions[j].UpdatedAt)
+		}
+		return revisions[i].ID < revisions[j].ID
+	})
+	return revisions, nil
+}
+
+// ChangeEntity is the part of a flag a FlagChange applies to
+type ChangeEntity string
+
+const (
+	EntityFlag         ChangeEntity = "flag"
+	EntitySegment      ChangeEntity = "segment"
+	EntityConstraint   ChangeEntity = "constraint"
+	EntityDistribution ChangeEntity = "distribution"
+	EntityVariant      ChangeEntity = "variant"
+	EntityTag          ChangeEntity = "tag"
+)
+
+// ChangeKind tells whether an entity was added, removed or modified
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// FlagChange is one semantic difference between two versions of a flag
+type FlagChange struct {
+	Entity ChangeEntity
+	Kind   ChangeKind
+	// ID of the changed entity; the flag ID for flag fields. Distribution IDs
+	// change on every update, so distributions are identified by Name instead.
+	ID int64
+	// SegmentID is the parent segment of constraints and distributions
+	SegmentID int64
+	// Name is a human-readable label: the variant key for variants and
+	// distributions (the old key when a variant is renamed), "property OPERATOR"
+	// for constraints, the tag value for tags
+	Name string
+	// Field is the JSON name of the modified field (empty for added/removed)
+	Field string
+	// Old and New hold the field values (or the whole entity for added/removed)
+	Old interface{}
+	New interface{}
+	// Author is who made the change, when diffing revisions
+	Author string
+}
+
+// String renders the change, e.g. "segment 12 rollout 20%→50%" or "flag disabled by alice"
+func (c FlagChange) String() string {
+	s := c.describe()
+	if c.Author != "" {
+		s += " by " + c.Author
+	}
+	return s
+}
+
+func (c FlagChange) describe() string {
+	switch c.Entity {
+	case EntityFlag:
+		switch c.Field {
+		case "enabled":
+			if c.New == true {
+				return "flag enabled"
+			}
+			return "flag disabled"
+		case "dataRecordsEnabled":
+			if c.New == true {
+				return "flag data records enabled"
+			}
+			return "flag data records disabled"
+		case "description", "notes":
+			return "flag " + c.Field + " changed"
+		}
+		return fmt.Sprintf("flag %s %s→%s", c.Field, formatChangeValue(c.Old), formatChangeValue(c.New))
+	case EntitySegment:
+		label := fmt.Sprintf("segment %d", c.ID)
+		switch {
+		case c.Kind != ChangeModified:
+			return label + " " + string(c.Kind)
+		case c.Field == "rolloutPercent":
+			return fmt.Sprintf("%s rollout %v%%→%v%%", label, c.Old, c.New)
+		}
+		return fmt.Sprintf("%s %s %s→%s", label, c.Field, formatChangeValue(c.Old), formatChangeValue(c.New))
+	case EntityDistribution:
+		label := "distribution " + c.Name
+		switch c.Kind {
+		case ChangeAdded:
+			if dist, ok := c.New.(Distribution); ok {
+				return fmt.Sprintf("%s %d%% added", label, dist.Percent)
+			}
+			return label + " added"
+		case ChangeRemoved:
+			return label + " removed"
+		}
+		return fmt.Sprintf("%s %v%%→%v%%", label, c.Old, c.New)
+	case EntityVariant:
+		label := "variant " + c.Name
+		switch {
+		case c.Kind != ChangeModified:
+			return label + " " + string(c.Kind)
+		case c.Field == "attachment":
+			return label + " attachment changed"
+		}
+		return fmt.Sprintf("%s %s %s→%s", label, c.Field, formatChangeValue(c.Old), formatChangeValue(c.New))
+	}
+	// Constraints and tags
+	label := string(c.Entity) + " " + c.Name
+	if c.Kind != ChangeModified {
+		return label + " " + string(c.Kind)
+	}
+	return fmt.Sprintf("%s %s %s→%s", label, c.Field, formatChangeValue(c.Old), formatChangeValue(c.New))
+}
+
+// formatChangeValue quotes strings so empty and multi-word values stay readable
+func formatChangeValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+// FlagDiff is the set of changes between two revisions of a flag
+type FlagDiff struct {
+	FlagID int64
+	// From and To are the compared revision IDs (From is 0 for the first revision)
+	From      int64
+	To        int64
+	UpdatedBy string
+	UpdatedAt time.Time
+	Changes   []FlagChange
+}
+
+// String renders one change per line
+func (d FlagDiff) String() string {
+	lines := make([]string, len(d.Changes))
+	for i, c := range d.Changes {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffRevisions compares two revisions of a flag; changes are attributed to to.UpdatedBy
+func DiffRevisions(from, to FlagRevision) FlagDiff {
+	changes := DiffFlags(&from.Flag, &to.Flag)
+	for i := range changes {
+		changes[i].Author = to.UpdatedBy
+	}
+	return FlagDiff{
+		FlagID:    to.Flag.Id,
+		From:      from.ID,
+		To:        to.ID,
+		UpdatedBy: to.UpdatedBy,
+		UpdatedAt: to.UpdatedAt,
+		Changes:   changes,
+	}
+}
+
+// DiffHistory diffs consecutive revisions (as returned by FlagHistory).
+// The first revision is compared with an empty flag, so it lists what the flag was created with.
+func DiffHistory(revisions []FlagRevision) []FlagDiff {
+	diffs := make([]FlagDiff, 0, len(revisions))
+	var previous FlagRevision
+	for i, revision := range revisions {
+		if i == 0 {
+			previous = FlagRevision{Flag: Flag{Id: revision.Flag.Id}}
+		}
+		diffs = append(diffs, DiffRevisions(previous, revision))
+		previous = revision
+	}
+	return diffs
+}
+
+// DiffFlags returns the semantic changes from one version of a flag to another.
+// Segments, constraints and variants are matched by ID, distributions by variant
+// and tags by value. A nil flag is treated as empty.
+func DiffFlags(from, to *Flag) []FlagChange {
+	if from == nil {
+		from = &Flag{}
+	}
+	if to == nil {
+		to = &Flag{}
+	}
+	d := &flagDiffer{flagID: to.Id, variantKeys: make(map[int64]string)}
+	if d.flagID == 0 {
+		d.flagID = from.Id
+	}
+	for _, v := range from.Variants {
+		d.variantKeys[v.Id] = v.Key
+	}
+	for _, v := range to.Variants {
+		d.variantKeys[v.Id] = v.Key
+	}
+
+	d.field(EntityFlag, d.flagID, 0, "", "key", from.Key, to.Key)
+	d.field(EntityFlag, d.flagID, 0, "", "description", from.Description, to.Description)
+	d.field(EntityFlag, d.flagID, 0, "", "enabled", from.Enabled, to.Enabled)
+	d.field(EntityFlag, d.flagID, 0, "", "dataRecordsEnabled", from.DataRecordsEnabled, to.DataRecordsEnabled)
+	d.field(EntityFlag, d.flagID, 0, "", "entityType", nullableString(from.EntityType), nullableString(to.EntityType))
+	d.field(EntityFlag, d.flagID, 0, "", "notes", nullableString(from.Notes), nullableString(to.Notes))
+
+	d.variants(from.Variants, to.Variants)
+	d.segments(from.Segments, to.Segments)
+	d.tags(from.Tags, to.Tags)
+	return d.changes
+}
+
+type flagDiffer struct {
+	flagID int64
+	// variantKeys labels distributions that do not carry their variant key
+	variantKeys map[int64]string
+	changes     []FlagChange
+}
+
+func (d *flagDiffer) add(change FlagChange) {
+	d.changes = append(d.changes, change)
+}
+
+func (d *flagDiffer) field(entity ChangeEntity, id, segmentID int64, name, field string, old, new interface{}) {
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+	d.add(FlagChange{Entity: entity, Kind: ChangeModified, ID: id, SegmentID: segmentID, Name: name, Field: field, Old: old, New: new})
+}
+
+func (d *flagDiffer) variants(from, to []Variant) {
+	old := make(map[int64]Variant, len(from))
+	for _, v := range from {
+		old[v.Id] = v
+	}
+	seen := make(map[int64]bool, len(to))
+	for _, v := range to {
+		seen[v.Id] = true
+		prev, ok := old[v.Id]
+		if !ok {
+			d.add(FlagChange{Entity: EntityVariant, Kind: ChangeAdded, ID: v.Id, Name: v.Key, New: v})
+			continue
+		}
+		d.field(EntityVariant, v.Id, 0, prev.Key, "key", prev.Key, v.Key)
+		if !attachmentsEqual(prev.Attachment, v.Attachment) {
+			d.add(FlagChange{Entity: EntityVariant, Kind: ChangeModified, ID: v.Id, Name: v.Key, Field: "attachment", Old: prev.Attachment, New: v.Attachment})
+		}
+	}
+	for _, v := range from {
+		if !seen[v.Id] {
+			d.add(FlagChange{Entity: EntityVariant, Kind: ChangeRemoved, ID: v.Id, Name: v.Key, Old: v})
+		}
+	}
+}
+
+func (d *flagDiffer) segments(from, to []Segment) {
+	old := make(map[int64]Segment, len(from))
+	for _, s := range from {
+		old[s.Id] = s
+	}
+	seen := make(map[int64]bool, len(to))
+	for _, s := range to {
+		seen[s.Id] = true
+		prev, ok := old[s.Id]
+		if !ok {
+			d.add(FlagChange{Entity: EntitySegment, Kind: ChangeAdded, ID: s.Id, Name: s.Description, New: s})
+			d.constraints(s.Id, nil, s.Constraints)
+			d.distributions(s.Id, nil, s.Distributions)
+			continue
+		}
+		d.field(EntitySegment, s.Id, 0, s.Description, "description", prev.Description, s.Description)
+		d.field(EntitySegment, s.Id, 0, s.Description, "rank", prev.Rank, s.Rank)
+		d.field(EntitySegment, s.Id, 0, s.Description, "rolloutPercent", prev.RolloutPercent, s.RolloutPercent)
+		d.constraints(s.Id, prev.Constraints, s.Constraints)
+		d.distributions(s.Id, prev.Distributions, s.Distributions)
+	}
+	for _, s := range from {
+		if !seen[s.Id] {
+			d.add(FlagChange{Entity: EntitySegment, Kind: ChangeRemoved, ID: s.Id, Name: s.Description, Old: s})
+			d.constraints(s.Id, s.Constraints, nil)
+			d.distributions(s.Id, s.Distributions, nil)
+		}
+	}
+}
+
+func (d *flagDiffer) constraints(segmentID int64, from, to []Constraint) {
+	old := make(map[int64]Constraint, len(from))
+	for _, c := range from {
+		old[c.Id] = c
+	}
+	seen := make(map[int64]bool, len(to))
+	for _, c := range to {
+		seen[c.Id] = true
+		name := c.Property + " " + c.Operator
+		prev, ok := old[c.Id]
+		if !ok {
+			d.add(FlagChange{Entity: EntityConstraint, Kind: ChangeAdded, ID: c.Id, SegmentID: segmentID, Name: name, New: c})
+			continue
+		}
+		d.field(EntityConstraint, c.Id, segmentID, name, "property", prev.Property, c.Property)
+		d.field(EntityConstraint, c.Id, segmentID, name, "operator", prev.Operator, c.Operator)
+		d.field(EntityConstraint, c.Id, segmentID, name, "value", prev.Value, c.Value)
+	}
+	for _, c := range from {
+		if !seen[c.Id] {
+			d.add(FlagChange{Entity: EntityConstraint, Kind: ChangeRemoved, ID: c.Id, SegmentID: segmentID, Name: c.Property + " " + c.Operator, Old: c})
+		}
+	}
+}
+
+// distributions are matched by variant: the server replaces them as a set,
+// so their IDs change on every update
+func (d *flagDiffer) distributions(segmentID int64, from, to []Distribution) {
+	old := make(map[int64]Distribution, len(from))
+	for _, dist := range from {
+		old[dist.VariantID] = dist
+	}
+	seen := make(map[int64]bool, len(to))
+	for _, dist := range to {
+		seen[dist.VariantID] = true
+		name := d.distributionName(dist)
+		prev, ok := old[dist.VariantID]
+		if !ok {
+			d.add(FlagChange{Entity: EntityDistribution, Kind: ChangeAdded, ID: dist.Id, SegmentID: segmentID, Name: name, New: dist})
+			continue
+		}
+		d.field(EntityDistribution, dist.Id, segmentID, name, "percent", prev.Percent, dist.Percent)
+	}
+	for _, dist := range from {
+		if !seen[dist.VariantID] {
+			d.add(FlagChange{Entity: EntityDistribution, Kind: ChangeRemoved, ID: dist.Id, SegmentID: segmentID, Name: d.distributionName(dist), Old: dist})
+		}
+	}
+}
+
+func (d *flagDiffer) tags(from, to []Tag) {
+	old := make(map[string]bool, len(from))
+	for _, t := range from {
+		old[t.Value] = true
+	}
+	current := make(map[string]bool, len(to))
+	for _, t := range to {
+		current[t.Value] = true
+		if !old[t.Value] {
+			d.add(FlagChange{Entity: EntityTag, Kind: ChangeAdded, ID: t.Id, Name: t.Value, New: t})
+		}
+	}
+	for _, t := range from {
+		if !current[t.Value] {
+			d.add(FlagChange{Entity: EntityTag, Kind: ChangeRemoved, ID: t.Id, Name: t.Value, Old: t})
+		}
+	}
+}
+
+// distributionName labels a distribution by its variant key, looked up in the
+// flag's variants when the distribution does not carry it. A variant in neither
+// version of the flag is labelled "#<variant ID>".
+func (d *flagDiffer) distributionName(dist Distribution) string {
+	if key := nullableString(dist.VariantKey); key != "" {
+		return key
+	}
+	if key := d.variantKeys[dist.VariantID]; key != "" {
+		return key
+	}
+	return fmt.Sprintf("#%d", dist.VariantID)
+}
+
+// attachmentsEqual compares attachments, treating nil and empty as equal
+func attachmentsEqual(a, b map[string]interface{}) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// nullableString returns the value of v, or "" when unset or null
+func nullableString(v api.NullableString) string {
+	if p := v.Get(); p != nil {
+		return *p
+	}
+	return ""
+}
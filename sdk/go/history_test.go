@@ -0,0 +1,183 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagHistory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns revisions oldest first", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/flags/7/snapshots", r.URL.Path)
+			assert.Equal(t, "ASC", r.URL.Query().Get("sort"))
+			w.Write([]byte(`[
+				{"id": 12, "updatedBy": "alice", "updatedAt": "2026-03-02T10:00:00Z", "flag": {"id": 7, "key": "checkout", "description": "", "enabled": false, "dataRecordsEnabled": false}},
+				{"id": 11, "updatedBy": null, "updatedAt": "2026-03-01T10:00:00Z", "flag": {"id": 7, "key": "checkout", "description": "", "enabled": true, "dataRecordsEnabled": false}}
+			]`))
+		})
+		revisions, err := client.FlagHistory(ctx, 7)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		assert.Equal(t, int64(11), revisions[0].ID)
+		assert.Equal(t, "", revisions[0].UpdatedBy)
+		assert.Equal(t, "alice", revisions[1].UpdatedBy)
+		assert.True(t, revisions[0].Flag.Enabled)
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`"error finding flagID 7"`))
+		})
+		_, err := client.FlagHistory(ctx, 7)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrFlagNotFound))
+	})
+}
+
+func historyFlag() Flag {
+	return Flag{
+		Id:      7,
+		Key:     "checkout",
+		Enabled: true,
+		Variants: []api.Variant{
+			{Id: 1, FlagID: 7, Key: "control"},
+			{Id: 2, FlagID: 7, Key: "blue", Attachment: map[string]interface{}{"color": "#00f"}},
+		},
+		Segments: []api.Segment{
+			{
+				Id: 12, FlagID: 7, Description: "beta", Rank: 1, RolloutPercent: 20,
+				Distributions: []api.Distribution{
+					{Id: 100, SegmentID: 12, VariantID: 1, VariantKey: *api.NewNullableString(api.PtrString("control")), Percent: 50},
+					{Id: 101, SegmentID: 12, VariantID: 2, VariantKey: *api.NewNullableString(api.PtrString("blue")), Percent: 50},
+				},
+			},
+		},
+		Tags: []api.Tag{{Id: 1, Value: "payments"}},
+	}
+}
+
+func TestDiffFlags(t *testing.T) {
+	t.Run("identical flags", func(t *testing.T) {
+		a, b := historyFlag(), historyFlag()
+		assert.Empty(t, DiffFlags(&a, &b))
+	})
+
+	t.Run("semantic changes", func(t *testing.T) {
+		from, to := historyFlag(), historyFlag()
+		to.Enabled = false
+		to.Variants[1].Attachment = map[string]interface{}{"color": "#0000ff"}
+		to.Segments[0].RolloutPercent = 50
+		to.Segments[0].Constraints = []api.Constraint{{Id: 30, SegmentID: 12, Property: "country", Operator: "IN", Value: `["US"]`}}
+		// Distributions get new IDs on every replace; they are matched by variant
+		to.Segments[0].Distributions = []api.Distribution{
+			{Id: 102, SegmentID: 12, VariantID: 1, VariantKey: *api.NewNullableString(api.PtrString("control")), Percent: 30},
+			{Id: 103, SegmentID: 12, VariantID: 2, VariantKey: *api.NewNullableString(api.PtrString("blue")), Percent: 70},
+		}
+		to.Tags = []api.Tag{{Id: 2, Value: "checkout"}}
+
+		var lines []string
+		for _, c := range DiffFlags(&from, &to) {
+			lines = append(lines, c.String())
+		}
+		assert.Equal(t, []string{
+			"flag disabled",
+			"variant blue attachment changed",
+			"segment 12 rollout 20%→50%",
+			"constraint country IN added",
+			"distribution control 50%→30%",
+			"distribution blue 50%→70%",
+			"tag checkout added",
+			"tag payments removed",
+		}, lines)
+	})
+
+	t.Run("structured fields", func(t *testing.T) {
+		from, to := historyFlag(), historyFlag()
+		to.Segments = append(to.Segments, api.Segment{
+			Id: 13, FlagID: 7, Description: "everyone", Rank: 2, RolloutPercent: 100,
+			Constraints: []api.Constraint{{Id: 31, SegmentID: 13, Property: "tier", Operator: "EQ", Value: "gold"}},
+		})
+		to.Variants = to.Variants[:1]
+
+		changes := DiffFlags(&from, &to)
+		require.Len(t, changes, 3)
+		assert.Equal(t, FlagChange{Entity: EntityVariant, Kind: ChangeRemoved, ID: 2, Name: "blue", Old: from.Variants[1]}, changes[0])
+		assert.Equal(t, EntitySegment, changes[1].Entity)
+		assert.Equal(t, ChangeAdded, changes[1].Kind)
+		assert.Equal(t, int64(13), changes[1].ID)
+		assert.Equal(t, EntityConstraint, changes[2].Entity)
+		assert.Equal(t, int64(13), changes[2].SegmentID)
+		assert.Equal(t, "tier EQ", changes[2].Name)
+	})
+
+	t.Run("variants are labelled by key", func(t *testing.T) {
+		from, to := historyFlag(), historyFlag()
+		to.Variants[1].Key = "green"
+		from.Segments[0].Distributions[1].VariantKey = api.NullableString{}
+		to.Segments[0].Distributions = []api.Distribution{
+			{Id: 102, SegmentID: 12, VariantID: 1, Percent: 40},
+			{Id: 103, SegmentID: 12, VariantID: 2, Percent: 60},
+			{Id: 104, SegmentID: 12, VariantID: 9, Percent: 0},
+		}
+
+		var lines []string
+		for _, c := range DiffFlags(&from, &to) {
+			lines = append(lines, c.String())
+		}
+		assert.Equal(t, []string{
+			`variant blue key "blue"→"green"`,
+			"distribution control 50%→40%",
+			"distribution green 50%→60%",
+			"distribution #9 0% added",
+		}, lines)
+	})
+
+	t.Run("tags keep their ID", func(t *testing.T) {
+		from, to := historyFlag(), historyFlag()
+		to.Tags = nil
+		changes := DiffFlags(&from, &to)
+		require.Len(t, changes, 1)
+		assert.Equal(t, int64(1), changes[0].ID)
+	})
+
+	t.Run("modified constraint value", func(t *testing.T) {
+		from, to := historyFlag(), historyFlag()
+		from.Segments[0].Constraints = []api.Constraint{{Id: 30, Property: "country", Operator: "EQ", Value: "US"}}
+		to.Segments[0].Constraints = []api.Constraint{{Id: 30, Property: "country", Operator: "EQ", Value: "CA"}}
+		changes := DiffFlags(&from, &to)
+		require.Len(t, changes, 1)
+		assert.Equal(t, `constraint country EQ value "US"→"CA"`, changes[0].String())
+	})
+}
+
+func TestDiffHistory(t *testing.T) {
+	created := historyFlag()
+	disabled := historyFlag()
+	disabled.Enabled = false
+	revisions := []FlagRevision{
+		{ID: 1, UpdatedBy: "bob", UpdatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Flag: created},
+		{ID: 2, UpdatedBy: "alice", UpdatedAt: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Flag: disabled},
+	}
+
+	diffs := DiffHistory(revisions)
+	require.Len(t, diffs, 2)
+
+	assert.Equal(t, int64(0), diffs[0].From)
+	assert.Equal(t, "bob", diffs[0].UpdatedBy)
+	assert.Contains(t, diffs[0].String(), `flag key ""→"checkout" by bob`)
+	assert.Contains(t, diffs[0].String(), "segment 12 added by bob")
+
+	assert.Equal(t, int64(1), diffs[1].From)
+	assert.Equal(t, int64(2), diffs[1].To)
+	assert.Equal(t, "flag disabled by alice", diffs[1].String())
+}
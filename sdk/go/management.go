@@ -0,0 +1,374 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+)
+
+// CreateFlagInput is the input for CreateFlag
+type CreateFlagInput struct {
+	// Key is the unique flag key (optional, generated by the server if empty)
+	Key         string
+	Description string
+	// Template creates the flag with predefined variants and segments (e.g. "simple_boolean_flag")
+	Template string
+}
+
+// UpdateFlagInput is the input for UpdateFlag. Nil fields are left unchanged.
+type UpdateFlagInput struct {
+	Key                *string
+	Description        *string
+	DataRecordsEnabled *bool
+	EntityType         *string
+	Notes              *string
+}
+
+// SegmentInput is the input for CreateSegment and UpdateSegment
+type SegmentInput struct {
+	Description    string
+	RolloutPercent int
+}
+
+// ConstraintInput is the input for CreateConstraint and UpdateConstraint
+type ConstraintInput struct {
+	Property string
+	Operator string
+	Value    string
+}
+
+// VariantInput is the input for CreateVariant and UpdateVariant
+type VariantInput struct {
+	Key        string
+	Attachment map[string]interface{}
+}
+
+// DistributionInput is one entry of ReplaceDistributions
+type DistributionInput struct {
+	VariantID  int64
+	VariantKey string
+	Percent    int
+}
+
+// ListTagsOptions represents options for listing tags
+type ListTagsOptions struct {
+	Limit     int
+	Offset    int
+	ValueLike string
+}
+
+// convertRequestError converts errors of management calls. A 404 is reported as
+// FlagNotFoundError for calls on the flag itself and NotFoundError for its children.
+func convertRequestError(resp *http.Response, err error, context string, flagScoped bool) error {
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode < http.StatusMultipleChoices {
+		return convertError(resp, err, context)
+	}
+	apiErr := newAPIError(resp, err)
+	if resp.StatusCode == http.StatusNotFound {
+		if flagScoped {
+			return NewFlagNotFoundError(context, apiErr)
+		}
+		return NewNotFoundError(context, apiErr)
+	}
+	return NewRequestError(context, apiErr)
+}
+
+// CreateFlag creates a new flag
+func (c *Client) CreateFlag(ctx context.Context, input *CreateFlagInput) (*Flag, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	req := api.NewCreateFlagRequest(input.Description)
+	if input.Key != "" {
+		req.SetKey(input.Key)
+	}
+	if input.Template != "" {
+		req.SetTemplate(input.Template)
+	}
+	flag, resp, err := c.apiClient.FlagAPI.CreateFlag(ctx).CreateFlagRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, "failed to create flag", false)
+	}
+	return flag, nil
+}
+
+// UpdateFlag updates flag properties
+func (c *Client) UpdateFlag(ctx context.Context, flagID int64, input *UpdateFlagInput) (*Flag, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	req := api.NewPutFlagRequest()
+	if input.Key != nil {
+		req.SetKey(*input.Key)
+	}
+	if input.Description != nil {
+		req.SetDescription(*input.Description)
+	}
+	if input.DataRecordsEnabled != nil {
+		req.SetDataRecordsEnabled(*input.DataRecordsEnabled)
+	}
+	if input.EntityType != nil {
+		req.SetEntityType(*input.EntityType)
+	}
+	if input.Notes != nil {
+		req.SetNotes(*input.Notes)
+	}
+	flag, resp, err := c.apiClient.FlagAPI.PutFlag(ctx, flagID).PutFlagRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to update flag %d", flagID), true)
+	}
+	return flag, nil
+}
+
+// SetFlagEnabled enables or disables a flag
+func (c *Client) SetFlagEnabled(ctx context.Context, flagID int64, enabled bool) (*Flag, error) {
+	flag, resp, err := c.apiClient.FlagAPI.SetFlagEnabled(ctx, flagID).
+		SetFlagEnabledRequest(*api.NewSetFlagEnabledRequest(enabled)).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to set flag %d enabled", flagID), true)
+	}
+	return flag, nil
+}
+
+// DeleteFlag archives a flag (soft delete). Use RestoreFlag to undo.
+func (c *Client) DeleteFlag(ctx context.Context, flagID int64) error {
+	resp, err := c.apiClient.FlagAPI.DeleteFlag(ctx, flagID).Execute()
+	return convertRequestError(resp, err, fmt.Sprintf("failed to delete flag %d", flagID), true)
+}
+
+// RestoreFlag restores an archived flag
+func (c *Client) RestoreFlag(ctx context.Context, flagID int64) (*Flag, error) {
+	flag, resp, err := c.apiClient.FlagAPI.RestoreFlag(ctx, flagID).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to restore flag %d", flagID), true)
+	}
+	return flag, nil
+}
+
+// PermanentlyDeleteFlag removes a flag and all its data. This cannot be undone.
+func (c *Client) PermanentlyDeleteFlag(ctx context.Context, flagID int64) error {
+	resp, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/flags/%d/permanent", flagID), nil, nil, nil)
+	return convertRequestError(resp, err, fmt.Sprintf("failed to permanently delete flag %d", flagID), true)
+}
+
+// ListEntityTypes returns all entity types used by flags
+func (c *Client) ListEntityTypes(ctx context.Context) ([]string, error) {
+	entityTypes, resp, err := c.apiClient.FlagAPI.GetFlagEntityTypes(ctx).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, "failed to list entity types", false)
+	}
+	return entityTypes, nil
+}
+
+// ListSegments returns the segments of a flag ordered by rank
+func (c *Client) ListSegments(ctx context.Context, flagID int64) ([]Segment, error) {
+	segments, resp, err := c.apiClient.SegmentAPI.FindSegments(ctx, flagID).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to list segments of flag %d", flagID), true)
+	}
+	return segments, nil
+}
+
+// CreateSegment adds a segment to a flag
+func (c *Client) CreateSegment(ctx context.Context, flagID int64, input *SegmentInput) (*Segment, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	req := api.NewCreateSegmentRequest(input.Description, int64(input.RolloutPercent))
+	segment, resp, err := c.apiClient.SegmentAPI.CreateSegment(ctx, flagID).CreateSegmentRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to create segment for flag %d", flagID), true)
+	}
+	return segment, nil
+}
+
+// UpdateSegment updates a segment's description and rollout percent
+func (c *Client) UpdateSegment(ctx context.Context, flagID, segmentID int64, input *SegmentInput) (*Segment, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	req := api.NewPutSegmentRequest(input.Description, int64(input.RolloutPercent))
+	segment, resp, err := c.apiClient.SegmentAPI.PutSegment(ctx, flagID, segmentID).PutSegmentRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to update segment %d", segmentID), false)
+	}
+	return segment, nil
+}
+
+// DeleteSegment removes a segment from a flag
+func (c *Client) DeleteSegment(ctx context.Context, flagID, segmentID int64) error {
+	resp, err := c.apiClient.SegmentAPI.DeleteSegment(ctx, flagID, segmentID).Execute()
+	return convertRequestError(resp, err, fmt.Sprintf("failed to delete segment %d", segmentID), false)
+}
+
+// ReorderSegments sets the evaluation order of a flag's segments (first ID is evaluated first)
+func (c *Client) ReorderSegments(ctx context.Context, flagID int64, segmentIDs []int64) error {
+	req := api.NewPutSegmentReorderRequest(segmentIDs)
+	resp, err := c.apiClient.SegmentAPI.PutSegmentReorder(ctx, flagID).PutSegmentReorderRequest(*req).Execute()
+	return convertRequestError(resp, err, fmt.Sprintf("failed to reorder segments of flag %d", flagID), true)
+}
+
+// ListConstraints returns the constraints of a segment
+func (c *Client) ListConstraints(ctx context.Context, flagID, segmentID int64) ([]Constraint, error) {
+	constraints, resp, err := c.apiClient.ConstraintAPI.FindConstraints(ctx, flagID, segmentID).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to list constraints of segment %d", segmentID), false)
+	}
+	return constraints, nil
+}
+
+// CreateConstraint adds a constraint to a segment
+func (c *Client) CreateConstraint(ctx context.Context, flagID, segmentID int64, input *ConstraintInput) (*Constraint, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	req := api.NewCreateConstraintRequest(input.Property, input.Operator, input.Value)
+	constraint, resp, err := c.apiClient.ConstraintAPI.CreateConstraint(ctx, flagID, segmentID).CreateConstraintRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to create constraint for segment %d", segmentID), false)
+	}
+	return constraint, nil
+}
+
+// UpdateConstraint replaces a constraint's property, operator and value
+func (c *Client) UpdateConstraint(ctx context.Context, flagID, segmentID, constraintID int64, input *ConstraintInput) (*Constraint, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	req := api.NewPutConstraintRequest(input.Property, input.Operator, input.Value)
+	constraint, resp, err := c.apiClient.ConstraintAPI.PutConstraint(ctx, flagID, segmentID, constraintID).PutConstraintRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to update constraint %d", constraintID), false)
+	}
+	return constraint, nil
+}
+
+// DeleteConstraint removes a constraint from a segment
+func (c *Client) DeleteConstraint(ctx context.Context, flagID, segmentID, constraintID int64) error {
+	resp, err := c.apiClient.ConstraintAPI.DeleteConstraint(ctx, flagID, segmentID, constraintID).Execute()
+	return convertRequestError(resp, err, fmt.Sprintf("failed to delete constraint %d", constraintID), false)
+}
+
+// ListDistributions returns the variant distribution of a segment
+func (c *Client) ListDistributions(ctx context.Context, flagID, segmentID int64) ([]Distribution, error) {
+	distributions, resp, err := c.apiClient.DistributionAPI.FindDistributions(ctx, flagID, segmentID).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to list distributions of segment %d", segmentID), false)
+	}
+	return distributions, nil
+}
+
+// ReplaceDistributions replaces the variant distribution of a segment. Percents must sum to 100.
+func (c *Client) ReplaceDistributions(ctx context.Context, flagID, segmentID int64, inputs []DistributionInput) ([]Distribution, error) {
+	distributions := make([]api.DistributionRequest, len(inputs))
+	for i, in := range inputs {
+		d := api.NewDistributionRequest(in.VariantID, int64(in.Percent))
+		if in.VariantKey != "" {
+			d.SetVariantKey(in.VariantKey)
+		}
+		distributions[i] = *d
+	}
+	req := api.NewPutDistributionsRequest(distributions)
+	result, resp, err := c.apiClient.DistributionAPI.PutDistributions(ctx, flagID, segmentID).PutDistributionsRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to replace distributions of segment %d", segmentID), false)
+	}
+	return result, nil
+}
+
+// ListVariants returns the variants of a flag
+func (c *Client) ListVariants(ctx context.Context, flagID int64) ([]Variant, error) {
+	variants, resp, err := c.apiClient.VariantAPI.FindVariants(ctx, flagID).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to list variants of flag %d", flagID), true)
+	}
+	return variants, nil
+}
+
+// CreateVariant adds a variant to a flag
+func (c *Client) CreateVariant(ctx context.Context, flagID int64, input *VariantInput) (*Variant, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	req := api.NewCreateVariantRequest(input.Key)
+	if input.Attachment != nil {
+		req.SetAttachment(input.Attachment)
+	}
+	variant, resp, err := c.apiClient.VariantAPI.CreateVariant(ctx, flagID).CreateVariantRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to create variant for flag %d", flagID), true)
+	}
+	return variant, nil
+}
+
+// UpdateVariant replaces a variant's key and attachment
+func (c *Client) UpdateVariant(ctx context.Context, flagID, variantID int64, input *VariantInput) (*Variant, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	req := api.NewPutVariantRequest(input.Key)
+	if input.Attachment != nil {
+		req.SetAttachment(input.Attachment)
+	}
+	variant, resp, err := c.apiClient.VariantAPI.PutVariant(ctx, flagID, variantID).PutVariantRequest(*req).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to update variant %d", variantID), false)
+	}
+	return variant, nil
+}
+
+// DeleteVariant removes a variant from a flag
+func (c *Client) DeleteVariant(ctx context.Context, flagID, variantID int64) error {
+	resp, err := c.apiClient.VariantAPI.DeleteVariant(ctx, flagID, variantID).Execute()
+	return convertRequestError(resp, err, fmt.Sprintf("failed to delete variant %d", variantID), false)
+}
+
+// ListTags returns all tags
+func (c *Client) ListTags(ctx context.Context, opts *ListTagsOptions) ([]Tag, error) {
+	req := c.apiClient.TagAPI.FindAllTags(ctx)
+	if opts != nil {
+		if opts.Limit > 0 {
+			req = req.Limit(int64(opts.Limit))
+		}
+		if opts.Offset > 0 {
+			req = req.Offset(int64(opts.Offset))
+		}
+		if opts.ValueLike != "" {
+			req = req.ValueLike(opts.ValueLike)
+		}
+	}
+	tags, resp, err := req.Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, "failed to list tags", false)
+	}
+	return tags, nil
+}
+
+// ListFlagTags returns the tags of a flag
+func (c *Client) ListFlagTags(ctx context.Context, flagID int64) ([]Tag, error) {
+	tags, resp, err := c.apiClient.TagAPI.FindFlagTags(ctx, flagID).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to list tags of flag %d", flagID), true)
+	}
+	return tags, nil
+}
+
+// AddFlagTag tags a flag, creating the tag if it does not exist
+func (c *Client) AddFlagTag(ctx context.Context, flagID int64, value string) (*Tag, error) {
+	tag, resp, err := c.apiClient.TagAPI.CreateFlagTag(ctx, flagID).CreateTagRequest(*api.NewCreateTagRequest(value)).Execute()
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to tag flag %d", flagID), true)
+	}
+	return tag, nil
+}
+
+// RemoveFlagTag removes a tag from a flag
+func (c *Client) RemoveFlagTag(ctx context.Context, flagID, tagID int64) error {
+	resp, err := c.apiClient.TagAPI.DeleteFlagTag(ctx, flagID, tagID).Execute()
+	return convertRequestError(resp, err, fmt.Sprintf("failed to remove tag %d from flag %d", tagID, flagID), false)
+}
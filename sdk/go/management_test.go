@@ -0,0 +1,251 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// managementHandler is called with each request and its decoded JSON body
+type managementHandler func(w http.ResponseWriter, r *http.Request, body map[string]interface{})
+
+// startManagementServer serves handler and returns the server URL
+func startManagementServer(t *testing.T, handler managementHandler) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		handler(w, r, body)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func newManagementServer(t *testing.T, handler managementHandler) *Client {
+	t.Helper()
+	client, err := NewClient(startManagementServer(t, handler), WithMaxRetries(0))
+	require.NoError(t, err)
+	return client
+}
+
+func TestFlagManagement(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateFlag", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/flags", r.URL.Path)
+			assert.Equal(t, "checkout_v2", body["key"])
+			assert.Equal(t, "New checkout", body["description"])
+			assert.NotContains(t, body, "template")
+			json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: "checkout_v2", Description: "New checkout"})
+		})
+		flag, err := client.CreateFlag(ctx, &CreateFlagInput{Key: "checkout_v2", Description: "New checkout"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), flag.Id)
+	})
+
+	t.Run("CreateFlag nil input", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {})
+		_, err := client.CreateFlag(ctx, nil)
+		assert.IsType(t, &InvalidConfigError{}, err)
+	})
+
+	t.Run("UpdateFlag sends only set fields", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "/flags/10", r.URL.Path)
+			assert.Equal(t, map[string]interface{}{"notes": "owned by payments"}, body)
+			json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: "checkout_v2"})
+		})
+		_, err := client.UpdateFlag(ctx, 10, &UpdateFlagInput{Notes: StringPtr("owned by payments")})
+		require.NoError(t, err)
+	})
+
+	t.Run("SetFlagEnabled", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/flags/10/enabled", r.URL.Path)
+			assert.Equal(t, true, body["enabled"])
+			json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: "checkout_v2", Enabled: true})
+		})
+		flag, err := client.SetFlagEnabled(ctx, 10, true)
+		require.NoError(t, err)
+		assert.True(t, flag.Enabled)
+	})
+
+	t.Run("DeleteFlag, RestoreFlag and PermanentlyDeleteFlag", func(t *testing.T) {
+		var calls []string
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			calls = append(calls, r.Method+" "+r.URL.Path)
+			if r.URL.Path == "/flags/10/restore" {
+				json.NewEncoder(w).Encode(api.Flag{Id: 10, Key: "checkout_v2"})
+			}
+		})
+		require.NoError(t, client.DeleteFlag(ctx, 10))
+		_, err := client.RestoreFlag(ctx, 10)
+		require.NoError(t, err)
+		require.NoError(t, client.PermanentlyDeleteFlag(ctx, 10))
+		assert.Equal(t, []string{"DELETE /flags/10", "PUT /flags/10/restore", "DELETE /flags/10/permanent"}, calls)
+	})
+
+	t.Run("PermanentlyDeleteFlag not found", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Flag not found"}`))
+		})
+		err := client.PermanentlyDeleteFlag(ctx, 10)
+		require.Error(t, err)
+		assert.IsType(t, &FlagNotFoundError{}, err)
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "Flag not found", apiErr.Message)
+		assert.Equal(t, http.MethodDelete, apiErr.Method)
+	})
+
+	t.Run("ListEntityTypes", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/flags/entity_types", r.URL.Path)
+			json.NewEncoder(w).Encode([]string{"user", "device"})
+		})
+		types, err := client.ListEntityTypes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user", "device"}, types)
+	})
+}
+
+func TestSegmentManagement(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateSegment and ReorderSegments", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			switch r.URL.Path {
+			case "/flags/1/segments":
+				assert.Equal(t, float64(25), body["rolloutPercent"])
+				json.NewEncoder(w).Encode(api.Segment{Id: 5, FlagID: 1, Description: "beta", RolloutPercent: 25})
+			case "/flags/1/segments/reorder":
+				assert.Equal(t, http.MethodPut, r.Method)
+				assert.Equal(t, []interface{}{float64(5), float64(3)}, body["segmentIDs"])
+			default:
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+		})
+		segment, err := client.CreateSegment(ctx, 1, &SegmentInput{Description: "beta", RolloutPercent: 25})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), segment.Id)
+		require.NoError(t, client.ReorderSegments(ctx, 1, []int64{5, 3}))
+	})
+
+	t.Run("UpdateSegment not found", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"segment not found"}`))
+		})
+		_, err := client.UpdateSegment(ctx, 1, 99, &SegmentInput{Description: "x", RolloutPercent: 100})
+		require.Error(t, err)
+		assert.IsType(t, &NotFoundError{}, err)
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.False(t, errors.Is(err, ErrFlagNotFound))
+	})
+
+	t.Run("CreateConstraint", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/flags/1/segments/5/constraints", r.URL.Path)
+			assert.Equal(t, "country", body["property"])
+			assert.Equal(t, "IN", body["operator"])
+			json.NewEncoder(w).Encode(api.Constraint{Id: 7, SegmentID: 5, Property: "country", Operator: "IN", Value: `["US","CA"]`})
+		})
+		constraint, err := client.CreateConstraint(ctx, 1, 5, &ConstraintInput{Property: "country", Operator: "IN", Value: `["US","CA"]`})
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), constraint.Id)
+	})
+
+	t.Run("ReplaceDistributions", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "/flags/1/segments/5/distributions", r.URL.Path)
+			assert.Len(t, body["distributions"], 2)
+			json.NewEncoder(w).Encode([]api.Distribution{
+				{Id: 1, SegmentID: 5, VariantID: 1, Percent: 50},
+				{Id: 2, SegmentID: 5, VariantID: 2, Percent: 50},
+			})
+		})
+		distributions, err := client.ReplaceDistributions(ctx, 1, 5, []DistributionInput{
+			{VariantID: 1, VariantKey: "control", Percent: 50},
+			{VariantID: 2, VariantKey: "treatment", Percent: 50},
+		})
+		require.NoError(t, err)
+		assert.Len(t, distributions, 2)
+	})
+
+	t.Run("validation error returns RequestError", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"percentages must add up to 100"}`))
+		})
+		_, err := client.ReplaceDistributions(ctx, 1, 5, []DistributionInput{{VariantID: 1, Percent: 40}})
+		require.Error(t, err)
+		assert.IsType(t, &RequestError{}, err)
+		assert.True(t, errors.Is(err, ErrBadRequest))
+	})
+}
+
+func TestVariantAndTagManagement(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateVariant with attachment", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/flags/1/variants", r.URL.Path)
+			assert.Equal(t, "blue", body["key"])
+			assert.Equal(t, map[string]interface{}{"color": "#00f"}, body["attachment"])
+			json.NewEncoder(w).Encode(api.Variant{Id: 3, FlagID: 1, Key: "blue"})
+		})
+		variant, err := client.CreateVariant(ctx, 1, &VariantInput{Key: "blue", Attachment: map[string]interface{}{"color": "#00f"}})
+		require.NoError(t, err)
+		assert.Equal(t, "blue", variant.Key)
+	})
+
+	t.Run("DeleteVariant", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodDelete, r.Method)
+			assert.Equal(t, "/flags/1/variants/3", r.URL.Path)
+		})
+		require.NoError(t, client.DeleteVariant(ctx, 1, 3))
+	})
+
+	t.Run("ListTags with options", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/tags", r.URL.Path)
+			assert.Equal(t, "pay", r.URL.Query().Get("value_like"))
+			json.NewEncoder(w).Encode([]api.Tag{{Id: 1, Value: "payments"}})
+		})
+		tags, err := client.ListTags(ctx, &ListTagsOptions{ValueLike: "pay"})
+		require.NoError(t, err)
+		assert.Equal(t, "payments", tags[0].Value)
+	})
+
+	t.Run("AddFlagTag and RemoveFlagTag", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			switch r.Method {
+			case http.MethodPost:
+				assert.Equal(t, "/flags/1/tags", r.URL.Path)
+				assert.Equal(t, "payments", body["value"])
+				json.NewEncoder(w).Encode(api.Tag{Id: 4, Value: "payments"})
+			case http.MethodDelete:
+				assert.Equal(t, "/flags/1/tags/4", r.URL.Path)
+			}
+		})
+		tag, err := client.AddFlagTag(ctx, 1, "payments")
+		require.NoError(t, err)
+		require.NoError(t, client.RemoveFlagTag(ctx, 1, tag.Id))
+	})
+}
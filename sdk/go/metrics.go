@@ -0,0 +1,237 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMetricsBucket   = time.Hour
+	defaultMetricsTopLimit = 10
+)
+
+// MetricsWindow is the time range of a metrics query. Both ends are inclusive.
+type MetricsWindow struct {
+	Start time.Time
+	End   time.Time
+	// Bucket is the resolution of time series (default: 1h)
+	Bucket time.Duration
+}
+
+// LastMetricsWindow returns the window ending now and spanning d
+func LastMetricsWindow(d time.Duration) MetricsWindow {
+	end := time.Now()
+	return MetricsWindow{Start: end.Add(-d), End: end}
+}
+
+// TimeSeriesPoint is the number of evaluations in the bucket starting at Time
+type TimeSeriesPoint struct {
+	Time  time.Time
+	Count int64
+}
+
+// FlagEvaluationStats is the number of evaluations of a flag over time
+type FlagEvaluationStats struct {
+	FlagID          int64
+	EvaluationCount int64
+	// TimeSeries has a point for every bucket with evaluations, oldest first
+	TimeSeries []TimeSeriesPoint
+}
+
+// LastEvaluated returns the start of the latest bucket with evaluations, so it is
+// accurate to the window's Bucket. ok is false if the flag was not evaluated.
+func (s *FlagEvaluationStats) LastEvaluated() (t time.Time, ok bool) {
+	if len(s.TimeSeries) == 0 {
+		return time.Time{}, false
+	}
+	return s.TimeSeries[len(s.TimeSeries)-1].Time, true
+}
+
+// FlagUsage is the number of evaluations of a flag per client. Clients identify
+// themselves with the X-Client-Id header.
+type FlagUsage struct {
+	FlagID int64
+	Start  time.Time
+	End    time.Time
+	// TotalEvaluationCount includes evaluations without a client ID
+	TotalEvaluationCount int64
+	// Clients is sorted by evaluation count, highest first
+	Clients []ClientUsage
+}
+
+// ClientUsage is the number of evaluations made by one client
+type ClientUsage struct {
+	ClientID        string
+	EvaluationCount int64
+}
+
+// MetricsOverview summarizes evaluations across all flags
+type MetricsOverview struct {
+	TotalEvaluations int64
+	UniqueFlags      int
+	// TopFlags are the most evaluated flags, highest first
+	TopFlags   []TopFlag
+	TimeSeries []TimeSeriesPoint
+}
+
+// TopFlag is a flag and its number of evaluations
+type TopFlag struct {
+	FlagID          int64
+	FlagKey         string
+	EvaluationCount int64
+}
+
+// Wire formats; timestamps are Unix milliseconds
+
+type timeSeriesJSON []struct {
+	Timestamp int64 `json:"timestamp"`
+	Count     int64 `json:"count"`
+}
+
+func (ts timeSeriesJSON) points() []TimeSeriesPoint {
+	points := make([]TimeSeriesPoint, len(ts))
+	for i, p := range ts {
+		points[i] = TimeSeriesPoint{Time: time.UnixMilli(p.Timestamp), Count: p.Count}
+	}
+	return points
+}
+
+type flagEvaluationStatsJSON struct {
+	FlagID          int64          `json:"flagId"`
+	EvaluationCount int64          `json:"evaluationCount"`
+	TimeSeries      timeSeriesJSON `json:"timeSeries"`
+}
+
+type flagUsageJSON struct {
+	FlagID               int64 `json:"flagId"`
+	StartMs              int64 `json:"startMs"`
+	EndMs                int64 `json:"endMs"`
+	TotalEvaluationCount int64 `json:"totalEvaluationCount"`
+	Clients              []struct {
+		ClientID        string `json:"clientId"`
+		EvaluationCount int64  `json:"evaluationCount"`
+	} `json:"clients"`
+}
+
+type metricsOverviewJSON struct {
+	TotalEvaluations int64 `json:"totalEvaluations"`
+	UniqueFlags      int   `json:"uniqueFlags"`
+	TopFlags         []struct {
+		FlagID          int64  `json:"flagId"`
+		FlagKey         string `json:"flagKey"`
+		EvaluationCount int64  `json:"evaluationCount"`
+	} `json:"topFlags"`
+	TimeSeries timeSeriesJSON `json:"timeSeries"`
+}
+
+// query validates the window and encodes it as query parameters
+func (w MetricsWindow) query(withBucket bool) (url.Values, error) {
+	if w.Start.IsZero() || w.End.IsZero() {
+		return nil, NewInvalidConfigError("metrics window start and end are required", nil)
+	}
+	if w.End.Before(w.Start) {
+		return nil, NewInvalidConfigError("metrics window end is before start", nil)
+	}
+	query := url.Values{
+		"start": {strconv.FormatInt(w.Start.UnixMilli(), 10)},
+		"end":   {strconv.FormatInt(w.End.UnixMilli(), 10)},
+	}
+	if withBucket {
+		bucket := w.Bucket
+		if bucket == 0 {
+			bucket = defaultMetricsBucket
+		}
+		if bucket < time.Millisecond {
+			return nil, NewInvalidConfigError("metrics bucket must be at least 1ms", nil)
+		}
+		query.Set("timeBucketMs", strconv.FormatInt(bucket.Milliseconds(), 10))
+	}
+	return query, nil
+}
+
+// GetFlagEvaluationStats returns the evaluations of a flag in window. The server
+// counts evaluations per flag only, so there is no per-variant series; compare
+// variants with tracked events instead (GetFunnelByVariant). Evaluation times are
+// only known to the window's Bucket, see LastEvaluated.
+func (c *Client) GetFlagEvaluationStats(ctx context.Context, flagID int64, window MetricsWindow) (*FlagEvaluationStats, error) {
+	if err := c.requireFeature(FeatureMetrics); err != nil {
+		return nil, err
+	}
+	query, err := window.query(true)
+	if err != nil {
+		return nil, err
+	}
+	var out flagEvaluationStatsJSON
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/flags/%d/evaluation-stats", flagID), query, nil, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to get evaluation stats of flag %d", flagID), true)
+	}
+	return &FlagEvaluationStats{
+		FlagID:          out.FlagID,
+		EvaluationCount: out.EvaluationCount,
+		TimeSeries:      out.TimeSeries.points(),
+	}, nil
+}
+
+// GetFlagUsage returns the evaluations of a flag in window per client. window.Bucket
+// is not used.
+func (c *Client) GetFlagUsage(ctx context.Context, flagID int64, window MetricsWindow) (*FlagUsage, error) {
+	if err := c.requireFeature(FeatureMetrics); err != nil {
+		return nil, err
+	}
+	query, err := window.query(false)
+	if err != nil {
+		return nil, err
+	}
+	var out flagUsageJSON
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/flags/%d/usage", flagID), query, nil, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to get usage of flag %d", flagID), true)
+	}
+	usage := &FlagUsage{
+		FlagID:               out.FlagID,
+		Start:                time.UnixMilli(out.StartMs),
+		End:                  time.UnixMilli(out.EndMs),
+		TotalEvaluationCount: out.TotalEvaluationCount,
+		Clients:              make([]ClientUsage, len(out.Clients)),
+	}
+	for i, client := range out.Clients {
+		usage.Clients[i] = ClientUsage{ClientID: client.ClientID, EvaluationCount: client.EvaluationCount}
+	}
+	return usage, nil
+}
+
+// GetMetricsOverview returns the evaluations of all flags in window and the topLimit
+// most evaluated flags (default: 10)
+func (c *Client) GetMetricsOverview(ctx context.Context, window MetricsWindow, topLimit int) (*MetricsOverview, error) {
+	if err := c.requireFeature(FeatureMetrics); err != nil {
+		return nil, err
+	}
+	query, err := window.query(true)
+	if err != nil {
+		return nil, err
+	}
+	if topLimit <= 0 {
+		topLimit = defaultMetricsTopLimit
+	}
+	query.Set("topLimit", strconv.Itoa(topLimit))
+	var out metricsOverviewJSON
+	resp, err := c.do(ctx, http.MethodGet, "/metrics/overview", query, nil, &out)
+	if err != nil {
+		return nil, c.featureError(FeatureMetrics, resp, err, "failed to get metrics overview")
+	}
+	overview := &MetricsOverview{
+		TotalEvaluations: out.TotalEvaluations,
+		UniqueFlags:      out.UniqueFlags,
+		TopFlags:         make([]TopFlag, len(out.TopFlags)),
+		TimeSeries:       out.TimeSeries.points(),
+	}
+	for i, f := range out.TopFlags {
+		overview.TopFlags[i] = TopFlag{FlagID: f.FlagID, FlagKey: f.FlagKey, EvaluationCount: f.EvaluationCount}
+	}
+	return overview, nil
+}
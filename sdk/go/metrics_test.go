@@ -0,0 +1,102 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	window := MetricsWindow{Start: start, End: start.Add(24 * time.Hour), Bucket: 6 * time.Hour}
+
+	t.Run("GetFlagEvaluationStats", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/flags/7/evaluation-stats", r.URL.Path)
+			assert.Equal(t, "1772323200000", r.URL.Query().Get("start"))
+			assert.Equal(t, "1772409600000", r.URL.Query().Get("end"))
+			assert.Equal(t, "21600000", r.URL.Query().Get("timeBucketMs"))
+			w.Write([]byte(`{"flagId": 7, "evaluationCount": 15, "timeSeries": [
+				{"timestamp": 1772323200000, "count": 10}, {"timestamp": 1772344800000, "count": 5}]}`))
+		})
+		stats, err := client.GetFlagEvaluationStats(ctx, 7, window)
+		require.NoError(t, err)
+		assert.Equal(t, int64(15), stats.EvaluationCount)
+		require.Len(t, stats.TimeSeries, 2)
+		assert.True(t, stats.TimeSeries[0].Time.Equal(start))
+		last, ok := stats.LastEvaluated()
+		require.True(t, ok)
+		assert.True(t, last.Equal(start.Add(6*time.Hour)))
+	})
+
+	t.Run("never evaluated", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			w.Write([]byte(`{"flagId": 7, "evaluationCount": 0, "timeSeries": []}`))
+		})
+		stats, err := client.GetFlagEvaluationStats(ctx, 7, LastMetricsWindow(30*24*time.Hour))
+		require.NoError(t, err)
+		_, ok := stats.LastEvaluated()
+		assert.False(t, ok)
+	})
+
+	t.Run("GetFlagUsage", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/flags/7/usage", r.URL.Path)
+			assert.NotContains(t, r.URL.Query(), "timeBucketMs")
+			w.Write([]byte(`{"flagId": 7, "startMs": 1772323200000, "endMs": 1772409600000, "totalEvaluationCount": 12,
+				"clients": [{"clientId": "checkout-service", "evaluationCount": 9}, {"clientId": "web", "evaluationCount": 2}]}`))
+		})
+		usage, err := client.GetFlagUsage(ctx, 7, window)
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), usage.TotalEvaluationCount)
+		assert.True(t, usage.Start.Equal(start))
+		assert.Equal(t, []ClientUsage{{"checkout-service", 9}, {"web", 2}}, usage.Clients)
+	})
+
+	t.Run("GetMetricsOverview", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "/metrics/overview", r.URL.Path)
+			assert.Equal(t, "10", r.URL.Query().Get("topLimit"))
+			assert.Equal(t, "3600000", r.URL.Query().Get("timeBucketMs"))
+			w.Write([]byte(`{"totalEvaluations": 40, "uniqueFlags": 2,
+				"topFlags": [{"flagId": 7, "flagKey": "checkout", "evaluationCount": 30}, {"flagId": 8, "flagKey": "search", "evaluationCount": 10}],
+				"timeSeries": [{"timestamp": 1772323200000, "count": 40}]}`))
+		})
+		overview, err := client.GetMetricsOverview(ctx, MetricsWindow{Start: start, End: start.Add(time.Hour)}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(40), overview.TotalEvaluations)
+		assert.Equal(t, 2, overview.UniqueFlags)
+		assert.Equal(t, TopFlag{FlagID: 7, FlagKey: "checkout", EvaluationCount: 30}, overview.TopFlags[0])
+		assert.Equal(t, []TimeSeriesPoint{{Time: time.UnixMilli(1772323200000), Count: 40}}, overview.TimeSeries)
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Flag not found"}`))
+		})
+		_, err := client.GetFlagUsage(ctx, 99, window)
+		assert.True(t, errors.Is(err, ErrFlagNotFound))
+	})
+
+	t.Run("invalid windows", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			t.Fatal("invalid window must not reach the server")
+		})
+		for name, w := range map[string]MetricsWindow{
+			"empty":    {},
+			"reversed": {Start: start, End: start.Add(-time.Hour)},
+			"bucket":   {Start: start, End: start.Add(time.Hour), Bucket: time.Microsecond},
+		} {
+			_, err := client.GetFlagEvaluationStats(ctx, 7, w)
+			var configErr *InvalidConfigError
+			assert.True(t, errors.As(err, &configErr), name)
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package flagent
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultRequestIDHeader = "X-Request-ID"
+
+// Middleware wraps the transport of every HTTP call the client makes.
+// It sits closest to the wire: below retries and authentication, so it sees each
+// attempt separately, with credentials already set.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts an ordinary function to http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware appends middleware to the client's chain. Middleware runs in the
+// order given, across all WithMiddleware options: the first one added sees the
+// request first and the response last.
+func WithMiddleware(middleware ...Middleware) ClientOption {
+	return func(c *Client) {
+		for _, mw := range middleware {
+			if mw != nil {
+				c.middleware = append(c.middleware, mw)
+			}
+		}
+	}
+}
+
+// withMiddleware returns a copy of httpClient whose transport runs the middleware chain.
+// Per-call headers from the context are always applied, ahead of the chain.
+func withMiddleware(httpClient *http.Client, middleware []Middleware) *http.Client {
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	wrapped := *httpClient
+	wrapped.Transport = contextHeaders(next)
+	return &wrapped
+}
+
+type headersContextKey struct{}
+
+// ContextWithHeader returns a context whose requests carry an extra header.
+// Per-call headers are applied after credentials, so they can override them.
+func ContextWithHeader(ctx context.Context, key, value string) context.Context {
+	headers := http.Header{}
+	if existing, ok := ctx.Value(headersContextKey{}).(http.Header); ok {
+		headers = existing.Clone()
+	}
+	headers.Add(key, value)
+	return context.WithValue(ctx, headersContextKey{}, headers)
+}
+
+// contextHeaders applies the headers set with ContextWithHeader
+func contextHeaders(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		headers, ok := req.Context().Value(headersContextKey{}).(http.Header)
+		if !ok || len(headers) == 0 {
+			return next.RoundTrip(req)
+		}
+		req = req.Clone(req.Context())
+		for key, values := range headers {
+			req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+// HeaderMiddleware sets fixed headers on every request, e.g. team or service conventions
+func HeaderMiddleware(headers http.Header) Middleware {
+	headers = headers.Clone()
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			for key, values := range headers {
+				req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID returns a context carrying a request/correlation ID for RequestIDMiddleware
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the ID set by ContextWithRequestID, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// RequestIDMiddleware propagates a request/correlation ID from the context into a header
+// (default: X-Request-ID). fromContext extracts the ID, e.g. from your router's context
+// key; nil uses RequestIDFromContext. Requests without an ID are sent unchanged.
+func RequestIDMiddleware(header string, fromContext func(ctx context.Context) string) Middleware {
+	if header == "" {
+		header = defaultRequestIDHeader
+	}
+	if fromContext == nil {
+		fromContext = RequestIDFromContext
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			id := fromContext(req.Context())
+			if id == "" || req.Header.Get(header) != "" {
+				return next.RoundTrip(req)
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set(header, id)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// defaultRedactedHeaders are never logged in clear text
+var defaultRedactedHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+	"X-API-Key", "X-Admin-Key",
+}
+
+// redactedQueryParams are query parameter names whose values are masked in logged URLs
+var redactedQueryParams = []string{"api_key", "apikey", "token", "access_token", "password", "secret"}
+
+const redacted = "REDACTED"
+
+// LoggingOptions configures LoggingMiddleware
+type LoggingOptions struct {
+	// Level for successful calls (default: slog.LevelInfo). Failures and 5xx are logged at Warn.
+	Level slog.Level
+	// Headers includes request and response headers in the log (secrets redacted)
+	Headers bool
+	// RedactHeaders adds header names to redact, on top of Authorization, Cookie,
+	// X-API-Key, X-Admin-Key and the like
+	RedactHeaders []string
+}
+
+// LoggingMiddleware logs every HTTP call with method, URL, status and duration as
+// structured slog records. Credentials are redacted from headers and query strings,
+// and bodies are never logged.
+func LoggingMiddleware(logger *slog.Logger, opts *LoggingOptions) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if opts == nil {
+		opts = &LoggingOptions{}
+	}
+	redact := make(map[string]bool)
+	for _, h := range append(append([]string(nil), defaultRedactedHeaders...), opts.RedactHeaders...) {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("url", redactURL(req.URL)),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if opts.Headers {
+				attrs = append(attrs, slog.Any("request_headers", redactHeaders(req.Header, redact)))
+			}
+			level := opts.Level
+			if err != nil {
+				level = slog.LevelWarn
+				attrs = append(attrs, slog.String("error", err.Error()))
+			} else {
+				attrs = append(attrs, slog.Int("status", resp.StatusCode))
+				if opts.Headers {
+					attrs = append(attrs, slog.Any("response_headers", redactHeaders(resp.Header, redact)))
+				}
+				if resp.StatusCode >= http.StatusInternalServerError {
+					level = slog.LevelWarn
+				}
+			}
+			logger.LogAttrs(ctx, level, "flagent http call", attrs...)
+			return resp, err
+		})
+	}
+}
+
+// redactHeaders returns a copy of h with sensitive values replaced
+func redactHeaders(h http.Header, redact map[string]bool) http.Header {
+	out := h.Clone()
+	for key := range out {
+		if redact[http.CanonicalHeaderKey(key)] {
+			out[key] = []string{redacted}
+		}
+	}
+	return out
+}
+
+// redactURL renders u with sensitive query parameters and user info masked
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	masked := *u
+	if masked.User != nil {
+		masked.User = url.User(redacted)
+	}
+	if masked.RawQuery != "" {
+		query := masked.Query()
+		for name := range query {
+			for _, sensitive := range redactedQueryParams {
+				if strings.EqualFold(name, sensitive) {
+					query.Set(name, redacted)
+				}
+			}
+		}
+		masked.RawQuery = query.Encode()
+	}
+	return masked.String()
+}
@@ -58,6 +58,11 @@ type FlagSnapshot struct {
 
 // Re-export api types for compatibility
 type (
-	Flag   = api.Flag
-	Health = api.Health
+	Flag         = api.Flag
+	Health       = api.Health
+	Segment      = api.Segment
+	Constraint   = api.Constraint
+	Distribution = api.Distribution
+	Variant      = api.Variant
+	Tag          = api.Tag
 )
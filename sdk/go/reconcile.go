@@ -0,0 +1,758 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultManagedTag marks the flags a Reconciler owns
+	DefaultManagedTag             = "managed-by:reconciler"
+	defaultReconcileConcurrency   = 4
+	reconcileDefaultSegmentRank   = 999
+	reconcileMaxTagLength         = 63
+	reconcileCreatedSegmentOffset = int64(1) << 62
+)
+
+// tagPattern is the server's rule for tag values
+var tagPattern = regexp.MustCompile(`^[ \w\-/.:]+$`)
+
+// ReconcilerConfig configures a Reconciler
+type ReconcilerConfig struct {
+	// ManagedTag marks the flags the reconciler owns (default: DefaultManagedTag). It is
+	// added to every flag the reconciler creates or updates.
+	ManagedTag string
+	// Prune archives managed flags that are missing from the desired state. Flags
+	// without the managed tag are never deleted.
+	Prune bool
+	// Adopt takes over existing flags without the managed tag whose keys are desired.
+	// Without it, Plan fails for such flags.
+	Adopt bool
+	// Concurrency is the number of flags Apply changes in parallel (default: 4)
+	Concurrency int
+	// DryRun makes Apply report what it would change without calling the server
+	DryRun bool
+}
+
+// Reconciler brings the flags on the server to a desired state, like terraform plan
+// and apply. Desired flags are matched to server flags by key, segments by
+// description, variants by key, constraints by property and operator, and tags by
+// value. On existing flags, a nil variant Attachment or an empty segment Distribution
+// leaves the server's value unchanged.
+type Reconciler struct {
+	client *Client
+	config ReconcilerConfig
+}
+
+// NewReconciler creates a reconciler for the flags of client's server
+func NewReconciler(client *Client, config ReconcilerConfig) (*Reconciler, error) {
+	if client == nil {
+		return nil, NewInvalidConfigError("client is required", nil)
+	}
+	if config.ManagedTag == "" {
+		config.ManagedTag = DefaultManagedTag
+	}
+	if len(config.ManagedTag) > reconcileMaxTagLength || !tagPattern.MatchString(config.ManagedTag) {
+		return nil, NewInvalidConfigError(fmt.Sprintf("invalid managed tag %q", config.ManagedTag), nil)
+	}
+	if config.Concurrency < 0 {
+		return nil, NewInvalidConfigError("concurrency must not be negative", nil)
+	}
+	if config.Concurrency == 0 {
+		config.Concurrency = defaultReconcileConcurrency
+	}
+	return &Reconciler{client: client, config: config}, nil
+}
+
+// PlanAction is what a plan does to a flag or one of its parts
+type PlanAction string
+
+const (
+	PlanCreate  PlanAction = "create"
+	PlanUpdate  PlanAction = "update"
+	PlanReorder PlanAction = "reorder"
+	PlanDelete  PlanAction = "delete"
+)
+
+var planSymbols = map[PlanAction]string{PlanCreate: "+", PlanUpdate: "~", PlanReorder: "↕", PlanDelete: "-"}
+
+// PlanStep is one management API call of a plan
+type PlanStep struct {
+	Action PlanAction
+	Entity ChangeEntity
+	// Description is a readable summary, e.g. `segment "beta" rollout 20%→50%`
+	Description string
+
+	run func(ctx context.Context, st *applyState) error
+}
+
+// String renders the step, e.g. `~ segment "beta" rollout 20%→50%`
+func (s PlanStep) String() string {
+	return planSymbols[s.Action] + " " + s.Description
+}
+
+// FlagPlan is the ordered steps that bring one flag to its desired state
+type FlagPlan struct {
+	Key string
+	// Action is PlanCreate, PlanUpdate or PlanDelete
+	Action PlanAction
+	Steps  []PlanStep
+
+	spec   *FlagSpec
+	flagID int64
+	// variantIDs and segmentIDs of the existing flag, by variant key and segment description
+	variantIDs map[string]int64
+	segmentIDs map[string]int64
+}
+
+// Plan is the change set from the server's flags to the desired state. Flags that
+// are already up to date are not part of it.
+type Plan struct {
+	Flags []FlagPlan
+	// Orphaned lists managed flags missing from the desired state; Apply archives
+	// them only with Prune
+	Orphaned []string
+}
+
+// Empty reports whether the plan changes nothing
+func (p *Plan) Empty() bool {
+	return len(p.Flags) == 0
+}
+
+// String renders the plan as a readable diff, one flag and one step per line
+func (p *Plan) String() string {
+	var b strings.Builder
+	counts := map[PlanAction]int{}
+	for _, f := range p.Flags {
+		counts[f.Action]++
+		fmt.Fprintf(&b, "%s flag %s\n", planSymbols[f.Action], f.Key)
+		for _, step := range f.Steps {
+			fmt.Fprintf(&b, "    %s\n", step)
+		}
+	}
+	for _, key := range p.Orphaned {
+		fmt.Fprintf(&b, "! flag %s is managed but not desired (enable Prune to delete it)\n", key)
+	}
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete.", counts[PlanCreate], counts[PlanUpdate], counts[PlanDelete])
+	return b.String()
+}
+
+// applyState carries the IDs that steps of one flag create and use
+type applyState struct {
+	client     *Client
+	flagID     int64
+	variantIDs map[string]int64
+	segmentIDs map[string]int64
+}
+
+// Plan reads the current flags and computes the ordered steps that bring them to
+// the desired state. It fails if a desired flag exists without the managed tag,
+// unless Adopt is set.
+func (r *Reconciler) Plan(ctx context.Context, desired []FlagSpec) (*Plan, error) {
+	specs := make(map[string]*FlagSpec, len(desired))
+	keys := make([]string, 0, len(desired))
+	for i := range desired {
+		spec, err := r.desiredSpec(&desired[i])
+		if err != nil {
+			return nil, err
+		}
+		if specs[spec.Key] != nil {
+			return nil, NewInvalidConfigError(fmt.Sprintf("flag %s: duplicate key", spec.Key), nil)
+		}
+		specs[spec.Key] = spec
+		keys = append(keys, spec.Key)
+	}
+	sort.Strings(keys)
+
+	current := map[string]*Flag{}
+	it := r.client.IterateFlags(ctx, &ListFlagsOptions{Preload: true})
+	for it.Next() {
+		flag := it.Flag()
+		current[flag.Key] = &flag
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	for _, key := range keys {
+		spec, flag := specs[key], current[key]
+		if flag == nil {
+			steps, err := newSpecDiffer(&Flag{}, spec).steps()
+			if err != nil {
+				return nil, err
+			}
+			plan.Flags = append(plan.Flags, FlagPlan{Key: key, Action: PlanCreate, Steps: steps, spec: spec})
+			continue
+		}
+		if !r.managed(flag) && !r.config.Adopt {
+			return nil, NewInvalidConfigError(fmt.Sprintf(
+				"flag %s exists but is not managed (tag %q); set Adopt to take it over", key, r.config.ManagedTag), nil)
+		}
+		d := newSpecDiffer(flag, spec)
+		steps, err := d.steps()
+		if err != nil {
+			return nil, err
+		}
+		if len(steps) > 0 {
+			plan.Flags = append(plan.Flags, FlagPlan{
+				Key: key, Action: PlanUpdate, Steps: steps, spec: spec,
+				flagID: flag.Id, variantIDs: d.variantIDs, segmentIDs: d.segmentIDs,
+			})
+		}
+	}
+
+	var orphaned []*Flag
+	for key, flag := range current {
+		if specs[key] == nil && r.managed(flag) {
+			orphaned = append(orphaned, flag)
+		}
+	}
+	sort.Slice(orphaned, func(i, j int) bool { return orphaned[i].Key < orphaned[j].Key })
+	for _, flag := range orphaned {
+		if !r.config.Prune {
+			plan.Orphaned = append(plan.Orphaned, flag.Key)
+			continue
+		}
+		flagID := flag.Id
+		plan.Flags = append(plan.Flags, FlagPlan{Key: flag.Key, Action: PlanDelete, flagID: flagID, Steps: []PlanStep{{
+			Action: PlanDelete, Entity: EntityFlag, Description: fmt.Sprintf("flag %s archived", flag.Key),
+			run: func(ctx context.Context, st *applyState) error {
+				return st.client.DeleteFlag(ctx, flagID)
+			},
+		}}})
+	}
+	return plan, nil
+}
+
+// desiredSpec validates spec and returns a copy carrying the managed tag
+func (r *Reconciler) desiredSpec(spec *FlagSpec) (*FlagSpec, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	descriptions := make(map[string]bool, len(spec.Segments))
+	for _, segment := range spec.Segments {
+		if descriptions[segment.Description] {
+			return nil, NewInvalidConfigError(fmt.Sprintf(
+				"flag %s: duplicate segment description %q; the reconciler matches segments by description", spec.Key, segment.Description), nil)
+		}
+		descriptions[segment.Description] = true
+	}
+	s := *spec
+	s.Tags = append([]string(nil), spec.Tags...)
+	for _, tag := range s.Tags {
+		if tag == r.config.ManagedTag {
+			return &s, nil
+		}
+	}
+	s.Tags = append(s.Tags, r.config.ManagedTag)
+	return &s, nil
+}
+
+func (r *Reconciler) managed(flag *Flag) bool {
+	for _, tag := range flag.Tags {
+		if tag.Value == r.config.ManagedTag {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyResult reports what Apply did
+type ApplyResult struct {
+	// Applied lists the keys of flags brought to their desired state (or that would
+	// be, in a dry run)
+	Applied []string
+	// Failed maps the keys of flags that could not be reconciled to their error
+	Failed map[string]error
+	DryRun bool
+}
+
+// Apply executes the plan, changing up to Concurrency flags in parallel; the steps
+// of one flag run in order. New flags are created with ApplyFlagSpec, so a failed
+// creation is rolled back. A failed update stops that flag's remaining steps but
+// not other flags. The returned error joins the errors of all failed flags.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) (*ApplyResult, error) {
+	if plan == nil {
+		return nil, NewInvalidConfigError("plan is required", nil)
+	}
+	result := &ApplyResult{Failed: map[string]error{}, DryRun: r.config.DryRun}
+	if r.config.DryRun {
+		for _, f := range plan.Flags {
+			result.Applied = append(result.Applied, f.Key)
+		}
+		return result, nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, r.config.Concurrency)
+	for i := range plan.Flags {
+		f := &plan.Flags[i]
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			result.Failed[f.Key] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := r.applyFlag(ctx, f)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed[f.Key] = err
+			} else {
+				result.Applied = append(result.Applied, f.Key)
+			}
+		}()
+	}
+	wg.Wait()
+	sort.Strings(result.Applied)
+
+	if len(result.Failed) == 0 {
+		return result, nil
+	}
+	failed := make([]string, 0, len(result.Failed))
+	for key := range result.Failed {
+		failed = append(failed, key)
+	}
+	sort.Strings(failed)
+	errs := make([]error, len(failed))
+	for i, key := range failed {
+		errs[i] = fmt.Errorf("flag %s: %w", key, result.Failed[key])
+	}
+	return result, NewRequestError(fmt.Sprintf("failed to reconcile %d of %d flags", len(failed), len(plan.Flags)), errors.Join(errs...))
+}
+
+func (r *Reconciler) applyFlag(ctx context.Context, f *FlagPlan) error {
+	if f.Action == PlanCreate {
+		_, err := r.client.ApplyFlagSpec(ctx, f.spec)
+		return err
+	}
+	st := &applyState{client: r.client, flagID: f.flagID, variantIDs: map[string]int64{}, segmentIDs: map[string]int64{}}
+	for key, id := range f.variantIDs {
+		st.variantIDs[key] = id
+	}
+	for description, id := range f.segmentIDs {
+		st.segmentIDs[description] = id
+	}
+	for _, step := range f.Steps {
+		if err := step.run(ctx, st); err != nil {
+			return fmt.Errorf("%s: %w", step.Description, err)
+		}
+	}
+	return nil
+}
+
+// specDiffer computes the steps from a server flag to a desired spec
+type specDiffer struct {
+	flag *Flag
+	spec *FlagSpec
+	// variantIDs and segmentIDs of kept variants and segments
+	variantIDs  map[string]int64
+	segmentIDs  map[string]int64
+	variantKeys map[int64]string
+	// distributedTo maps the variants of kept distributions to their segment
+	distributedTo map[string]string
+	out           []PlanStep
+}
+
+func newSpecDiffer(flag *Flag, spec *FlagSpec) *specDiffer {
+	return &specDiffer{
+		flag:          flag,
+		spec:          spec,
+		variantIDs:    map[string]int64{},
+		segmentIDs:    map[string]int64{},
+		variantKeys:   map[int64]string{},
+		distributedTo: map[string]string{},
+	}
+}
+
+func (d *specDiffer) add(action PlanAction, entity ChangeEntity, description string, run func(ctx context.Context, st *applyState) error) {
+	d.out = append(d.out, PlanStep{Action: action, Entity: entity, Description: description, run: run})
+}
+
+// steps returns the steps in dependency order: disable, flag fields, variants,
+// segments with constraints and distributions, segment order, removed variants,
+// tags, enable. It fails if a removed variant is still in a distribution the spec
+// leaves unchanged.
+func (d *specDiffer) steps() ([]PlanStep, error) {
+	if d.flag.Enabled && !d.spec.Enabled {
+		d.add(PlanUpdate, EntityFlag, "flag disabled", func(ctx context.Context, st *applyState) error {
+			_, err := st.client.SetFlagEnabled(ctx, st.flagID, false)
+			return err
+		})
+	}
+	d.fields()
+	removedVariants := d.variants()
+	d.segments()
+	for _, v := range removedVariants {
+		if description, ok := d.distributedTo[v.Key]; ok {
+			return nil, NewInvalidConfigError(fmt.Sprintf(
+				"flag %s: variant %s is removed but still in the distribution of segment %q; set the segment's distribution", d.spec.Key, v.Key, description), nil)
+		}
+		variantID := v.Id
+		d.add(PlanDelete, EntityVariant, "variant "+v.Key, func(ctx context.Context, st *applyState) error {
+			return st.client.DeleteVariant(ctx, st.flagID, variantID)
+		})
+	}
+	d.tags()
+	if !d.flag.Enabled && d.spec.Enabled {
+		d.add(PlanUpdate, EntityFlag, "flag enabled", func(ctx context.Context, st *applyState) error {
+			_, err := st.client.SetFlagEnabled(ctx, st.flagID, true)
+			return err
+		})
+	}
+	return d.out, nil
+}
+
+func (d *specDiffer) fields() {
+	input := &UpdateFlagInput{}
+	var changes []string
+	field := func(name, old, new string) *string {
+		if old == new {
+			return nil
+		}
+		changes = append(changes, fmt.Sprintf("%s %s→%s", name, formatChangeValue(old), formatChangeValue(new)))
+		return &new
+	}
+	input.Description = field("description", d.flag.Description, d.spec.Description)
+	input.EntityType = field("entityType", nullableString(d.flag.EntityType), d.spec.EntityType)
+	input.Notes = field("notes", nullableString(d.flag.Notes), d.spec.Notes)
+	if len(changes) == 0 {
+		return
+	}
+	d.add(PlanUpdate, EntityFlag, "flag "+strings.Join(changes, ", "), func(ctx context.Context, st *applyState) error {
+		_, err := st.client.UpdateFlag(ctx, st.flagID, input)
+		return err
+	})
+}
+
+// variants adds the create and update steps and returns the variants to remove once
+// no distribution uses them
+func (d *specDiffer) variants() (removed []Variant) {
+	current := make(map[string]Variant, len(d.flag.Variants))
+	for _, v := range d.flag.Variants {
+		current[v.Key] = v
+		d.variantKeys[v.Id] = v.Key
+	}
+	desired := make(map[string]bool, len(d.spec.Variants))
+	for i := range d.spec.Variants {
+		input := d.spec.Variants[i]
+		desired[input.Key] = true
+		v, ok := current[input.Key]
+		switch {
+		case !ok:
+			d.add(PlanCreate, EntityVariant, "variant "+input.Key, func(ctx context.Context, st *applyState) error {
+				created, err := st.client.CreateVariant(ctx, st.flagID, &input)
+				if err == nil {
+					st.variantIDs[created.Key] = created.Id
+				}
+				return err
+			})
+		case input.Attachment != nil && !jsonEqual(input.Attachment, v.Attachment):
+			d.variantIDs[v.Key] = v.Id
+			variantID := v.Id
+			d.add(PlanUpdate, EntityVariant, "variant "+input.Key+" attachment changed", func(ctx context.Context, st *applyState) error {
+				_, err := st.client.UpdateVariant(ctx, st.flagID, variantID, &input)
+				return err
+			})
+		default:
+			d.variantIDs[v.Key] = v.Id
+		}
+	}
+	for _, v := range d.flag.Variants {
+		if !desired[v.Key] {
+			removed = append(removed, v)
+		}
+	}
+	return removed
+}
+
+func (d *specDiffer) segments() {
+	current := append([]Segment(nil), d.flag.Segments...)
+	sort.SliceStable(current, func(i, j int) bool {
+		if current[i].Rank != current[j].Rank {
+			return current[i].Rank < current[j].Rank
+		}
+		return current[i].Id < current[j].Id
+	})
+	byDescription := map[string]Segment{}
+	for _, s := range current {
+		if _, dup := byDescription[s.Description]; !dup {
+			byDescription[s.Description] = s
+		}
+	}
+
+	// order predicts the server's order after the steps: kept segments keep their
+	// rank, created ones get the default rank and sort after existing ones
+	type ranked struct {
+		description string
+		rank, id    int64
+	}
+	var order []ranked
+	for i := range d.spec.Segments {
+		spec := &d.spec.Segments[i]
+		label := fmt.Sprintf("segment %q", spec.Description)
+		segment, ok := byDescription[spec.Description]
+		if !ok {
+			input := &SegmentInput{Description: spec.Description, RolloutPercent: spec.RolloutPercent}
+			description := spec.Description
+			d.add(PlanCreate, EntitySegment, fmt.Sprintf("%s rollout %d%%", label, spec.RolloutPercent), func(ctx context.Context, st *applyState) error {
+				created, err := st.client.CreateSegment(ctx, st.flagID, input)
+				if err == nil {
+					st.segmentIDs[description] = created.Id
+				}
+				return err
+			})
+			d.constraints(spec, nil)
+			d.distribution(spec, nil)
+			order = append(order, ranked{spec.Description, reconcileDefaultSegmentRank, reconcileCreatedSegmentOffset + int64(i)})
+			continue
+		}
+		delete(byDescription, spec.Description)
+		d.segmentIDs[spec.Description] = segment.Id
+		order = append(order, ranked{spec.Description, segment.Rank, segment.Id})
+		if int64(spec.RolloutPercent) != segment.RolloutPercent {
+			input := &SegmentInput{Description: spec.Description, RolloutPercent: spec.RolloutPercent}
+			segmentID := segment.Id
+			d.add(PlanUpdate, EntitySegment, fmt.Sprintf("%s rollout %d%%→%d%%", label, segment.RolloutPercent, spec.RolloutPercent),
+				func(ctx context.Context, st *applyState) error {
+					_, err := st.client.UpdateSegment(ctx, st.flagID, segmentID, input)
+					return err
+				})
+		}
+		d.constraints(spec, segment.Constraints)
+		d.distribution(spec, segment.Distributions)
+	}
+
+	for _, s := range current {
+		if id, kept := d.segmentIDs[s.Description]; kept && id == s.Id {
+			continue
+		}
+		segmentID := s.Id
+		d.add(PlanDelete, EntitySegment, fmt.Sprintf("segment %q", s.Description), func(ctx context.Context, st *applyState) error {
+			return st.client.DeleteSegment(ctx, st.flagID, segmentID)
+		})
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].rank != order[j].rank {
+			return order[i].rank < order[j].rank
+		}
+		return order[i].id < order[j].id
+	})
+	inOrder := true
+	descriptions := make([]string, len(d.spec.Segments))
+	for i, s := range d.spec.Segments {
+		descriptions[i] = fmt.Sprintf("%q", s.Description)
+		if order[i].description != s.Description {
+			inOrder = false
+		}
+	}
+	if inOrder {
+		return
+	}
+	d.add(PlanReorder, EntitySegment, "segments reordered: "+strings.Join(descriptions, ", "), func(ctx context.Context, st *applyState) error {
+		ids := make([]int64, len(d.spec.Segments))
+		for i, s := range d.spec.Segments {
+			ids[i] = st.segmentIDs[s.Description]
+		}
+		return st.client.ReorderSegments(ctx, st.flagID, ids)
+	})
+}
+
+// constraints adds the steps from a segment's current constraints to the spec's.
+// Identical constraints are kept, constraints with the same property and operator
+// get the new value, and the rest are created or deleted.
+func (d *specDiffer) constraints(spec *SegmentSpec, current []Constraint) {
+	description := spec.Description
+	suffix := fmt.Sprintf(" in segment %q", description)
+	remaining := append([]Constraint(nil), current...)
+	take := func(match func(c Constraint) bool) (Constraint, bool) {
+		for i, c := range remaining {
+			if match(c) {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				return c, true
+			}
+		}
+		return Constraint{}, false
+	}
+
+	var unmatched []ConstraintInput
+	for _, want := range spec.Constraints {
+		if _, ok := take(func(c Constraint) bool {
+			return c.Property == want.Property && c.Operator == want.Operator && c.Value == want.Value
+		}); !ok {
+			unmatched = append(unmatched, want)
+		}
+	}
+	for i := range unmatched {
+		input := unmatched[i]
+		label := fmt.Sprintf("constraint %s %s", input.Property, input.Operator)
+		if c, ok := take(func(c Constraint) bool { return c.Property == input.Property && c.Operator == input.Operator }); ok {
+			constraintID := c.Id
+			d.add(PlanUpdate, EntityConstraint, fmt.Sprintf("%s %q→%q%s", label, c.Value, input.Value, suffix), func(ctx context.Context, st *applyState) error {
+				_, err := st.client.UpdateConstraint(ctx, st.flagID, st.segmentIDs[description], constraintID, &input)
+				return err
+			})
+			continue
+		}
+		d.add(PlanCreate, EntityConstraint, fmt.Sprintf("%s %q%s", label, input.Value, suffix), func(ctx context.Context, st *applyState) error {
+			_, err := st.client.CreateConstraint(ctx, st.flagID, st.segmentIDs[description], &input)
+			return err
+		})
+	}
+	for _, c := range remaining {
+		constraintID := c.Id
+		d.add(PlanDelete, EntityConstraint, fmt.Sprintf("constraint %s %s %q%s", c.Property, c.Operator, c.Value, suffix), func(ctx context.Context, st *applyState) error {
+			return st.client.DeleteConstraint(ctx, st.flagID, st.segmentIDs[description], constraintID)
+		})
+	}
+}
+
+// distribution adds a step replacing the segment's distribution if it differs
+func (d *specDiffer) distribution(spec *SegmentSpec, current []Distribution) {
+	old := make(map[string]int, len(current))
+	for _, dist := range current {
+		key := nullableString(dist.VariantKey)
+		if key == "" {
+			key = d.variantKeys[dist.VariantID]
+		}
+		old[key] = int(dist.Percent)
+	}
+	if len(spec.Distribution) == 0 {
+		for key := range old {
+			d.distributedTo[key] = spec.Description
+		}
+		return
+	}
+	if reflect.DeepEqual(old, spec.Distribution) {
+		return
+	}
+	action := PlanUpdate
+	if len(current) == 0 {
+		action = PlanCreate
+	}
+	description := spec.Description
+	d.add(action, EntityDistribution, fmt.Sprintf("distribution of segment %q %s", description, formatDistribution(spec.Distribution)),
+		func(ctx context.Context, st *applyState) error {
+			_, err := st.client.ReplaceDistributions(ctx, st.flagID, st.segmentIDs[description], spec.distributionInputs(st.variantIDs))
+			return err
+		})
+}
+
+func (d *specDiffer) tags() {
+	current := make(map[string]int64, len(d.flag.Tags))
+	for _, tag := range d.flag.Tags {
+		current[tag.Value] = tag.Id
+	}
+	desired := make(map[string]bool, len(d.spec.Tags))
+	for _, value := range d.spec.Tags {
+		desired[value] = true
+		if _, ok := current[value]; ok {
+			continue
+		}
+		value := value
+		d.add(PlanCreate, EntityTag, "tag "+value, func(ctx context.Context, st *applyState) error {
+			_, err := st.client.AddFlagTag(ctx, st.flagID, value)
+			return err
+		})
+	}
+	for _, tag := range d.flag.Tags {
+		if desired[tag.Value] {
+			continue
+		}
+		tagID := tag.Id
+		d.add(PlanDelete, EntityTag, "tag "+tag.Value, func(ctx context.Context, st *applyState) error {
+			return st.client.RemoveFlagTag(ctx, st.flagID, tagID)
+		})
+	}
+}
+
+// formatDistribution renders a distribution sorted by variant key, e.g. "control 50%, treatment 50%"
+func formatDistribution(distribution map[string]int) string {
+	keys := make([]string, 0, len(distribution))
+	for key := range distribution {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s %d%%", key, distribution[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// jsonEqual compares two values by their JSON encoding, so 1 and 1.0 are equal
+func jsonEqual(a, b interface{}) bool {
+	var x, y interface{}
+	if data, err := json.Marshal(a); err != nil || json.Unmarshal(data, &x) != nil {
+		return false
+	}
+	if data, err := json.Marshal(b); err != nil || json.Unmarshal(data, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// FlagSpecs converts the file's flags to specs for a Reconciler. Segments are
+// ordered by rank, and variants referenced only by distributions are declared.
+func (f *GitOpsFile) FlagSpecs() []FlagSpec {
+	specs := make([]FlagSpec, len(f.Flags))
+	for i, flag := range f.Flags {
+		spec := FlagSpec{
+			Key:         flag.Key,
+			Description: flag.Description,
+			EntityType:  flag.EntityType,
+			Notes:       flag.Notes,
+			Enabled:     flag.Enabled,
+			Tags:        append([]string(nil), flag.Tags...),
+		}
+		declared := map[string]bool{}
+		for _, v := range flag.Variants {
+			input := VariantInput{Key: v.Key}
+			if v.Attachment != nil {
+				input.Attachment = make(map[string]interface{}, len(v.Attachment))
+				for name, value := range v.Attachment {
+					input.Attachment[name] = value
+				}
+			}
+			spec.Variants = append(spec.Variants, input)
+			declared[v.Key] = true
+		}
+		segments := append([]GitOpsSegment(nil), flag.Segments...)
+		sort.SliceStable(segments, func(i, j int) bool { return segments[i].Rank < segments[j].Rank })
+		for _, s := range segments {
+			segment := SegmentSpec{Description: s.Description, RolloutPercent: s.RolloutPercent}
+			for _, c := range s.Constraints {
+				segment.Constraints = append(segment.Constraints, ConstraintInput{Property: c.Property, Operator: c.Operator, Value: c.Value})
+			}
+			if len(s.Distributions) > 0 {
+				segment.Distribution = make(map[string]int, len(s.Distributions))
+				for _, dist := range s.Distributions {
+					segment.Distribution[dist.VariantKey] += dist.Percent
+					if !declared[dist.VariantKey] {
+						spec.Variants = append(spec.Variants, VariantInput{Key: dist.VariantKey})
+						declared[dist.VariantKey] = true
+					}
+				}
+			}
+			spec.Segments = append(spec.Segments, segment)
+		}
+		specs[i] = spec
+	}
+	return specs
+}
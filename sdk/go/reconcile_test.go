@@ -0,0 +1,406 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reconcileFlags returns the server state of the reconciler tests: the managed flags
+// checkout_ab and old_banner and the unmanaged flag legacy
+func reconcileFlags() []api.Flag {
+	managed := []api.Tag{{Id: 7, Value: DefaultManagedTag}}
+	return []api.Flag{
+		{
+			Id: 10, Key: "checkout_ab", Description: "Checkout", Enabled: true, Tags: managed,
+			Variants: []api.Variant{{Id: 1, FlagID: 10, Key: "control"}, {Id: 2, FlagID: 10, Key: "treatment"}},
+			Segments: []api.Segment{{
+				Id: 5, FlagID: 10, Description: "everyone", Rank: 1, RolloutPercent: 50,
+				Distributions: []api.Distribution{
+					{VariantID: 1, VariantKey: *api.NewNullableString(api.PtrString("control")), Percent: 50},
+					{VariantID: 2, VariantKey: *api.NewNullableString(api.PtrString("treatment")), Percent: 50},
+				},
+			}},
+		},
+		{Id: 20, Key: "legacy", Description: "Legacy", Enabled: true},
+		{Id: 30, Key: "old_banner", Description: "Old banner", Tags: managed},
+	}
+}
+
+// newReconcileServer serves reconcileFlags and records every other call as
+// "METHOD path". Created flags get ID 40 and created segments ID 6.
+func newReconcileServer(t *testing.T, calls *[]string, bodies map[string]map[string]interface{}) *Client {
+	t.Helper()
+	var mu sync.Mutex
+	return newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+		call := r.Method + " " + r.URL.Path
+		if call == "GET /flags" {
+			if r.URL.Query().Get("offset") != "" && r.URL.Query().Get("offset") != "0" {
+				json.NewEncoder(w).Encode([]api.Flag{})
+				return
+			}
+			json.NewEncoder(w).Encode(reconcileFlags())
+			return
+		}
+		mu.Lock()
+		*calls = append(*calls, call)
+		if bodies != nil {
+			bodies[call] = body
+		}
+		mu.Unlock()
+		switch {
+		case call == "POST /flags":
+			json.NewEncoder(w).Encode(api.Flag{Id: 40, Key: body["key"].(string)})
+		case call == "POST /flags/10/segments":
+			json.NewEncoder(w).Encode(api.Segment{Id: 6, FlagID: 10})
+		case call == "POST /flags/40/variants":
+			json.NewEncoder(w).Encode(api.Variant{Id: 41, FlagID: 40, Key: body["key"].(string)})
+		case call == "POST /flags/40/segments":
+			json.NewEncoder(w).Encode(api.Segment{Id: 42, FlagID: 40})
+		default:
+			encodeReconcileResponse(w, r, body)
+		}
+	})
+}
+
+// encodeReconcileResponse answers the management calls without a fixed result
+func encodeReconcileResponse(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/constraints"):
+		json.NewEncoder(w).Encode(api.Constraint{Id: 1, Property: body["property"].(string), Operator: body["operator"].(string)})
+	case strings.HasSuffix(r.URL.Path, "/distributions"):
+		json.NewEncoder(w).Encode([]api.Distribution{})
+	case r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/segments/"):
+		json.NewEncoder(w).Encode(api.Segment{Id: 5, FlagID: 10})
+	case strings.HasSuffix(r.URL.Path, "/tags"):
+		json.NewEncoder(w).Encode(api.Tag{Id: 1, Value: body["value"].(string)})
+	default:
+		json.NewEncoder(w).Encode(api.Flag{Id: 40, Key: "new_search", Enabled: true})
+	}
+}
+
+// desiredFlags moves checkout_ab to a 20/80 split behind a new beta segment and adds new_search
+func desiredFlags() []FlagSpec {
+	checkout := *ABTestSpec("checkout_ab", "Checkout")
+	checkout.Segments = []SegmentSpec{
+		{
+			Description:    "beta",
+			RolloutPercent: 100,
+			Constraints:    []ConstraintInput{{Property: "tier", Operator: "EQ", Value: "beta"}},
+			Distribution:   map[string]int{"treatment": 100},
+		},
+		{Description: "everyone", RolloutPercent: 100, Distribution: map[string]int{"control": 20, "treatment": 80}},
+	}
+	return []FlagSpec{checkout, *PercentageRolloutSpec("new_search", "New search", 10)}
+}
+
+func TestReconcilerPlan(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("computes the minimal steps", func(t *testing.T) {
+		var calls []string
+		reconciler, err := NewReconciler(newReconcileServer(t, &calls, nil), ReconcilerConfig{})
+		require.NoError(t, err)
+		plan, err := reconciler.Plan(ctx, desiredFlags())
+		require.NoError(t, err)
+		assert.Empty(t, calls, "planning must not change anything")
+
+		require.Len(t, plan.Flags, 2)
+		assert.Equal(t, PlanUpdate, plan.Flags[0].Action)
+		assert.Equal(t, PlanCreate, plan.Flags[1].Action)
+		assert.Equal(t, []string{"old_banner"}, plan.Orphaned)
+		assert.Equal(t, `~ flag checkout_ab
+    + segment "beta" rollout 100%
+    + constraint tier EQ "beta" in segment "beta"
+    + distribution of segment "beta" treatment 100%
+    ~ segment "everyone" rollout 50%→100%
+    ~ distribution of segment "everyone" control 20%, treatment 80%
+    ↕ segments reordered: "beta", "everyone"
++ flag new_search
+    ~ flag description ""→"New search"
+    + variant enabled
+    + segment "everyone" rollout 10%
+    + distribution of segment "everyone" enabled 100%
+    + tag managed-by:reconciler
+    ~ flag enabled
+! flag old_banner is managed but not desired (enable Prune to delete it)
+Plan: 1 to create, 1 to update, 0 to delete.`, plan.String())
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		var calls []string
+		reconciler, err := NewReconciler(newReconcileServer(t, &calls, nil), ReconcilerConfig{})
+		require.NoError(t, err)
+		checkout := *ABTestSpec("checkout_ab", "Checkout")
+		checkout.Segments[0].RolloutPercent = 50
+		plan, err := reconciler.Plan(ctx, []FlagSpec{checkout, {Key: "old_banner", Description: "Old banner"}})
+		require.NoError(t, err)
+		assert.True(t, plan.Empty(), plan.String())
+		assert.Empty(t, plan.Orphaned)
+	})
+
+	t.Run("refuses unmanaged flags unless adopting", func(t *testing.T) {
+		var calls []string
+		client := newReconcileServer(t, &calls, nil)
+		desired := []FlagSpec{{Key: "legacy", Description: "Legacy", Enabled: true}}
+
+		reconciler, err := NewReconciler(client, ReconcilerConfig{})
+		require.NoError(t, err)
+		_, err = reconciler.Plan(ctx, desired)
+		var configErr *InvalidConfigError
+		require.True(t, errors.As(err, &configErr))
+		assert.Contains(t, err.Error(), "flag legacy exists but is not managed")
+
+		reconciler, err = NewReconciler(client, ReconcilerConfig{Adopt: true})
+		require.NoError(t, err)
+		plan, err := reconciler.Plan(ctx, desired)
+		require.NoError(t, err)
+		require.Len(t, plan.Flags, 1)
+		assert.Equal(t, "+ tag managed-by:reconciler", plan.Flags[0].Steps[0].String())
+	})
+
+	t.Run("prunes only managed flags", func(t *testing.T) {
+		var calls []string
+		reconciler, err := NewReconciler(newReconcileServer(t, &calls, nil), ReconcilerConfig{Prune: true})
+		require.NoError(t, err)
+		plan, err := reconciler.Plan(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, plan.Flags, 2)
+		assert.Equal(t, "checkout_ab", plan.Flags[0].Key)
+		assert.Equal(t, "old_banner", plan.Flags[1].Key)
+		for _, f := range plan.Flags {
+			assert.Equal(t, PlanDelete, f.Action)
+		}
+		assert.Contains(t, plan.String(), "Plan: 0 to create, 0 to update, 2 to delete.")
+	})
+
+	t.Run("plans a GitOps file", func(t *testing.T) {
+		// The server has new_checkout as the GitOps example describes it
+		reconciler, err := NewReconciler(newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, "GET /flags", r.Method+" "+r.URL.Path)
+			if offset := r.URL.Query().Get("offset"); offset != "" && offset != "0" {
+				json.NewEncoder(w).Encode([]api.Flag{})
+				return
+			}
+			json.NewEncoder(w).Encode([]api.Flag{{
+				Id: 50, Key: "new_checkout", Description: "New checkout flow", Enabled: true,
+				Tags:     []api.Tag{{Id: 3, Value: "payments"}},
+				Variants: []api.Variant{{Id: 51, FlagID: 50, Key: "on", Attachment: map[string]interface{}{"color": "blue"}}},
+				Segments: []api.Segment{{
+					Id: 52, FlagID: 50, Description: "Beta users", Rank: 999, RolloutPercent: 100,
+					Constraints:   []api.Constraint{{Id: 53, SegmentID: 52, Property: "tier", Operator: "EQ", Value: "beta"}},
+					Distributions: []api.Distribution{{VariantID: 51, VariantKey: *api.NewNullableString(api.PtrString("on")), Percent: 100}},
+				}},
+			}})
+		}), ReconcilerConfig{Adopt: true})
+		require.NoError(t, err)
+		file, err := ParseGitOpsFile([]byte(gitOpsYAML), GitOpsYAML)
+		require.NoError(t, err)
+		file.Flags = append(file.Flags, GitOpsFlag{Key: "Checkout.V2", Variants: []GitOpsVariant{{Key: "release_1.2"}}})
+
+		plan, err := reconciler.Plan(ctx, file.FlagSpecs())
+		require.NoError(t, err)
+		assert.Equal(t, `+ flag Checkout.V2
+    + variant release_1.2
+    + tag managed-by:reconciler
++ flag dark_mode
+    ~ flag description ""→"Dark mode UI"
+    + tag managed-by:reconciler
+~ flag new_checkout
+    + tag managed-by:reconciler
+Plan: 2 to create, 1 to update, 0 to delete.`, plan.String())
+	})
+
+	t.Run("refuses to remove variants of unchanged distributions", func(t *testing.T) {
+		var calls []string
+		reconciler, err := NewReconciler(newReconcileServer(t, &calls, nil), ReconcilerConfig{})
+		require.NoError(t, err)
+		// The spec drops treatment but leaves the distribution using it unchanged
+		checkout := FlagSpec{
+			Key: "checkout_ab", Description: "Checkout", Enabled: true,
+			Variants: []VariantInput{{Key: "control"}},
+			Segments: []SegmentSpec{{Description: "everyone", RolloutPercent: 50}},
+		}
+		_, err = reconciler.Plan(ctx, []FlagSpec{checkout})
+		var configErr *InvalidConfigError
+		require.True(t, errors.As(err, &configErr))
+		assert.Contains(t, err.Error(), `variant treatment is removed but still in the distribution of segment "everyone"`)
+
+		checkout.Segments[0].Distribution = map[string]int{"control": 100}
+		plan, err := reconciler.Plan(ctx, []FlagSpec{checkout})
+		require.NoError(t, err)
+		assert.Contains(t, plan.String(), "- variant treatment")
+		assert.Empty(t, calls)
+	})
+
+	t.Run("validates the desired state", func(t *testing.T) {
+		reconciler, err := NewReconciler(newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			t.Fatal("invalid state must not reach the server")
+		}), ReconcilerConfig{})
+		require.NoError(t, err)
+		duplicateSegment := *ABTestSpec("checkout_ab", "")
+		duplicateSegment.Segments = append(duplicateSegment.Segments, duplicateSegment.Segments[0])
+		for name, desired := range map[string][]FlagSpec{
+			"duplicate key":     {*KillSwitchSpec("kill_checkout", ""), *KillSwitchSpec("kill_checkout", "")},
+			"duplicate segment": {duplicateSegment},
+			"invalid spec":      {{Key: "Checkout AB"}},
+		} {
+			_, err := reconciler.Plan(ctx, desired)
+			var configErr *InvalidConfigError
+			assert.True(t, errors.As(err, &configErr), name)
+		}
+	})
+
+	t.Run("rejects invalid config", func(t *testing.T) {
+		_, err := NewReconciler(nil, ReconcilerConfig{})
+		assert.Error(t, err)
+		_, err = NewReconciler(&Client{}, ReconcilerConfig{ManagedTag: "owner=me"})
+		assert.Error(t, err)
+		_, err = NewReconciler(&Client{}, ReconcilerConfig{Concurrency: -1})
+		assert.Error(t, err)
+	})
+}
+
+func TestReconcilerApply(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("applies the plan", func(t *testing.T) {
+		var calls []string
+		bodies := map[string]map[string]interface{}{}
+		reconciler, err := NewReconciler(newReconcileServer(t, &calls, bodies), ReconcilerConfig{Prune: true, Concurrency: 1})
+		require.NoError(t, err)
+		plan, err := reconciler.Plan(ctx, desiredFlags())
+		require.NoError(t, err)
+		result, err := reconciler.Apply(ctx, plan)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"checkout_ab", "new_search", "old_banner"}, result.Applied)
+		assert.Empty(t, result.Failed)
+
+		assert.Equal(t, []string{
+			"POST /flags/10/segments",
+			"POST /flags/10/segments/6/constraints",
+			"PUT /flags/10/segments/6/distributions",
+			"PUT /flags/10/segments/5",
+			"PUT /flags/10/segments/5/distributions",
+			"PUT /flags/10/segments/reorder",
+		}, calls[:6])
+		assert.Equal(t, []interface{}{6.0, 5.0}, bodies["PUT /flags/10/segments/reorder"]["segmentIDs"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"variantID": 1.0, "variantKey": "control", "percent": 20.0},
+			map[string]interface{}{"variantID": 2.0, "variantKey": "treatment", "percent": 80.0},
+		}, bodies["PUT /flags/10/segments/5/distributions"]["distributions"])
+		assert.Contains(t, calls, "POST /flags")
+		assert.Contains(t, calls, "POST /flags/40/tags")
+		assert.Equal(t, "DELETE /flags/30", calls[len(calls)-1])
+	})
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		var calls []string
+		reconciler, err := NewReconciler(newReconcileServer(t, &calls, nil), ReconcilerConfig{DryRun: true, Prune: true})
+		require.NoError(t, err)
+		plan, err := reconciler.Plan(ctx, desiredFlags())
+		require.NoError(t, err)
+		result, err := reconciler.Apply(ctx, plan)
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, []string{"checkout_ab", "new_search", "old_banner"}, result.Applied)
+		assert.Empty(t, calls)
+	})
+
+	t.Run("reports failed flags and continues", func(t *testing.T) {
+		var calls []string
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			switch r.Method + " " + r.URL.Path {
+			case "GET /flags":
+				json.NewEncoder(w).Encode(reconcileFlags())
+			case "DELETE /flags/10":
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"message":"rejected"}`))
+			default:
+				calls = append(calls, r.Method+" "+r.URL.Path)
+			}
+		})
+		reconciler, err := NewReconciler(client, ReconcilerConfig{Prune: true, Concurrency: 1})
+		require.NoError(t, err)
+		plan, err := reconciler.Plan(ctx, nil)
+		require.NoError(t, err)
+		result, err := reconciler.Apply(ctx, plan)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrBadRequest))
+		assert.Contains(t, err.Error(), "failed to reconcile 1 of 2 flags")
+		assert.Equal(t, []string{"old_banner"}, result.Applied)
+		assert.Contains(t, result.Failed, "checkout_ab")
+		assert.Equal(t, []string{"DELETE /flags/30"}, calls)
+	})
+
+	t.Run("limits concurrency", func(t *testing.T) {
+		var inFlight, maxInFlight int32
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			if r.Method == http.MethodGet && r.URL.Path == "/flags" {
+				json.NewEncoder(w).Encode([]api.Flag{})
+				return
+			}
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			encodeReconcileResponse(w, r, body)
+		})
+		reconciler, err := NewReconciler(client, ReconcilerConfig{Concurrency: 2})
+		require.NoError(t, err)
+		var desired []FlagSpec
+		for _, key := range []string{"flag_a", "flag_b", "flag_c", "flag_d", "flag_e", "flag_f"} {
+			desired = append(desired, FlagSpec{Key: key})
+		}
+		plan, err := reconciler.Plan(ctx, desired)
+		require.NoError(t, err)
+		result, err := reconciler.Apply(ctx, plan)
+		require.NoError(t, err)
+		assert.Len(t, result.Applied, 6)
+		assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+	})
+}
+
+func TestGitOpsFileFlagSpecs(t *testing.T) {
+	file := &GitOpsFile{Version: "1", Flags: []GitOpsFlag{{
+		Key:        "checkout_ab",
+		Enabled:    true,
+		EntityType: "user",
+		Variants:   []GitOpsVariant{{Key: "control", Attachment: map[string]string{"color": "blue"}}},
+		Segments: []GitOpsSegment{
+			{Rank: 2, Description: "everyone", RolloutPercent: 100, Distributions: []GitOpsDistribution{
+				{VariantKey: "control", Percent: 50}, {VariantKey: "treatment", Percent: 50},
+			}},
+			{Rank: 1, Description: "beta", RolloutPercent: 100, Constraints: []GitOpsConstraint{{Property: "tier", Operator: "EQ", Value: "beta"}}},
+		},
+		Tags: []string{"payments"},
+	}}}
+	specs := file.FlagSpecs()
+	require.Len(t, specs, 1)
+	spec := specs[0]
+	require.NoError(t, spec.Validate())
+	assert.Equal(t, "user", spec.EntityType)
+	assert.Equal(t, []VariantInput{
+		{Key: "control", Attachment: map[string]interface{}{"color": "blue"}},
+		{Key: "treatment"},
+	}, spec.Variants)
+	assert.Equal(t, "beta", spec.Segments[0].Description)
+	assert.Equal(t, []ConstraintInput{{Property: "tier", Operator: "EQ", Value: "beta"}}, spec.Segments[0].Constraints)
+	assert.Equal(t, map[string]int{"control": 50, "treatment": 50}, spec.Segments[1].Distribution)
+	assert.Equal(t, []string{"payments"}, spec.Tags)
+}
@@ -0,0 +1,67 @@
+package flagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// do sends a JSON request to an endpoint that the generated api package does not cover.
+// path is relative to the base URL. body and out may be nil. HTTP error statuses are
+// returned as *APIError together with the response, so convertError can classify them.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (*http.Response, error) {
+	cfg := c.apiClient.GetConfig()
+	baseURL, err := cfg.ServerURLWithContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	target := baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+	for header, value := range cfg.DefaultHeader {
+		req.Header.Set(header, value)
+	}
+
+	resp, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return resp, apiErrorFromBody(resp, data)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
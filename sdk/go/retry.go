@@ -0,0 +1,206 @@
+package flagent
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries    = 3
+	defaultRetryDelay    = 200 * time.Millisecond
+	defaultMaxRetryDelay = 5 * time.Second
+)
+
+// RetryPolicy decides whether a failed HTTP attempt should be retried.
+// Implementations must be safe for concurrent use.
+type RetryPolicy interface {
+	// Retry is called after each failed attempt with the response (may be nil),
+	// the transport error (may be nil) and the number of retries already made.
+	// It returns the delay before the next attempt and whether to retry at all.
+	Retry(req *http.Request, resp *http.Response, err error, retries int) (time.Duration, bool)
+}
+
+// RetryPolicyFunc adapts an ordinary function to RetryPolicy
+type RetryPolicyFunc func(req *http.Request, resp *http.Response, err error, retries int) (time.Duration, bool)
+
+// Retry calls f(req, resp, err, retries)
+func (f RetryPolicyFunc) Retry(req *http.Request, resp *http.Response, err error, retries int) (time.Duration, bool) {
+	return f(req, resp, err, retries)
+}
+
+// BackoffPolicy is the default RetryPolicy: exponential backoff with jitter,
+// honouring Retry-After, for safe-to-repeat requests only.
+type BackoffPolicy struct {
+	// MaxRetries is the maximum number of retries after the first attempt (0 disables retries)
+	MaxRetries int
+
+	// BaseDelay is the delay before the first retry; it doubles on every further retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff delay. A Retry-After longer than MaxDelay is not waited for.
+	MaxDelay time.Duration
+}
+
+// NewBackoffPolicy creates a BackoffPolicy with the default delays
+func NewBackoffPolicy(maxRetries int) *BackoffPolicy {
+	return &BackoffPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  defaultRetryDelay,
+		MaxDelay:   defaultMaxRetryDelay,
+	}
+}
+
+// Retry implements RetryPolicy
+func (p *BackoffPolicy) Retry(req *http.Request, resp *http.Response, err error, retries int) (time.Duration, bool) {
+	if retries >= p.MaxRetries || !IsRetryableRequest(req) || !IsRetryableResponse(resp, err) {
+		return 0, false
+	}
+	if wait, ok := retryAfter(resp); ok {
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			return 0, false
+		}
+		return wait, true
+	}
+	return p.backoff(retries), true
+}
+
+// backoff returns the jittered delay for the given retry number ("equal jitter":
+// half of the exponential delay is fixed, the other half is random).
+func (p *BackoffPolicy) backoff(retries int) time.Duration {
+	delay := p.BaseDelay
+	if delay <= 0 {
+		return 0
+	}
+	for i := 0; i < retries; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			delay = p.MaxDelay
+			break
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// IsRetryableRequest reports whether req is safe to send more than once.
+// Idempotent methods qualify, and so do evaluation POSTs, which have no side effects.
+func IsRetryableRequest(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false // body cannot be replayed
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		path := strings.TrimSuffix(req.URL.Path, "/")
+		return strings.HasSuffix(path, "/evaluation") || strings.HasSuffix(path, "/evaluation/batch")
+	}
+	return false
+}
+
+// IsRetryableResponse reports whether an attempt failed transiently: a transport
+// error, 429 Too Many Requests, or a 502/503/504 from the server or a proxy.
+func IsRetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if resp == nil {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header (delta-seconds or HTTP-date)
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		wait := time.Until(at)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// retryTransport replays requests according to a RetryPolicy
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for retries := 0; ; retries++ {
+		attempt := req
+		if retries > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt = req.Clone(ctx)
+			attempt.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(attempt)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		wait, ok := t.policy.Retry(req, resp, err, retries)
+		if !ok || ctx.Err() != nil {
+			return resp, err
+		}
+		// Stay within the caller's budget: never sleep past the context deadline
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) <= wait {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// withRetries returns a copy of httpClient whose transport retries according to policy
+func withRetries(httpClient *http.Client, policy RetryPolicy) *http.Client {
+	if policy == nil {
+		return httpClient
+	}
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped := *httpClient
+	wrapped.Transport = &retryTransport{next: next, policy: policy}
+	return &wrapped
+}
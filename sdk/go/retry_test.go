@@ -0,0 +1,185 @@
+package flagent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MaxLuxs/Flagent/sdk/go/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	t.Run("Evaluate retries transient 502", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			result := api.EvalResult{}
+			result.SetFlagKey("f1")
+			json.NewEncoder(w).Encode(result)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(server.URL, WithRetryDelay(time.Millisecond))
+		require.NoError(t, err)
+		result, err := client.Evaluate(context.Background(), &EvaluationContext{FlagKey: stringPtr("f1")})
+		require.NoError(t, err)
+		assert.Equal(t, "f1", result.GetFlagKey())
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("gives up after MaxRetries", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(server.URL, WithMaxRetries(2), WithRetryDelay(time.Millisecond))
+		require.NoError(t, err)
+		_, err = client.GetSnapshot(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("does not retry non-idempotent POST", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(server.URL, WithRetryDelay(time.Millisecond))
+		require.NoError(t, err)
+		_, _, err = client.apiClient.FlagAPI.CreateFlag(context.Background()).
+			CreateFlagRequest(*api.NewCreateFlagRequest("new flag")).Execute()
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(server.URL, WithRetryDelay(time.Millisecond))
+		require.NoError(t, err)
+		_, err = client.ListFlags(context.Background(), nil)
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("honours Retry-After", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			json.NewEncoder(w).Encode([]api.Flag{})
+		}))
+		defer server.Close()
+
+		client, err := NewClient(server.URL, WithRetryDelay(time.Millisecond))
+		require.NoError(t, err)
+		start := time.Now()
+		_, err = client.ListFlags(context.Background(), nil)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("stops when backoff exceeds context deadline", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(server.URL, WithRetryDelay(time.Second))
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err = client.ListFlags(ctx, nil)
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("custom RetryPolicy", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			json.NewEncoder(w).Encode([]api.Flag{})
+		}))
+		defer server.Close()
+
+		policy := RetryPolicyFunc(func(req *http.Request, resp *http.Response, err error, retries int) (time.Duration, bool) {
+			return 0, retries < 1 && resp != nil && resp.StatusCode == http.StatusInternalServerError
+		})
+		client, err := NewClient(server.URL, WithRetryPolicy(policy))
+		require.NoError(t, err)
+		_, err = client.ListFlags(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("WithRetryPolicy nil disables retries", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(server.URL, WithRetryPolicy(nil))
+		require.NoError(t, err)
+		_, err = client.ListFlags(context.Background(), nil)
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestBackoffPolicy(t *testing.T) {
+	p := &BackoffPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	req := httptest.NewRequest(http.MethodGet, "/flags", nil)
+
+	for retries := 0; retries < 5; retries++ {
+		wait, ok := p.Retry(req, nil, assert.AnError, retries)
+		require.True(t, ok)
+		assert.LessOrEqual(t, wait, time.Second)
+	}
+	_, ok := p.Retry(req, nil, assert.AnError, 5)
+	assert.False(t, ok)
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"10"}}}
+	_, ok = p.Retry(req, resp, nil, 0)
+	assert.False(t, ok, "Retry-After beyond MaxDelay is not waited for")
+}
+
+func TestIsRetryableRequest(t *testing.T) {
+	assert.True(t, IsRetryableRequest(httptest.NewRequest(http.MethodGet, "/api/v1/flags", nil)))
+	assert.True(t, IsRetryableRequest(httptest.NewRequest(http.MethodPost, "/api/v1/evaluation", http.NoBody)))
+	assert.True(t, IsRetryableRequest(httptest.NewRequest(http.MethodPost, "/api/v1/evaluation/batch", http.NoBody)))
+	assert.False(t, IsRetryableRequest(httptest.NewRequest(http.MethodPost, "/api/v1/flags", http.NoBody)))
+	assert.False(t, IsRetryableRequest(nil))
+}
@@ -0,0 +1,151 @@
+package flagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionUser is the principal returned by /auth/login
+type SessionUser struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// SessionTokenSource logs in at /auth/login with email and password and returns the
+// session JWT. Combined with WithTokenSource (or WithLogin) the JWT is renewed before
+// it expires, so long-running tools keep acting as the same principal.
+type SessionTokenSource struct {
+	loginURL string
+	email    string
+	password string
+
+	mu         sync.Mutex
+	httpClient *http.Client
+	user       *SessionUser
+}
+
+// NewSessionTokenSource creates a session token source. loginURL is the full URL of the
+// login endpoint, e.g. "https://flagent.example.com/auth/login".
+func NewSessionTokenSource(loginURL, email, password string) *SessionTokenSource {
+	return &SessionTokenSource{
+		loginURL: loginURL,
+		email:    email,
+		password: password,
+	}
+}
+
+// WithLogin authenticates as a user by logging in at /auth/login. The login endpoint is
+// served next to the API, so it is derived from the base URL ("…/api/v1" → "…/auth/login").
+func WithLogin(email, password string) ClientOption {
+	return func(c *Client) {
+		baseURL := c.apiClient.GetConfig().Servers[0].URL
+		loginURL := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v1") + "/auth/login"
+		c.credentials = &tokenCredentials{source: NewSessionTokenSource(loginURL, email, password)}
+	}
+}
+
+// Token implements TokenSource by logging in. It is not bound to a context;
+// clients call TokenContext instead.
+func (s *SessionTokenSource) Token() (*Token, error) {
+	return s.login(context.Background())
+}
+
+// TokenContext implements ContextTokenSource by logging in with ctx
+func (s *SessionTokenSource) TokenContext(ctx context.Context) (*Token, error) {
+	return s.login(ctx)
+}
+
+// User returns the principal of the last successful login, or nil
+func (s *SessionTokenSource) User() *SessionUser {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.user
+}
+
+// setHTTPClient implements httpClientUser
+func (s *SessionTokenSource) setHTTPClient(httpClient *http.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.httpClient == nil {
+		s.httpClient = httpClient
+	}
+}
+
+func (s *SessionTokenSource) login(ctx context.Context) (*Token, error) {
+	body, err := json.Marshal(map[string]string{"email": s.email, "password": s.password})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.loginURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, NewInvalidConfigError("invalid login URL", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	s.mu.Lock()
+	httpClient := s.httpClient
+	s.mu.Unlock()
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, NewNetworkError("login failed", err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, NewNetworkError("login failed", err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, NewRequestError("login failed", apiErrorFromBody(resp, data))
+	}
+
+	var result struct {
+		Token string      `json:"token"`
+		User  SessionUser `json:"user"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil || result.Token == "" {
+		return nil, NewRequestError("login failed: unexpected response", err)
+	}
+
+	s.mu.Lock()
+	s.user = &result.User
+	s.mu.Unlock()
+
+	return &Token{
+		AccessToken: result.Token,
+		TokenType:   "Bearer",
+		Expiry:      jwtExpiry(result.Token),
+	}, nil
+}
+
+// jwtExpiry reads the exp claim of a JWT without verifying it (the server does that).
+// It returns the zero time when the token has no readable expiry.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
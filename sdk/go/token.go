@@ -0,0 +1,170 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryDelta renews tokens this long before they expire, so a token
+// never runs out while a request is in flight
+const tokenExpiryDelta = 30 * time.Second
+
+// Token is an access token. Its fields mirror golang.org/x/oauth2.Token.
+type Token struct {
+	AccessToken string
+	// TokenType is the Authorization scheme (default: "Bearer")
+	TokenType    string
+	RefreshToken string
+	// Expiry is when the token expires; zero means it is used until the server rejects it
+	Expiry time.Time
+}
+
+// Valid reports whether the token is set and not about to expire
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(tokenExpiryDelta).Before(t.Expiry)
+}
+
+// TokenSource supplies access tokens. It has the same shape as oauth2.TokenSource;
+// wrap an oauth2 source with OAuth2TokenSource.
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+// ContextTokenSource is a TokenSource that can bind a token request to a context.
+// The client calls TokenContext with the context of the API call that needs the
+// token, so cancellation and deadlines reach the token endpoint.
+type ContextTokenSource interface {
+	TokenSource
+	TokenContext(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc adapts an ordinary function to TokenSource
+type TokenSourceFunc func() (*Token, error)
+
+// Token calls f()
+func (f TokenSourceFunc) Token() (*Token, error) {
+	return f()
+}
+
+// AuthHeaderSetter is implemented by *oauth2.Token
+type AuthHeaderSetter interface {
+	SetAuthHeader(r *http.Request)
+}
+
+// OAuth2TokenSource adapts a source with the oauth2.TokenSource shape, such as
+// clientcredentials.Config.TokenSource(ctx), without this module depending on x/oauth2:
+//
+//	client, err := flagent.NewClient(baseURL,
+//		flagent.WithTokenSource(flagent.OAuth2TokenSource(conf.TokenSource(ctx))))
+//
+// The expiry of an oauth2 token is not reachable through an interface, so the
+// client does not cache the tokens: it asks src for every request, and src
+// (which caches and renews tokens itself) decides when to fetch a new one. An
+// oauth2 source returns the same token until it expires and cannot be made to
+// renew it early, so a 401 is returned to the caller without a replay.
+func OAuth2TokenSource[T AuthHeaderSetter](src interface{ Token() (T, error) }) TokenSource {
+	return oauth2TokenSource(func() (*Token, error) {
+		t, err := src.Token()
+		if err != nil {
+			return nil, err
+		}
+		// The access token fields are not reachable through an interface, but the
+		// header it produces is
+		req := &http.Request{Header: make(http.Header)}
+		t.SetAuthHeader(req)
+		scheme, token, ok := strings.Cut(req.Header.Get(headerAuthorization), " ")
+		if !ok || token == "" {
+			return nil, errors.New("flagent: token source returned an empty token")
+		}
+		return &Token{AccessToken: token, TokenType: scheme}, nil
+	})
+}
+
+// oauth2TokenSource marks sources that cache tokens themselves, see OAuth2TokenSource
+type oauth2TokenSource func() (*Token, error)
+
+// Token calls f()
+func (f oauth2TokenSource) Token() (*Token, error) {
+	return f()
+}
+
+// WithTokenSource authenticates every request with a token from src, sent in the
+// Authorization header with its TokenType. Tokens are cached until they expire. When the server
+// answers 401, the token is discarded and the request is replayed once if src
+// returns a different one; OAuth2TokenSource does not until its token expires.
+func WithTokenSource(src TokenSource) ClientOption {
+	return func(c *Client) {
+		if src != nil {
+			_, uncached := src.(oauth2TokenSource)
+			c.credentials = &tokenCredentials{source: src, uncached: uncached}
+		}
+	}
+}
+
+// tokenCredentials is a CredentialsProvider backed by a TokenSource
+type tokenCredentials struct {
+	source TokenSource
+	// uncached asks source for every request, for sources that cache tokens themselves
+	uncached bool
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// Credentials implements CredentialsProvider
+func (p *tokenCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.uncached || !p.token.Valid() {
+		var token *Token
+		var err error
+		if src, ok := p.source.(ContextTokenSource); ok {
+			token, err = src.TokenContext(ctx)
+		} else {
+			token, err = p.source.Token()
+		}
+		if err != nil {
+			return Credentials{}, err
+		}
+		if !token.Valid() {
+			return Credentials{}, errors.New("flagent: token source returned an invalid token")
+		}
+		p.token = token
+	}
+	return Credentials{BearerToken: p.token.AccessToken, TokenType: tokenScheme(p.token.TokenType)}, nil
+}
+
+// tokenScheme returns the Authorization scheme of a token type. Like oauth2, it
+// capitalizes "bearer", which many token endpoints return in lower case.
+func tokenScheme(tokenType string) string {
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		return "Bearer"
+	}
+	return tokenType
+}
+
+// invalidate implements credentialsRefresher. Only the rejected token is dropped,
+// so concurrent 401s trigger a single renewal.
+func (p *tokenCredentials) invalidate(rejected Credentials) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != nil && p.token.AccessToken == rejected.BearerToken {
+		p.token = nil
+	}
+}
+
+// setHTTPClient implements httpClientUser
+func (p *tokenCredentials) setHTTPClient(httpClient *http.Client) {
+	if user, ok := p.source.(httpClientUser); ok {
+		user.setHTTPClient(httpClient)
+	}
+}
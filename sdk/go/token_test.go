@@ -0,0 +1,286 @@
+package flagent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOAuth2Token and fakeOAuth2Source mimic golang.org/x/oauth2 types
+type fakeOAuth2Token struct {
+	AccessToken string
+}
+
+func (t *fakeOAuth2Token) SetAuthHeader(r *http.Request) {
+	r.Header.Set("Authorization", "Bearer "+t.AccessToken)
+}
+
+type fakeOAuth2Source interface {
+	Token() (*fakeOAuth2Token, error)
+}
+
+type staticOAuth2Source struct{ token string }
+
+func (s staticOAuth2Source) Token() (*fakeOAuth2Token, error) {
+	return &fakeOAuth2Token{AccessToken: s.token}, nil
+}
+
+// rotatingOAuth2Source renews its token on every call, like an oauth2 source whose token expired
+type rotatingOAuth2Source struct{ issued int32 }
+
+func (s *rotatingOAuth2Source) Token() (*fakeOAuth2Token, error) {
+	return &fakeOAuth2Token{AccessToken: fmt.Sprintf("t%d", atomic.AddInt32(&s.issued, 1))}, nil
+}
+
+func testJWT(exp time.Time) string {
+	enc := base64.RawURLEncoding
+	payload, _ := json.Marshal(map[string]interface{}{"sub": "alice@example.com", "exp": exp.Unix()})
+	return enc.EncodeToString([]byte(`{"alg":"HS256"}`)) + "." + enc.EncodeToString(payload) + ".sig"
+}
+
+func TestTokenSource(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("caches tokens until they expire", func(t *testing.T) {
+		headers := make(chan http.Header, 3)
+		var issued int32
+		src := TokenSourceFunc(func() (*Token, error) {
+			n := atomic.AddInt32(&issued, 1)
+			// The first token is about to expire and must be renewed on the next call
+			expiry := time.Now().Add(time.Hour)
+			if n == 1 {
+				expiry = time.Now().Add(tokenExpiryDelta + 100*time.Millisecond)
+			}
+			return &Token{AccessToken: fmt.Sprintf("t%d", n), Expiry: expiry}, nil
+		})
+		client, err := NewClient(newHeaderServer(t, headers), WithTokenSource(src))
+		require.NoError(t, err)
+
+		_, err = client.ListFlags(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer t1", (<-headers).Get("Authorization"))
+
+		time.Sleep(150 * time.Millisecond)
+		for i := 0; i < 2; i++ {
+			_, err = client.ListFlags(ctx, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer t2", (<-headers).Get("Authorization"))
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&issued))
+	})
+
+	t.Run("401 renews the token and replays the request once", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			if r.Header.Get("Authorization") != "Bearer fresh" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, "checkout", body["key"], "body is replayed")
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"id":1,"key":"checkout","description":"","enabled":false,"dataRecordsEnabled":false}`))
+		}))
+		defer server.Close()
+
+		var issued int32
+		src := TokenSourceFunc(func() (*Token, error) {
+			if atomic.AddInt32(&issued, 1) == 1 {
+				return &Token{AccessToken: "revoked"}, nil
+			}
+			return &Token{AccessToken: "fresh"}, nil
+		})
+		client, err := NewClient(server.URL, WithTokenSource(src))
+		require.NoError(t, err)
+		_, err = client.CreateFlag(ctx, &CreateFlagInput{Key: "checkout"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("persistent 401 is returned after one replay", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		var issued int32
+		src := TokenSourceFunc(func() (*Token, error) {
+			return &Token{AccessToken: fmt.Sprintf("t%d", atomic.AddInt32(&issued, 1))}, nil
+		})
+		client, err := NewClient(server.URL, WithTokenSource(src))
+		require.NoError(t, err)
+		_, err = client.ListFlags(ctx, nil)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("sends the token type as the scheme", func(t *testing.T) {
+		headers := make(chan http.Header, 2)
+		for _, tc := range []struct{ tokenType, want string }{
+			{"bearer", "Bearer t1"},
+			{"DPoP", "DPoP t1"},
+		} {
+			src := TokenSourceFunc(func() (*Token, error) {
+				return &Token{AccessToken: "t1", TokenType: tc.tokenType}, nil
+			})
+			client, err := NewClient(newHeaderServer(t, headers), WithTokenSource(src))
+			require.NoError(t, err)
+			_, err = client.ListFlags(ctx, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, (<-headers).Get("Authorization"))
+		}
+	})
+
+	t.Run("OAuth2TokenSource adapts oauth2-shaped sources", func(t *testing.T) {
+		headers := make(chan http.Header, 1)
+		var src fakeOAuth2Source = staticOAuth2Source{token: "cc-token"}
+		client, err := NewClient(newHeaderServer(t, headers), WithTokenSource(OAuth2TokenSource(src)))
+		require.NoError(t, err)
+		_, err = client.ListFlags(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer cc-token", (<-headers).Get("Authorization"))
+	})
+
+	t.Run("OAuth2TokenSource does not replay a 401 with the same token", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		var src fakeOAuth2Source = staticOAuth2Source{token: "revoked"}
+		client, err := NewClient(server.URL, WithTokenSource(OAuth2TokenSource(src)))
+		require.NoError(t, err)
+		_, err = client.ListFlags(ctx, nil)
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("OAuth2TokenSource asks the oauth2 source for every request", func(t *testing.T) {
+		headers := make(chan http.Header, 2)
+		src := &rotatingOAuth2Source{}
+		client, err := NewClient(newHeaderServer(t, headers), WithTokenSource(OAuth2TokenSource(src)))
+		require.NoError(t, err)
+		for _, want := range []string{"Bearer t1", "Bearer t2"} {
+			_, err = client.ListFlags(ctx, nil)
+			require.NoError(t, err)
+			assert.Equal(t, want, (<-headers).Get("Authorization"))
+		}
+	})
+}
+
+func TestSessionLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("WithLogin logs in at /auth/login", func(t *testing.T) {
+		var logins int32
+		jwt := testJWT(time.Now().Add(time.Hour))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			switch r.URL.Path {
+			case "/auth/login":
+				atomic.AddInt32(&logins, 1)
+				body := map[string]string{}
+				json.NewDecoder(r.Body).Decode(&body)
+				assert.Equal(t, "alice@example.com", body["email"])
+				assert.Equal(t, "s3cret", body["password"])
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"token": jwt,
+					"user":  map[string]string{"id": "7", "email": "alice@example.com", "name": "Alice"},
+				})
+			case "/api/v1/flags":
+				assert.Equal(t, "Bearer "+jwt, r.Header.Get("Authorization"))
+				w.Write([]byte(`[]`))
+			default:
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		client, err := NewClient(server.URL+"/api/v1", WithLogin("alice@example.com", "s3cret"))
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err = client.ListFlags(ctx, nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&logins), "JWT is reused until it expires")
+	})
+
+	t.Run("login uses the context of the request", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		client, err := NewClient(server.URL+"/api/v1", WithLogin("alice@example.com", "s3cret"), WithMaxRetries(0))
+		require.NoError(t, err)
+		cancelled, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = client.ListFlags(cancelled, nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second, "login is not bound to the default timeout")
+	})
+
+	t.Run("SessionTokenSource reports failed logins", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Invalid email or password"}`))
+		}))
+		defer server.Close()
+
+		src := NewSessionTokenSource(server.URL+"/auth/login", "alice@example.com", "wrong")
+		_, err := src.Token()
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+		assert.Nil(t, src.User())
+	})
+
+	t.Run("SessionTokenSource reads JWT expiry", func(t *testing.T) {
+		exp := time.Now().Add(time.Hour).Truncate(time.Second)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"token": testJWT(exp),
+				"user":  map[string]string{"id": "admin", "email": "alice@example.com", "name": "alice"},
+			})
+		}))
+		defer server.Close()
+
+		src := NewSessionTokenSource(server.URL+"/auth/login", "alice@example.com", "s3cret")
+		token, err := src.Token()
+		require.NoError(t, err)
+		assert.True(t, token.Expiry.Equal(exp))
+		assert.Equal(t, "alice@example.com", src.User().Email)
+	})
+}
+
+func TestJWTExpiry(t *testing.T) {
+	assert.True(t, jwtExpiry("not-a-jwt").IsZero())
+	assert.True(t, jwtExpiry("a.!!!.c").IsZero())
+	exp := time.Unix(1893456000, 0)
+	assert.True(t, jwtExpiry(testJWT(exp)).Equal(exp))
+}
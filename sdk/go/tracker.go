@@ -0,0 +1,489 @@
+package flagent
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTrackerBatchSize     = 100
+	defaultTrackerFlushInterval = 10 * time.Second
+	defaultTrackerMaxQueueSize  = 10000
+	defaultTrackerMaxRetries    = 3
+	defaultTrackerRetryDelay    = time.Second
+	maxTrackedAssignments       = 10000
+	trackerSpoolFile            = "flagent-events.jsonl"
+)
+
+// ErrTrackerClosed is returned by Track after Close
+var ErrTrackerClosed = errors.New("tracker is closed")
+
+// TrackerConfig configures a Tracker. Zero fields take the defaults.
+type TrackerConfig struct {
+	// BatchSize is the number of events sent per request; a full batch is sent
+	// right away (default: 100)
+	BatchSize int
+
+	// FlushInterval is how often queued events are sent (default: 10s)
+	FlushInterval time.Duration
+
+	// MaxQueueSize bounds the queue; when it is full the oldest events are dropped (default: 10000)
+	MaxQueueSize int
+
+	// MaxRetries is the number of retries of a failed batch before it is put back in
+	// the queue for the next flush (default: 3, negative disables retries)
+	MaxRetries int
+
+	// RetryDelay is the delay before the first retry, doubled on every further retry (default: 1s)
+	RetryDelay time.Duration
+
+	// SpoolDir keeps queued events in a file in this directory, so events that were
+	// not sent survive a restart. Use one directory per tracker. Empty keeps events in memory only.
+	SpoolDir string
+
+	// Platform and AppVersion are sent with every event that does not set its own
+	Platform   string
+	AppVersion string
+
+	// OnError is called when a batch is dropped, a flush fails or the spool cannot be written
+	OnError func(err error)
+}
+
+// Event is an analytics event for TrackEvent
+type Event struct {
+	Name string
+	// EntityID is the user the event belongs to
+	EntityID  string
+	SessionID string
+	Params    map[string]interface{}
+	// FlagID and VariantID tag the event with an experiment variant. When zero they
+	// are taken from the entity's most recent evaluation.
+	FlagID     int64
+	VariantID  int64
+	Platform   string
+	AppVersion string
+	// Timestamp defaults to the time of the TrackEvent call
+	Timestamp time.Time
+}
+
+// trackedEvent is the wire format of an event, also used for the spool file
+type trackedEvent struct {
+	EventName   string `json:"eventName"`
+	EventParams string `json:"eventParams,omitempty"`
+	FlagID      int64  `json:"flagId,omitempty"`
+	VariantID   int64  `json:"variantId,omitempty"`
+	UserID      string `json:"userId,omitempty"`
+	SessionID   string `json:"sessionId,omitempty"`
+	Platform    string `json:"platform,omitempty"`
+	AppVersion  string `json:"appVersion,omitempty"`
+	TimestampMs int64  `json:"timestampMs"`
+}
+
+// assignment is the flag variant an entity was last evaluated to
+type assignment struct {
+	entityID  string
+	flagID    int64
+	variantID int64
+}
+
+// Tracker sends analytics events to /analytics/events in the background. Events are
+// queued by Track and sent in batches when BatchSize events are queued or every
+// FlushInterval, with retries. Events are tagged with the flag and variant the
+// entity was assigned by the client's most recent Evaluate or EvaluateBatch call.
+// Call Close to send the remaining events before exiting.
+type Tracker struct {
+	client *Client
+	config TrackerConfig
+
+	mu       sync.Mutex
+	queue    []trackedEvent
+	inflight []trackedEvent
+	spool    *os.File
+	closed   bool
+
+	assignMu    sync.Mutex
+	assignments map[string]*list.Element
+	assignOrder *list.List
+
+	// flushMu serializes flushes so batches are sent in order
+	flushMu sync.Mutex
+	trigger chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	// unobserve detaches the tracker from the client's evaluations
+	unobserve func()
+}
+
+// NewTracker creates a tracker that sends events with client. With SpoolDir set,
+// events left over from a previous run are loaded and sent.
+func NewTracker(client *Client, config TrackerConfig) (*Tracker, error) {
+	if client == nil {
+		return nil, NewInvalidConfigError("client is required", nil)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultTrackerBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultTrackerFlushInterval
+	}
+	if config.MaxQueueSize <= 0 {
+		config.MaxQueueSize = defaultTrackerMaxQueueSize
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = defaultTrackerMaxRetries
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultTrackerRetryDelay
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Tracker{
+		client:      client,
+		config:      config,
+		assignments: make(map[string]*list.Element),
+		assignOrder: list.New(),
+		trigger:     make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	if config.SpoolDir != "" {
+		if err := t.openSpool(); err != nil {
+			cancel()
+			return nil, NewInvalidConfigError("cannot open event spool", err)
+		}
+	}
+	t.unobserve = client.observeEvaluations(t.recordResult)
+	go t.run()
+	return t, nil
+}
+
+// Track queues an event for entityID. params are sent as the event's JSON parameters.
+// Track does not block on the network; ctx is only checked for cancellation.
+func (t *Tracker) Track(ctx context.Context, entityID, eventName string, params map[string]interface{}) error {
+	return t.TrackEvent(ctx, Event{Name: eventName, EntityID: entityID, Params: params})
+}
+
+// TrackEvent queues an event with all its fields
+func (t *Tracker) TrackEvent(ctx context.Context, event Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if event.Name == "" {
+		return NewInvalidConfigError("event name is required", nil)
+	}
+	e := trackedEvent{
+		EventName:   event.Name,
+		FlagID:      event.FlagID,
+		VariantID:   event.VariantID,
+		UserID:      event.EntityID,
+		SessionID:   event.SessionID,
+		Platform:    event.Platform,
+		AppVersion:  event.AppVersion,
+		TimestampMs: event.Timestamp.UnixMilli(),
+	}
+	if len(event.Params) > 0 {
+		params, err := json.Marshal(event.Params)
+		if err != nil {
+			return NewInvalidConfigError("cannot encode event params", err)
+		}
+		e.EventParams = string(params)
+	}
+	if e.FlagID == 0 && e.VariantID == 0 && e.UserID != "" {
+		if a, ok := t.assignment(e.UserID); ok {
+			e.FlagID, e.VariantID = a.flagID, a.variantID
+		}
+	}
+	if e.Platform == "" {
+		e.Platform = t.config.Platform
+	}
+	if e.AppVersion == "" {
+		e.AppVersion = t.config.AppVersion
+	}
+	if event.Timestamp.IsZero() {
+		e.TimestampMs = time.Now().UnixMilli()
+	}
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return ErrTrackerClosed
+	}
+	dropped := t.enqueue(e)
+	full := len(t.queue) >= t.config.BatchSize
+	spoolErr := t.appendSpool(e)
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		t.report(fmt.Errorf("event queue is full, dropped %d oldest events", dropped))
+	}
+	if spoolErr != nil {
+		t.report(fmt.Errorf("cannot write event spool: %w", spoolErr))
+	}
+	if full {
+		select {
+		case t.trigger <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// RecordAssignment tags later events of entityID with a flag variant, e.g. after a
+// local evaluation that did not go through the client
+func (t *Tracker) RecordAssignment(entityID string, flagID, variantID int64) {
+	t.assignMu.Lock()
+	defer t.assignMu.Unlock()
+	if el, ok := t.assignments[entityID]; ok {
+		a := el.Value.(*assignment)
+		a.flagID, a.variantID = flagID, variantID
+		t.assignOrder.MoveToFront(el)
+		return
+	}
+	t.assignments[entityID] = t.assignOrder.PushFront(&assignment{entityID: entityID, flagID: flagID, variantID: variantID})
+	if t.assignOrder.Len() > maxTrackedAssignments {
+		oldest := t.assignOrder.Back()
+		t.assignOrder.Remove(oldest)
+		delete(t.assignments, oldest.Value.(*assignment).entityID)
+	}
+}
+
+// Flush sends all queued events and returns the first error. Events that could not
+// be sent stay queued.
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.flushMu.Lock()
+	defer t.flushMu.Unlock()
+	for {
+		t.mu.Lock()
+		n := len(t.queue)
+		if n > t.config.BatchSize {
+			n = t.config.BatchSize
+		}
+		batch := t.queue[:n:n]
+		t.queue = t.queue[n:]
+		t.inflight = batch
+		t.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := t.send(ctx, batch)
+		var dropErr *batchDropError
+		if errors.As(err, &dropErr) {
+			t.report(err)
+			err = nil
+		}
+
+		t.mu.Lock()
+		t.inflight = nil
+		dropped := 0
+		if err != nil {
+			// Put the batch back in front of newer events
+			t.queue = append(batch, t.queue...)
+			if over := len(t.queue) - t.config.MaxQueueSize; over > 0 {
+				t.queue = t.queue[over:]
+				dropped = over
+			}
+		}
+		spoolErr := t.rewriteSpool(err == nil || dropped > 0)
+		t.mu.Unlock()
+
+		if dropped > 0 {
+			t.report(fmt.Errorf("event queue is full, dropped %d oldest events", dropped))
+		}
+		if spoolErr != nil {
+			t.report(fmt.Errorf("cannot write event spool: %w", spoolErr))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Close stops the background flushes and sends the remaining events. Events that
+// cannot be sent before ctx is done stay in the spool, if there is one.
+func (t *Tracker) Close(ctx context.Context) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.mu.Unlock()
+
+	t.unobserve()
+	close(t.stop)
+	t.cancel()
+	<-t.done
+
+	err := t.Flush(ctx)
+
+	t.mu.Lock()
+	if t.spool != nil {
+		if closeErr := t.spool.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		t.spool = nil
+	}
+	t.mu.Unlock()
+	return err
+}
+
+// run flushes when a batch is full and every FlushInterval
+func (t *Tracker) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		case <-t.trigger:
+		}
+		if err := t.Flush(t.ctx); err != nil && t.ctx.Err() == nil {
+			t.report(err)
+		}
+	}
+}
+
+// send posts a batch, retrying transient failures with backoff
+func (t *Tracker) send(ctx context.Context, batch []trackedEvent) error {
+	body := struct {
+		Events []trackedEvent `json:"events"`
+	}{Events: batch}
+	return postBatch(ctx, t.client, FeatureAnalytics, "/analytics/events", "analytics events", body, len(batch), t.config.MaxRetries, t.config.RetryDelay)
+}
+
+// enqueue appends e, dropping the oldest events beyond MaxQueueSize. Called with mu held.
+func (t *Tracker) enqueue(e trackedEvent) int {
+	t.queue = append(t.queue, e)
+	over := len(t.queue) - t.config.MaxQueueSize
+	if over <= 0 {
+		return 0
+	}
+	t.queue = t.queue[over:]
+	return over
+}
+
+// recordResult is the client's evaluation observer
+func (t *Tracker) recordResult(_ context.Context, entityID string, result *EvaluationResult) {
+	if entityID == "" || result == nil || result.EvalResult == nil {
+		return
+	}
+	variantID := result.GetVariantID()
+	if variantID == 0 {
+		return
+	}
+	t.RecordAssignment(entityID, result.GetFlagID(), variantID)
+}
+
+func (t *Tracker) assignment(entityID string) (assignment, bool) {
+	t.assignMu.Lock()
+	defer t.assignMu.Unlock()
+	el, ok := t.assignments[entityID]
+	if !ok {
+		return assignment{}, false
+	}
+	return *el.Value.(*assignment), true
+}
+
+func (t *Tracker) report(err error) {
+	if t.config.OnError != nil {
+		t.config.OnError(err)
+	}
+}
+
+// openSpool loads events left in the spool file and opens it for appending
+func (t *Tracker) openSpool() error {
+	if err := os.MkdirAll(t.config.SpoolDir, 0o700); err != nil {
+		return err
+	}
+	path := filepath.Join(t.config.SpoolDir, trackerSpoolFile)
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for scanner.Scan() {
+			var e trackedEvent
+			// Skip a line cut short by a crash
+			if json.Unmarshal(scanner.Bytes(), &e) == nil && e.EventName != "" {
+				t.enqueue(e)
+			}
+		}
+		f.Close()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return t.rewriteSpool(true)
+}
+
+// appendSpool writes e to the spool. Called with mu held.
+func (t *Tracker) appendSpool(e trackedEvent) error {
+	if t.spool == nil {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = t.spool.Write(append(line, '\n'))
+	return err
+}
+
+// rewriteSpool replaces the spool with the events not yet sent, when they changed.
+// Called with mu held.
+func (t *Tracker) rewriteSpool(changed bool) error {
+	if t.config.SpoolDir == "" || !changed {
+		return nil
+	}
+	path := filepath.Join(t.config.SpoolDir, trackerSpoolFile)
+	tmp, err := os.CreateTemp(t.config.SpoolDir, trackerSpoolFile+".*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, events := range [][]trackedEvent{t.inflight, t.queue} {
+		for _, e := range events {
+			line, _ := json.Marshal(e)
+			w.Write(line)
+			w.WriteByte('\n')
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if t.spool != nil {
+		t.spool.Close()
+		t.spool = nil
+	}
+	if t.closed {
+		// Nothing is appended after Close
+		return nil
+	}
+	t.spool, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	return err
+}
@@ -0,0 +1,213 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEventsServer starts a server accepting analytics event batches
+func newEventsServer(t *testing.T) *batchServer {
+	t.Helper()
+	return newBatchServer(t, "/analytics/events", "events", http.StatusOK)
+}
+
+func newTestTracker(t *testing.T, server *batchServer, config TrackerConfig) *Tracker {
+	t.Helper()
+	client := server.newClient(t)
+	if config.FlushInterval == 0 {
+		config.FlushInterval = time.Hour
+	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = time.Millisecond
+	}
+	tracker, err := NewTracker(client, config)
+	require.NoError(t, err)
+	t.Cleanup(func() { tracker.Close(context.Background()) })
+	return tracker
+}
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("sends full batches in the background", func(t *testing.T) {
+		server := newEventsServer(t)
+		tracker := newTestTracker(t, server, TrackerConfig{BatchSize: 2, Platform: "server", AppVersion: "1.4.0"})
+		require.NoError(t, tracker.Track(ctx, "user1", "purchase", map[string]interface{}{"amount": 42}))
+		require.NoError(t, tracker.Track(ctx, "user2", "signup", nil))
+
+		assert.Eventually(t, func() bool { return len(server.received()) == 2 }, time.Second, time.Millisecond)
+		event := server.received()[0]
+		assert.Equal(t, "purchase", event["eventName"])
+		assert.Equal(t, `{"amount":42}`, event["eventParams"])
+		assert.Equal(t, "user1", event["userId"])
+		assert.Equal(t, "server", event["platform"])
+		assert.Equal(t, "1.4.0", event["appVersion"])
+		assert.NotZero(t, event["timestampMs"])
+		assert.NotContains(t, server.received()[1], "eventParams")
+	})
+
+	t.Run("flushes on the interval", func(t *testing.T) {
+		server := newEventsServer(t)
+		tracker := newTestTracker(t, server, TrackerConfig{FlushInterval: 10 * time.Millisecond})
+		require.NoError(t, tracker.Track(ctx, "user1", "screen_view", nil))
+		assert.Eventually(t, func() bool { return len(server.received()) == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("tags events with the entity's assignment", func(t *testing.T) {
+		server := newEventsServer(t)
+		tracker := newTestTracker(t, server, TrackerConfig{})
+		_, err := tracker.client.Evaluate(ctx, &EvaluationContext{FlagKey: stringPtr("checkout"), EntityID: stringPtr("user1")})
+		require.NoError(t, err)
+		tracker.RecordAssignment("user2", 6, 11)
+
+		require.NoError(t, tracker.Track(ctx, "user1", "purchase", nil))
+		require.NoError(t, tracker.Track(ctx, "user2", "purchase", nil))
+		require.NoError(t, tracker.Track(ctx, "user3", "purchase", nil))
+		require.NoError(t, tracker.TrackEvent(ctx, Event{Name: "purchase", EntityID: "user1", FlagID: 7, VariantID: 12}))
+		require.NoError(t, tracker.Flush(ctx))
+
+		events := server.received()
+		require.Len(t, events, 4)
+		assert.Equal(t, []interface{}{5.0, 9.0}, []interface{}{events[0]["flagId"], events[0]["variantId"]})
+		assert.Equal(t, []interface{}{6.0, 11.0}, []interface{}{events[1]["flagId"], events[1]["variantId"]})
+		assert.NotContains(t, events[2], "flagId")
+		assert.Equal(t, []interface{}{7.0, 12.0}, []interface{}{events[3]["flagId"], events[3]["variantId"]})
+	})
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		server := newEventsServer(t)
+		server.status = func(request int32) int {
+			if request <= 2 {
+				return http.StatusServiceUnavailable
+			}
+			return http.StatusOK
+		}
+		tracker := newTestTracker(t, server, TrackerConfig{})
+		require.NoError(t, tracker.Track(ctx, "user1", "purchase", nil))
+		require.NoError(t, tracker.Flush(ctx))
+		assert.Len(t, server.received(), 1)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&server.requests))
+	})
+
+	t.Run("keeps events when the server is down", func(t *testing.T) {
+		server := newEventsServer(t)
+		var down atomic.Bool
+		down.Store(true)
+		server.status = func(int32) int {
+			if down.Load() {
+				return http.StatusBadGateway
+			}
+			return http.StatusOK
+		}
+		tracker := newTestTracker(t, server, TrackerConfig{MaxRetries: -1})
+		require.NoError(t, tracker.Track(ctx, "user1", "first", nil))
+		err := tracker.Flush(ctx)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrServerUnavailable))
+
+		require.NoError(t, tracker.Track(ctx, "user1", "second", nil))
+		down.Store(false)
+		require.NoError(t, tracker.Flush(ctx))
+		events := server.received()
+		require.Len(t, events, 2)
+		assert.Equal(t, "first", events[0]["eventName"])
+	})
+
+	t.Run("drops batches the server rejects", func(t *testing.T) {
+		server := newEventsServer(t)
+		server.status = func(int32) int { return http.StatusBadRequest }
+		var reported []error
+		tracker := newTestTracker(t, server, TrackerConfig{OnError: func(err error) { reported = append(reported, err) }})
+		require.NoError(t, tracker.Track(ctx, "user1", "purchase", nil))
+		require.NoError(t, tracker.Flush(ctx))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&server.requests), "rejected batches are not retried")
+		require.Len(t, reported, 1)
+		assert.True(t, errors.Is(reported[0], ErrBadRequest))
+		var requestErr *RequestError
+		assert.True(t, errors.As(reported[0], &requestErr), "rejected batches are request errors, not evaluation errors")
+	})
+
+	t.Run("bounded queue drops the oldest events", func(t *testing.T) {
+		server := newEventsServer(t)
+		tracker := newTestTracker(t, server, TrackerConfig{MaxQueueSize: 2})
+		for _, name := range []string{"a", "b", "c"} {
+			require.NoError(t, tracker.Track(ctx, "user1", name, nil))
+		}
+		require.NoError(t, tracker.Flush(ctx))
+		events := server.received()
+		require.Len(t, events, 2)
+		assert.Equal(t, "b", events[0]["eventName"])
+	})
+
+	t.Run("Close sends remaining events", func(t *testing.T) {
+		server := newEventsServer(t)
+		tracker := newTestTracker(t, server, TrackerConfig{})
+		require.NoError(t, tracker.Track(ctx, "user1", "purchase", nil))
+		require.NoError(t, tracker.Close(ctx))
+		assert.Len(t, server.received(), 1)
+		assert.True(t, errors.Is(tracker.Track(ctx, "user1", "purchase", nil), ErrTrackerClosed))
+	})
+
+	t.Run("Close detaches from the client", func(t *testing.T) {
+		server := newEventsServer(t)
+		tracker := newTestTracker(t, server, TrackerConfig{})
+		require.NoError(t, tracker.Close(ctx))
+		_, err := tracker.client.Evaluate(ctx, &EvaluationContext{FlagKey: stringPtr("checkout"), EntityID: stringPtr("user1")})
+		require.NoError(t, err)
+		_, ok := tracker.assignment("user1")
+		assert.False(t, ok, "evaluations after Close are not recorded")
+		assert.Empty(t, tracker.client.observers)
+	})
+
+	t.Run("invalid events", func(t *testing.T) {
+		server := newEventsServer(t)
+		tracker := newTestTracker(t, server, TrackerConfig{})
+		var configErr *InvalidConfigError
+		assert.True(t, errors.As(tracker.Track(ctx, "user1", "", nil), &configErr))
+		assert.True(t, errors.As(tracker.Track(ctx, "user1", "purchase", map[string]interface{}{"f": func() {}}), &configErr))
+	})
+}
+
+func TestTrackerSpool(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	server := newEventsServer(t)
+	var down atomic.Bool
+	down.Store(true)
+	server.status = func(int32) int {
+		if down.Load() {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	}
+
+	tracker := newTestTracker(t, server, TrackerConfig{SpoolDir: dir, MaxRetries: -1})
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, tracker.Track(ctx, "user1", name, nil))
+	}
+	require.Error(t, tracker.Close(ctx))
+
+	// A new tracker, e.g. after a restart, sends the spooled events
+	down.Store(false)
+	restarted := newTestTracker(t, server, TrackerConfig{SpoolDir: dir})
+	require.NoError(t, restarted.Track(ctx, "user1", "d", nil))
+	require.NoError(t, restarted.Flush(ctx))
+	var names []interface{}
+	for _, e := range server.received() {
+		names = append(names, e["eventName"])
+	}
+	assert.Equal(t, []interface{}{"a", "b", "c", "d"}, names)
+
+	// Sent events are removed from the spool
+	require.NoError(t, restarted.Close(ctx))
+	again := newTestTracker(t, server, TrackerConfig{SpoolDir: dir})
+	require.NoError(t, again.Flush(ctx))
+	assert.Len(t, server.received(), 4)
+}
@@ -0,0 +1,342 @@
+package flagent
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader carries "sha256=" and the base64 HMAC-SHA256 of the body
+	WebhookSignatureHeader = "X-Flagent-Signature"
+	// WebhookEventHeader carries the event name
+	WebhookEventHeader = "X-Flagent-Event"
+	// WebhookDeliveryHeader carries a unique ID for every delivery attempt
+	WebhookDeliveryHeader = "X-Flagent-Delivery"
+
+	defaultWebhookTolerance = 5 * time.Minute
+	maxWebhookBodySize      = 10 << 20
+	webhookRefreshTimeout   = time.Minute
+)
+
+// errWebhookPayload marks deliveries whose payload does not match their event
+var errWebhookPayload = errors.New("invalid webhook payload")
+
+// WebhookRefresher reloads flags after a change, e.g. flagentenhanced.OfflineManager
+type WebhookRefresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// WebhookReceiverConfig configures a WebhookReceiver
+type WebhookReceiverConfig struct {
+	// Secret is the webhook's HMAC secret (required). The server only signs deliveries
+	// for webhooks that have a secret.
+	Secret string
+
+	// Tolerance is how far the payload timestamp may be from the current time before
+	// the delivery is rejected as a replay (default: 5m). It must cover the server's
+	// retries: when it cannot connect, it resends the original payload after 1s and
+	// again after another 5s.
+	Tolerance time.Duration
+
+	// Refresher is refreshed in the background after every flag event, so services
+	// that cannot hold an SSE connection still pick up changes quickly. Bursts of
+	// events cause at most one extra refresh.
+	Refresher WebhookRefresher
+
+	// OnError is called when a background refresh fails, and with a
+	// *WebhookHandlerError when a handler fails. The server does not redeliver events
+	// whose handler failed, so keep the error's Delivery to process it again.
+	OnError func(err error)
+}
+
+// WebhookDelivery describes a verified webhook delivery
+type WebhookDelivery struct {
+	// ID is the X-Flagent-Delivery header; the server sends a new ID on every retry
+	ID        string
+	Event     WebhookEvent
+	Timestamp time.Time
+	// Body is the raw, verified payload
+	Body []byte
+}
+
+// FlagEvent is the payload of flag.created, flag.updated, flag.enabled and flag.disabled
+type FlagEvent struct {
+	WebhookDelivery
+	Flag Flag
+}
+
+// FlagDeletedEvent is the payload of flag.deleted
+type FlagDeletedEvent struct {
+	WebhookDelivery
+	FlagID  int64
+	FlagKey string
+}
+
+// webhookPayloadJSON is the wire format of a delivery
+type webhookPayloadJSON struct {
+	Event           string          `json:"event"`
+	Timestamp       int64           `json:"timestamp"`
+	FlagData        json.RawMessage `json:"flagData"`
+	FlagDeletedData *struct {
+		FlagID  int64  `json:"flagId"`
+		FlagKey string `json:"flagKey"`
+	} `json:"flagDeletedData"`
+}
+
+// WebhookHandlerError reports a delivery whose handler failed
+type WebhookHandlerError struct {
+	Delivery WebhookDelivery
+	Err      error
+}
+
+func (e *WebhookHandlerError) Error() string {
+	return fmt.Sprintf("webhook handler for %s delivery %s failed: %v", e.Delivery.Event, e.Delivery.ID, e.Err)
+}
+
+func (e *WebhookHandlerError) Unwrap() error {
+	return e.Err
+}
+
+// WebhookReceiver is an http.Handler for Flagent webhooks. It verifies the signature,
+// rejects stale payloads, drops retries of deliveries it already handled and calls the
+// handler registered for the event. Handlers run on the request goroutine. A handler
+// error is answered with 500 and reported to OnError; the server only retries
+// deliveries it could not send, so it does not redeliver the event. Register handlers
+// before serving requests.
+type WebhookReceiver struct {
+	config WebhookReceiverConfig
+	now    func() time.Time
+
+	flagHandlers    map[WebhookEvent]func(ctx context.Context, event *FlagEvent) error
+	deletedHandler  func(ctx context.Context, event *FlagDeletedEvent) error
+	deliveryHandler func(ctx context.Context, delivery *WebhookDelivery) error
+
+	mu sync.Mutex
+	// seen maps the hash of handled payloads to when they can be forgotten
+	seen      map[[sha256.Size]byte]time.Time
+	nextPrune time.Time
+	// refreshing is set while a refresh runs, refreshPending when another one is due after it
+	refreshing     bool
+	refreshPending bool
+}
+
+// NewWebhookReceiver creates a webhook receiver
+func NewWebhookReceiver(config WebhookReceiverConfig) (*WebhookReceiver, error) {
+	if config.Secret == "" {
+		return nil, NewInvalidConfigError("webhook secret is required", nil)
+	}
+	if config.Tolerance <= 0 {
+		config.Tolerance = defaultWebhookTolerance
+	}
+	return &WebhookReceiver{
+		config:       config,
+		now:          time.Now,
+		flagHandlers: make(map[WebhookEvent]func(ctx context.Context, event *FlagEvent) error),
+		seen:         make(map[[sha256.Size]byte]time.Time),
+	}, nil
+}
+
+// OnFlagCreated registers the handler for flag.created
+func (r *WebhookReceiver) OnFlagCreated(fn func(ctx context.Context, event *FlagEvent) error) {
+	r.flagHandlers[WebhookFlagCreated] = fn
+}
+
+// OnFlagUpdated registers the handler for flag.updated
+func (r *WebhookReceiver) OnFlagUpdated(fn func(ctx context.Context, event *FlagEvent) error) {
+	r.flagHandlers[WebhookFlagUpdated] = fn
+}
+
+// OnFlagEnabled registers the handler for flag.enabled
+func (r *WebhookReceiver) OnFlagEnabled(fn func(ctx context.Context, event *FlagEvent) error) {
+	r.flagHandlers[WebhookFlagEnabled] = fn
+}
+
+// OnFlagDisabled registers the handler for flag.disabled
+func (r *WebhookReceiver) OnFlagDisabled(fn func(ctx context.Context, event *FlagEvent) error) {
+	r.flagHandlers[WebhookFlagDisabled] = fn
+}
+
+// OnFlagDeleted registers the handler for flag.deleted
+func (r *WebhookReceiver) OnFlagDeleted(fn func(ctx context.Context, event *FlagDeletedEvent) error) {
+	r.deletedHandler = fn
+}
+
+// OnDelivery registers a handler called for every verified delivery before the
+// event handler, including events without a typed handler such as anomaly.detected
+func (r *WebhookReceiver) OnDelivery(fn func(ctx context.Context, delivery *WebhookDelivery) error) {
+	r.deliveryHandler = fn
+}
+
+// ServeHTTP implements http.Handler
+func (r *WebhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, "cannot read body", http.StatusBadRequest)
+		return
+	}
+	if !r.verify(req.Header.Get(WebhookSignatureHeader), body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var payload webhookPayloadJSON
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Event == "" {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	delivery := WebhookDelivery{
+		ID:        req.Header.Get(WebhookDeliveryHeader),
+		Event:     WebhookEvent(payload.Event),
+		Timestamp: time.UnixMilli(payload.Timestamp),
+		Body:      body,
+	}
+	if age := r.now().Sub(delivery.Timestamp); age > r.config.Tolerance || age < -r.config.Tolerance {
+		http.Error(w, "stale payload", http.StatusBadRequest)
+		return
+	}
+
+	// The server resends the same payload when it cannot connect, so the body identifies it
+	key := sha256.Sum256(body)
+	if !r.markSeen(key) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := r.dispatch(req.Context(), &delivery, &payload); err != nil {
+		r.forget(key)
+		if errors.Is(err, errWebhookPayload) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.config.OnError != nil {
+			r.config.OnError(&WebhookHandlerError{Delivery: delivery, Err: err})
+		}
+		http.Error(w, "webhook handler failed", http.StatusInternalServerError)
+		return
+	}
+	if r.config.Refresher != nil && strings.HasPrefix(payload.Event, "flag.") {
+		r.refresh()
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify checks the signature header in constant time
+func (r *WebhookReceiver) verify(header string, body []byte) bool {
+	encoded, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(r.config.Secret))
+	mac.Write(body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// dispatch decodes the typed payload and calls the registered handlers
+func (r *WebhookReceiver) dispatch(ctx context.Context, delivery *WebhookDelivery, payload *webhookPayloadJSON) error {
+	if r.deliveryHandler != nil {
+		if err := r.deliveryHandler(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	if delivery.Event == WebhookFlagDeleted {
+		if r.deletedHandler == nil {
+			return nil
+		}
+		if payload.FlagDeletedData == nil {
+			return fmt.Errorf("%w: flag.deleted without flagDeletedData", errWebhookPayload)
+		}
+		return r.deletedHandler(ctx, &FlagDeletedEvent{
+			WebhookDelivery: *delivery,
+			FlagID:          payload.FlagDeletedData.FlagID,
+			FlagKey:         payload.FlagDeletedData.FlagKey,
+		})
+	}
+	handler := r.flagHandlers[delivery.Event]
+	if handler == nil {
+		return nil
+	}
+	event := &FlagEvent{WebhookDelivery: *delivery}
+	if err := json.Unmarshal(payload.FlagData, &event.Flag); err != nil {
+		return fmt.Errorf("%w: %s: %v", errWebhookPayload, delivery.Event, err)
+	}
+	return handler(ctx, event)
+}
+
+// markSeen records a payload and reports whether it is new
+func (r *WebhookReceiver) markSeen(key [sha256.Size]byte) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if now.After(r.nextPrune) {
+		for k, expires := range r.seen {
+			if now.After(expires) {
+				delete(r.seen, k)
+			}
+		}
+		r.nextPrune = now.Add(r.config.Tolerance / 4)
+	}
+	if expires, ok := r.seen[key]; ok && !now.After(expires) {
+		return false
+	}
+	// Past two tolerances the payload is rejected as stale anyway
+	r.seen[key] = now.Add(2 * r.config.Tolerance)
+	return true
+}
+
+// forget lets a failed payload be handled again if it arrives again within Tolerance
+func (r *WebhookReceiver) forget(key [sha256.Size]byte) {
+	r.mu.Lock()
+	delete(r.seen, key)
+	r.mu.Unlock()
+}
+
+// refresh starts a background refresh, or schedules one after the running refresh
+// so changes made while it was in flight are not missed
+func (r *WebhookReceiver) refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.refreshing {
+		r.refreshPending = true
+		return
+	}
+	r.refreshing = true
+	go r.runRefresh()
+}
+
+func (r *WebhookReceiver) runRefresh() {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookRefreshTimeout)
+		err := r.config.Refresher.Refresh(ctx)
+		cancel()
+		if err != nil && r.config.OnError != nil {
+			r.config.OnError(err)
+		}
+
+		r.mu.Lock()
+		if !r.refreshPending {
+			r.refreshing = false
+			r.mu.Unlock()
+			return
+		}
+		r.refreshPending = false
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,210 @@
+package flagent
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "whsec"
+
+// deliverWebhook posts body to the receiver the way the server does
+func deliverWebhook(r *WebhookReceiver, body, secret string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/flagent", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookDeliveryHeader, fmt.Sprintf("delivery-%d", time.Now().UnixNano()))
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		req.Header.Set(WebhookSignatureHeader, "sha256="+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func flagEventBody(event string, timestamp time.Time) string {
+	return fmt.Sprintf(`{"event": %q, "timestamp": %d, "flagData": {"id": 7, "key": "checkout", "description": "",
+		"enabled": true, "dataRecordsEnabled": false, "snapshotID": 3}, "flagDeletedData": null}`, event, timestamp.UnixMilli())
+}
+
+func newTestReceiver(t *testing.T, config WebhookReceiverConfig) *WebhookReceiver {
+	t.Helper()
+	config.Secret = testWebhookSecret
+	receiver, err := NewWebhookReceiver(config)
+	require.NoError(t, err)
+	return receiver
+}
+
+type countingRefresher struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (r *countingRefresher) Refresh(ctx context.Context) error {
+	atomic.AddInt32(&r.calls, 1)
+	if r.release != nil {
+		<-r.release
+	}
+	return nil
+}
+
+func TestWebhookReceiver(t *testing.T) {
+	t.Run("dispatches typed flag events", func(t *testing.T) {
+		receiver := newTestReceiver(t, WebhookReceiverConfig{})
+		var got *FlagEvent
+		receiver.OnFlagUpdated(func(ctx context.Context, event *FlagEvent) error {
+			got = event
+			return nil
+		})
+		rec := deliverWebhook(receiver, flagEventBody("flag.updated", time.Now()), testWebhookSecret)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, got)
+		assert.Equal(t, WebhookFlagUpdated, got.Event)
+		assert.Equal(t, "checkout", got.Flag.Key)
+		assert.Equal(t, int64(7), got.Flag.Id)
+		assert.NotEmpty(t, got.ID)
+	})
+
+	t.Run("dispatches flag.deleted", func(t *testing.T) {
+		receiver := newTestReceiver(t, WebhookReceiverConfig{})
+		var got *FlagDeletedEvent
+		receiver.OnFlagDeleted(func(ctx context.Context, event *FlagDeletedEvent) error {
+			got = event
+			return nil
+		})
+		body := fmt.Sprintf(`{"event": "flag.deleted", "timestamp": %d, "flagData": null,
+			"flagDeletedData": {"flagId": 7, "flagKey": "checkout", "deleted": true}}`, time.Now().UnixMilli())
+		assert.Equal(t, http.StatusOK, deliverWebhook(receiver, body, testWebhookSecret).Code)
+		require.NotNil(t, got)
+		assert.Equal(t, int64(7), got.FlagID)
+		assert.Equal(t, "checkout", got.FlagKey)
+	})
+
+	t.Run("OnDelivery sees untyped events", func(t *testing.T) {
+		receiver := newTestReceiver(t, WebhookReceiverConfig{})
+		var events []WebhookEvent
+		receiver.OnDelivery(func(ctx context.Context, delivery *WebhookDelivery) error {
+			events = append(events, delivery.Event)
+			return nil
+		})
+		body := fmt.Sprintf(`{"event": "anomaly.detected", "timestamp": %d}`, time.Now().UnixMilli())
+		assert.Equal(t, http.StatusOK, deliverWebhook(receiver, body, testWebhookSecret).Code)
+		assert.Equal(t, []WebhookEvent{WebhookAnomalyDetected}, events)
+	})
+
+	t.Run("rejects bad signatures", func(t *testing.T) {
+		receiver := newTestReceiver(t, WebhookReceiverConfig{})
+		receiver.OnFlagUpdated(func(ctx context.Context, event *FlagEvent) error {
+			t.Fatal("handler must not run")
+			return nil
+		})
+		body := flagEventBody("flag.updated", time.Now())
+		assert.Equal(t, http.StatusUnauthorized, deliverWebhook(receiver, body, "wrong").Code)
+		assert.Equal(t, http.StatusUnauthorized, deliverWebhook(receiver, body, "").Code)
+	})
+
+	t.Run("rejects stale payloads", func(t *testing.T) {
+		receiver := newTestReceiver(t, WebhookReceiverConfig{Tolerance: time.Minute})
+		body := flagEventBody("flag.updated", time.Now().Add(-2*time.Minute))
+		assert.Equal(t, http.StatusBadRequest, deliverWebhook(receiver, body, testWebhookSecret).Code)
+	})
+
+	t.Run("drops retries of handled deliveries", func(t *testing.T) {
+		receiver := newTestReceiver(t, WebhookReceiverConfig{})
+		var calls int
+		receiver.OnFlagEnabled(func(ctx context.Context, event *FlagEvent) error {
+			calls++
+			return nil
+		})
+		body := flagEventBody("flag.enabled", time.Now())
+		assert.Equal(t, http.StatusOK, deliverWebhook(receiver, body, testWebhookSecret).Code)
+		assert.Equal(t, http.StatusOK, deliverWebhook(receiver, body, testWebhookSecret).Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("reports handler errors", func(t *testing.T) {
+		var reported []error
+		receiver := newTestReceiver(t, WebhookReceiverConfig{OnError: func(err error) { reported = append(reported, err) }})
+		var calls int
+		receiver.OnFlagCreated(func(ctx context.Context, event *FlagEvent) error {
+			calls++
+			if calls == 1 {
+				return errors.New("database down")
+			}
+			return nil
+		})
+		body := flagEventBody("flag.created", time.Now())
+		assert.Equal(t, http.StatusInternalServerError, deliverWebhook(receiver, body, testWebhookSecret).Code)
+		require.Len(t, reported, 1)
+		var handlerErr *WebhookHandlerError
+		require.True(t, errors.As(reported[0], &handlerErr))
+		assert.Equal(t, WebhookFlagCreated, handlerErr.Delivery.Event)
+		assert.Equal(t, body, string(handlerErr.Delivery.Body))
+		assert.EqualError(t, errors.Unwrap(handlerErr), "database down")
+
+		// The same payload arriving again is handled again
+		assert.Equal(t, http.StatusOK, deliverWebhook(receiver, body, testWebhookSecret).Code)
+		assert.Equal(t, 2, calls)
+		assert.Len(t, reported, 1)
+	})
+
+	t.Run("malformed flag data", func(t *testing.T) {
+		receiver := newTestReceiver(t, WebhookReceiverConfig{})
+		receiver.OnFlagCreated(func(ctx context.Context, event *FlagEvent) error { return nil })
+		body := fmt.Sprintf(`{"event": "flag.created", "timestamp": %d, "flagData": {"id": 1}}`, time.Now().UnixMilli())
+		assert.Equal(t, http.StatusBadRequest, deliverWebhook(receiver, body, testWebhookSecret).Code)
+	})
+
+	t.Run("only accepts POST", func(t *testing.T) {
+		receiver := newTestReceiver(t, WebhookReceiverConfig{})
+		rec := httptest.NewRecorder()
+		receiver.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("secret is required", func(t *testing.T) {
+		_, err := NewWebhookReceiver(WebhookReceiverConfig{})
+		var configErr *InvalidConfigError
+		assert.True(t, errors.As(err, &configErr))
+	})
+}
+
+func TestWebhookReceiverRefresh(t *testing.T) {
+	refresher := &countingRefresher{release: make(chan struct{})}
+	receiver := newTestReceiver(t, WebhookReceiverConfig{Refresher: refresher})
+
+	now := time.Now()
+	deliverWebhook(receiver, flagEventBody("flag.updated", now), testWebhookSecret)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&refresher.calls) == 1 }, time.Second, time.Millisecond)
+
+	// Events during a refresh are coalesced into one more refresh
+	for i := 1; i <= 3; i++ {
+		deliverWebhook(receiver, flagEventBody("flag.updated", now.Add(time.Duration(i)*time.Millisecond)), testWebhookSecret)
+	}
+	refresher.release <- struct{}{}
+	refresher.release <- struct{}{}
+	assert.Eventually(t, func() bool {
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		return !receiver.refreshing
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&refresher.calls))
+
+	// Non-flag events do not refresh
+	deliverWebhook(receiver, fmt.Sprintf(`{"event": "anomaly.detected", "timestamp": %d}`, now.UnixMilli()), testWebhookSecret)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&refresher.calls))
+}
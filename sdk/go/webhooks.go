@@ -0,0 +1,220 @@
+package flagent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WebhookEvent is an event a webhook can subscribe to
+type WebhookEvent string
+
+const (
+	WebhookFlagCreated     WebhookEvent = "flag.created"
+	WebhookFlagUpdated     WebhookEvent = "flag.updated"
+	WebhookFlagDeleted     WebhookEvent = "flag.deleted"
+	WebhookFlagEnabled     WebhookEvent = "flag.enabled"
+	WebhookFlagDisabled    WebhookEvent = "flag.disabled"
+	WebhookAnomalyDetected WebhookEvent = "anomaly.detected"
+)
+
+// WebhookFlagEvents returns all flag lifecycle events
+func WebhookFlagEvents() []WebhookEvent {
+	return []WebhookEvent{
+		WebhookFlagCreated, WebhookFlagUpdated, WebhookFlagDeleted, WebhookFlagEnabled, WebhookFlagDisabled,
+	}
+}
+
+// AllWebhookEvents returns every event the server can deliver
+func AllWebhookEvents() []WebhookEvent {
+	return append(WebhookFlagEvents(), WebhookAnomalyDetected)
+}
+
+// Valid reports whether the server knows the event
+func (e WebhookEvent) Valid() bool {
+	for _, known := range AllWebhookEvents() {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is a registered webhook
+type Webhook struct {
+	ID     int64
+	URL    string
+	Events []WebhookEvent
+	// Secret signs deliveries with HMAC-SHA256 (empty if deliveries are unsigned)
+	Secret    string
+	Enabled   bool
+	TenantID  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookInput is the input for creating and updating webhooks
+type WebhookInput struct {
+	URL    string
+	Events []WebhookEvent
+	// Secret is optional; when set, deliveries carry an HMAC-SHA256 signature
+	Secret string
+	// Enabled defaults to true
+	Enabled *bool
+}
+
+// webhookJSON is the wire format of a webhook; timestamps are Unix milliseconds
+type webhookJSON struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Secret    *string  `json:"secret,omitempty"`
+	Enabled   bool     `json:"enabled"`
+	TenantID  *string  `json:"tenantId,omitempty"`
+	CreatedAt int64    `json:"createdAt"`
+	UpdatedAt int64    `json:"updatedAt"`
+}
+
+func (w webhookJSON) webhook() Webhook {
+	events := make([]WebhookEvent, len(w.Events))
+	for i, e := range w.Events {
+		events[i] = WebhookEvent(e)
+	}
+	webhook := Webhook{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    events,
+		Enabled:   w.Enabled,
+		CreatedAt: time.UnixMilli(w.CreatedAt),
+		UpdatedAt: time.UnixMilli(w.UpdatedAt),
+	}
+	if w.Secret != nil {
+		webhook.Secret = *w.Secret
+	}
+	if w.TenantID != nil {
+		webhook.TenantID = *w.TenantID
+	}
+	return webhook
+}
+
+// webhookRequestJSON is the body of create and update requests
+type webhookRequestJSON struct {
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Secret  string   `json:"secret,omitempty"`
+	Enabled bool     `json:"enabled"`
+}
+
+// WebhookService manages webhooks. Get it with Client.Webhooks.
+type WebhookService struct {
+	client *Client
+}
+
+// Webhooks returns the webhook management API
+func (c *Client) Webhooks() *WebhookService {
+	return &WebhookService{client: c}
+}
+
+// List returns all webhooks
+func (s *WebhookService) List(ctx context.Context) ([]Webhook, error) {
+	if err := s.client.requireFeature(FeatureWebhooks); err != nil {
+		return nil, err
+	}
+	var out []webhookJSON
+	resp, err := s.client.do(ctx, http.MethodGet, "/webhooks", nil, nil, &out)
+	if err != nil {
+		return nil, s.client.featureError(FeatureWebhooks, resp, err, "failed to list webhooks")
+	}
+	webhooks := make([]Webhook, len(out))
+	for i, w := range out {
+		webhooks[i] = w.webhook()
+	}
+	return webhooks, nil
+}
+
+// Get returns a webhook by ID
+func (s *WebhookService) Get(ctx context.Context, webhookID int64) (*Webhook, error) {
+	if err := s.client.requireFeature(FeatureWebhooks); err != nil {
+		return nil, err
+	}
+	var out webhookJSON
+	resp, err := s.client.do(ctx, http.MethodGet, fmt.Sprintf("/webhooks/%d", webhookID), nil, nil, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to get webhook %d", webhookID), false)
+	}
+	webhook := out.webhook()
+	return &webhook, nil
+}
+
+// Create registers a webhook
+func (s *WebhookService) Create(ctx context.Context, input *WebhookInput) (*Webhook, error) {
+	if err := s.client.requireFeature(FeatureWebhooks); err != nil {
+		return nil, err
+	}
+	body, err := input.body()
+	if err != nil {
+		return nil, err
+	}
+	var out webhookJSON
+	resp, err := s.client.do(ctx, http.MethodPost, "/webhooks", nil, body, &out)
+	if err != nil {
+		return nil, s.client.featureError(FeatureWebhooks, resp, err, "failed to create webhook")
+	}
+	webhook := out.webhook()
+	return &webhook, nil
+}
+
+// Update replaces a webhook's configuration. An empty Secret removes the secret.
+func (s *WebhookService) Update(ctx context.Context, webhookID int64, input *WebhookInput) (*Webhook, error) {
+	if err := s.client.requireFeature(FeatureWebhooks); err != nil {
+		return nil, err
+	}
+	body, err := input.body()
+	if err != nil {
+		return nil, err
+	}
+	var out webhookJSON
+	resp, err := s.client.do(ctx, http.MethodPut, fmt.Sprintf("/webhooks/%d", webhookID), nil, body, &out)
+	if err != nil {
+		return nil, convertRequestError(resp, err, fmt.Sprintf("failed to update webhook %d", webhookID), false)
+	}
+	webhook := out.webhook()
+	return &webhook, nil
+}
+
+// Delete removes a webhook
+func (s *WebhookService) Delete(ctx context.Context, webhookID int64) error {
+	if err := s.client.requireFeature(FeatureWebhooks); err != nil {
+		return err
+	}
+	resp, err := s.client.do(ctx, http.MethodDelete, fmt.Sprintf("/webhooks/%d", webhookID), nil, nil, nil)
+	return convertRequestError(resp, err, fmt.Sprintf("failed to delete webhook %d", webhookID), false)
+}
+
+// body validates the input and builds the request body. The server silently drops
+// unknown events, so they are rejected here instead.
+func (input *WebhookInput) body() (*webhookRequestJSON, error) {
+	if input == nil {
+		return nil, NewInvalidConfigError("input is required", nil)
+	}
+	if strings.TrimSpace(input.URL) == "" {
+		return nil, NewInvalidConfigError("webhook URL is required", nil)
+	}
+	if len(input.Events) == 0 {
+		return nil, NewInvalidConfigError("at least one webhook event is required", nil)
+	}
+	events := make([]string, len(input.Events))
+	for i, e := range input.Events {
+		if !e.Valid() {
+			return nil, NewInvalidConfigError(fmt.Sprintf("unknown webhook event %q", e), nil)
+		}
+		events[i] = string(e)
+	}
+	enabled := true
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+	return &webhookRequestJSON{URL: input.URL, Events: events, Secret: input.Secret, Enabled: enabled}, nil
+}
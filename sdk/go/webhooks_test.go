@@ -0,0 +1,115 @@
+package flagent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookResponse = `{"id": 3, "url": "https://hooks.example.com/flagent", "events": ["flag.created", "flag.deleted"],
+	"secret": "s3cret", "enabled": true, "tenantId": "acme", "createdAt": 1767225600000, "updatedAt": 1767225600000}`
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Create", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/webhooks", r.URL.Path)
+			assert.Equal(t, "https://hooks.example.com/flagent", body["url"])
+			assert.Equal(t, []interface{}{"flag.created", "flag.deleted"}, body["events"])
+			assert.Equal(t, "s3cret", body["secret"])
+			assert.Equal(t, true, body["enabled"])
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(webhookResponse))
+		})
+		webhook, err := client.Webhooks().Create(ctx, &WebhookInput{
+			URL:    "https://hooks.example.com/flagent",
+			Events: []WebhookEvent{WebhookFlagCreated, WebhookFlagDeleted},
+			Secret: "s3cret",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), webhook.ID)
+		assert.Equal(t, []WebhookEvent{WebhookFlagCreated, WebhookFlagDeleted}, webhook.Events)
+		assert.Equal(t, "acme", webhook.TenantID)
+		assert.True(t, webhook.CreatedAt.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Create validates input", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			t.Fatal("invalid input must not reach the server")
+		})
+		for name, input := range map[string]*WebhookInput{
+			"nil":           nil,
+			"no URL":        {Events: []WebhookEvent{WebhookFlagUpdated}},
+			"no events":     {URL: "https://hooks.example.com"},
+			"unknown event": {URL: "https://hooks.example.com", Events: []WebhookEvent{"flag.renamed"}},
+		} {
+			_, err := client.Webhooks().Create(ctx, input)
+			var configErr *InvalidConfigError
+			assert.True(t, errors.As(err, &configErr), name)
+		}
+	})
+
+	t.Run("List and Get", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			switch r.URL.Path {
+			case "/webhooks":
+				w.Write([]byte(`[` + webhookResponse + `, {"id": 4, "url": "https://other.example.com", "events": ["flag.enabled"], "enabled": false, "createdAt": 0, "updatedAt": 0}]`))
+			case "/webhooks/3":
+				w.Write([]byte(webhookResponse))
+			default:
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+		})
+		webhooks, err := client.Webhooks().List(ctx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 2)
+		assert.Equal(t, "", webhooks[1].Secret)
+		assert.False(t, webhooks[1].Enabled)
+
+		webhook, err := client.Webhooks().Get(ctx, 3)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", webhook.Secret)
+	})
+
+	t.Run("Update can disable", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "/webhooks/3", r.URL.Path)
+			assert.Equal(t, false, body["enabled"])
+			assert.NotContains(t, body, "secret")
+			w.Write([]byte(webhookResponse))
+		})
+		disabled := false
+		_, err := client.Webhooks().Update(ctx, 3, &WebhookInput{
+			URL:     "https://hooks.example.com/flagent",
+			Events:  WebhookFlagEvents(),
+			Enabled: &disabled,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Delete not found", func(t *testing.T) {
+		client := newManagementServer(t, func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			assert.Equal(t, http.MethodDelete, r.Method)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`"Webhook not found"`))
+		})
+		err := client.Webhooks().Delete(ctx, 9)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.False(t, errors.Is(err, ErrFlagNotFound))
+	})
+}
+
+func TestWebhookEvents(t *testing.T) {
+	assert.Len(t, AllWebhookEvents(), 6)
+	assert.True(t, WebhookAnomalyDetected.Valid())
+	assert.False(t, WebhookEvent("flag.renamed").Valid())
+}